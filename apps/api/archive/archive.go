@@ -0,0 +1,142 @@
+// Package archive 以可攜式封存檔備份/還原資料：一個 zip，內含 manifest.json 與每個資料表一個 JSON Lines 檔。
+//
+// 封存檔保留原始 ID 與時間戳記，可在 SQLite 與 PostgreSQL 之間互相還原。
+package archive
+
+import (
+	"time"
+)
+
+// FormatVersion 封存檔格式版本（檔案結構改變時遞增）
+const FormatVersion = 1
+
+// manifestName zip 中 manifest 的檔名
+const manifestName = "manifest.json"
+
+// Manifest 封存檔描述
+type Manifest struct {
+	Format        string      `json:"format"`        // 固定為 "duellog-archive"
+	FormatVersion int         `json:"formatVersion"` // 見 FormatVersion
+	SchemaVersion int         `json:"schemaVersion"` // 匯出時資料庫已套用的最新 migration 版本
+	CreatedAt     time.Time   `json:"createdAt"`
+	Dialect       string      `json:"dialect"`             // 來源資料庫種類（sqlite / postgres）
+	UserEmail     string      `json:"userEmail,omitempty"` // 只匯出單一使用者時的帳號
+	Tables        []TableInfo `json:"tables"`
+}
+
+// TableInfo 封存檔中一個資料表的資訊
+type TableInfo struct {
+	Name    string   `json:"name"`
+	File    string   `json:"file"`
+	Columns []string `json:"columns"`
+	Rows    int      `json:"rows"`
+}
+
+const formatName = "duellog-archive"
+
+// tableSpec 資料表的匯出/還原規則
+type tableSpec struct {
+	name string
+	// naturalKey ID 不存在時，用來找出內容相同的既有資料列（e.g. 相同 email 的使用者）
+	naturalKey []string
+	// refs 參照其他資料表 ID 的欄位 → 資料表名稱（還原時對應到既有資料列的 ID）
+	refs map[string]string
+	// userColumn 存放使用者 ID 的欄位（只匯出/還原單一使用者時使用）
+	userColumn string
+	// userOmit 只匯出單一使用者時不匯出的欄位（e.g. 密碼雜湊：封存檔會被下載、帶出伺服器）
+	userOmit []string
+	// userFilter 沒有使用者欄位的子資料表，只匯出單一使用者時的條件（一個 ? 為使用者 ID）
+	userFilter string
+	// userRestore 還原到單一使用者時的處理方式
+	userRestore restoreMode
+	// parent 對局的子資料表中參照對局的欄位：還原到單一使用者時，只新增這次新增的對局的資料列，
+	// 不會替既有的對局加上各局記錄或標籤
+	parent string
+}
+
+// restoreMode 還原到單一使用者時，資料表的處理方式
+type restoreMode int
+
+const (
+	restoreInsert  restoreMode = iota // 新增不存在的資料列（使用者自己的資料，或建立對局時本來就會建立的 decks）
+	restoreMapOnly                    // 共用資料：只對應到既有的資料列，不新增
+	restoreNone                       // 不還原（e.g. audit_log 是伺服器的紀錄，不接受使用者上傳）
+)
+
+// tables 封存的資料表（依外鍵相依順序）
+var tables = []tableSpec{
+	{name: "users", naturalKey: []string{"email"}, userColumn: "id", userOmit: []string{"password_hash"}},
+	{name: "games", naturalKey: []string{"key"}, userRestore: restoreMapOnly},
+	{name: "seasons", naturalKey: []string{"game_id", "code"}, refs: map[string]string{"game_id": "games"}, userRestore: restoreMapOnly},
+	{name: "decks", naturalKey: []string{"game_id", "main", "sub"}, refs: map[string]string{"game_id": "games"}},
+	{
+		name:        "deck_templates",
+		naturalKey:  []string{"game_id", "main", "deck_type"},
+		refs:        map[string]string{"game_id": "games"},
+		userRestore: restoreMapOnly,
+	},
+	{
+		name:        "ranks",
+		naturalKey:  []string{"game_id", "mode", "name"},
+		refs:        map[string]string{"game_id": "games"},
+		userRestore: restoreMapOnly,
+	},
+	{
+		name:        "deck_aliases",
+		naturalKey:  []string{"game_id", "alias"},
+		refs:        map[string]string{"game_id": "games", "template_id": "deck_templates"},
+		userRestore: restoreMapOnly,
+	},
+	{
+		name: "matches",
+		refs: map[string]string{
+			"user_id":     "users",
+			"game_id":     "games",
+			"season_id":   "seasons",
+			"my_deck_id":  "decks",
+			"opp_deck_id": "decks",
+			"rank_id":     "ranks",
+		},
+		userColumn: "user_id",
+	},
+	{
+		name:       "tags",
+		naturalKey: []string{"user_id", "name"},
+		refs:       map[string]string{"user_id": "users"},
+		userColumn: "user_id",
+	},
+	{
+		name:       "match_tags",
+		naturalKey: []string{"match_id", "tag_id"},
+		refs:       map[string]string{"match_id": "matches", "tag_id": "tags"},
+		userFilter: "match_id IN (SELECT id FROM matches WHERE user_id = ?)",
+		parent:     "match_id",
+	},
+	{
+		name:       "match_games",
+		naturalKey: []string{"match_id", "game_no"},
+		refs:       map[string]string{"match_id": "matches"},
+		userFilter: "match_id IN (SELECT id FROM matches WHERE user_id = ?)",
+		parent:     "match_id",
+	},
+	{
+		name:        "audit_log",
+		refs:        map[string]string{"user_id": "users"},
+		userColumn:  "user_id",
+		userRestore: restoreNone,
+	},
+}
+
+// isDateType DATE 欄位以 YYYY-MM-DD 保存
+func isDateType(dbType string) bool {
+	return dbType == "DATE"
+}
+
+// isTimestampType DATETIME/TIMESTAMP 欄位以 RFC 3339 保存
+func isTimestampType(dbType string) bool {
+	switch dbType {
+	case "DATETIME", "TIMESTAMP", "TIMESTAMPTZ":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+// seedSource 來源資料庫：user-a、user-b 各有對局、各局記錄與變更紀錄；
+// m-a 在 S40、m-s99 在只有來源資料庫才有的賽季 S99，牌組模板 tpl-new 也只在來源資料庫
+func seedSource(t *testing.T, db *database.DB) {
+	t.Helper()
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, password_hash) VALUES ('user-a', 'a@example.com', 'hash-a'), ('user-b', 'b@example.com', 'hash-b')`,
+		`INSERT INTO seasons (id, game_id, code) VALUES ('season-40', 'game-md', 'S40'), ('season-99', 'game-md', 'S99')`,
+		`INSERT INTO decks (id, game_id, main, sub) VALUES ('deck-1', 'game-md', '蛇眼', NULL), ('deck-2', 'game-md', '天盃龍', NULL)`,
+		`INSERT INTO deck_templates (id, game_id, main, theme, deck_type) VALUES ('tpl-new', 'game-md', '新牌組', '無', 'main')`,
+		`INSERT INTO matches (id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id, play_order, result) VALUES
+			('m-a', 'user-a', 'game-md', 'season-40', '2025-01-02', '金 IV', 'deck-1', 'deck-2', '先攻', 'W'),
+			('m-s99', 'user-a', 'game-md', 'season-99', '2025-02-02', '金 IV', 'deck-1', 'deck-2', '後攻', 'L'),
+			('m-b', 'user-b', 'game-md', 'season-40', '2025-01-03', '金 IV', 'deck-2', 'deck-1', '先攻', 'W')`,
+		`INSERT INTO match_games (id, match_id, game_no, play_order, result) VALUES
+			('g-a1', 'm-a', 1, '先攻', 'W'), ('g-a2', 'm-a', 2, '後攻', 'W'),
+			('g-b1', 'm-b', 1, '先攻', 'L'), ('g-b2', 'm-b', 2, '後攻', 'L')`,
+		`INSERT INTO tags (id, user_id, name) VALUES ('tag-a', 'user-a', '卡手')`,
+		`INSERT INTO match_tags (id, match_id, tag_id) VALUES ('mt-a', 'm-a', 'tag-a')`,
+		`INSERT INTO audit_log (id, user_id, entity, entity_id, action) VALUES
+			('audit-a', 'user-a', 'match', 'm-a', 'create'), ('audit-b', 'user-b', 'match', 'm-b', 'create')`,
+	} {
+		testdb.MustExec(t, db, stmt)
+	}
+}
+
+// seedTarget 目標資料庫：user-a、user-b 已存在，S40 的 ID 與來源不同，m-b 已是 user-b 的對局
+func seedTarget(t *testing.T, db *database.DB) {
+	t.Helper()
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, password_hash) VALUES ('user-a', 'a@example.com', 'x'), ('user-b', 'b@example.com', 'x')`,
+		`INSERT INTO seasons (id, game_id, code) VALUES ('season-target', 'game-md', 'S40')`,
+		`INSERT INTO decks (id, game_id, main, sub) VALUES ('deck-target', 'game-md', '天盃龍', NULL)`,
+		`INSERT INTO matches (id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id, play_order, result, note) VALUES
+			('m-b', 'user-b', 'game-md', 'season-target', '2025-01-03', '金 IV', 'deck-target', 'deck-target', '先攻', 'W', '原本的')`,
+	} {
+		testdb.MustExec(t, db, stmt)
+	}
+}
+
+func exportArchive(t *testing.T, db *database.DB, userID string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := Export(db, &buf, ExportOptions{UserID: userID}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// readTable 封存檔中一個資料表的所有資料列
+func readTable(t *testing.T, data []byte, name string) []map[string]interface{} {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	f, err := zr.Open(name + ".jsonl")
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+	records := []map[string]interface{}{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// rewriteManifest 修改封存檔的 manifest
+func rewriteManifest(t *testing.T, data []byte, modify func(*Manifest)) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	manifest, err := ReadManifest(zr)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	modify(manifest)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if f.Name == manifestName {
+			err = json.NewEncoder(w).Encode(manifest)
+		} else {
+			var rc io.ReadCloser
+			if rc, err = f.Open(); err == nil {
+				_, err = io.Copy(w, rc)
+				rc.Close()
+			}
+		}
+		if err != nil {
+			t.Fatalf("rewrite %s: %v", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func tableResult(t *testing.T, result *ImportResult, name string) TableResult {
+	t.Helper()
+	for _, tr := range result.Tables {
+		if tr.Name == name {
+			return tr
+		}
+	}
+	t.Fatalf("no result for %s in %+v", name, result.Tables)
+	return TableResult{}
+}
+
+func countRows(t *testing.T, db *database.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestExportUserArchive(t *testing.T) {
+	db := testdb.Open(t)
+	seedSource(t, db)
+	data := exportArchive(t, db, "user-a")
+
+	users := readTable(t, data, "users")
+	if len(users) != 1 || users[0]["email"] != "a@example.com" {
+		t.Fatalf("users = %v, want only user-a", users)
+	}
+	if _, ok := users[0]["password_hash"]; ok {
+		t.Error("user archive includes password_hash")
+	}
+	zr, _ := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	manifest, err := ReadManifest(zr)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	if cols := manifest.table("users").Columns; strings.Contains(strings.Join(cols, ","), "password_hash") {
+		t.Errorf("manifest users columns = %v, want no password_hash", cols)
+	}
+	if manifest.UserEmail != "a@example.com" {
+		t.Errorf("manifest user = %q, want a@example.com", manifest.UserEmail)
+	}
+
+	for table, want := range map[string][]string{
+		"matches":     {"m-a", "m-s99"},
+		"match_games": {"g-a1", "g-a2"},
+		"audit_log":   {"audit-a"},
+	} {
+		ids := []string{}
+		for _, r := range readTable(t, data, table) {
+			ids = append(ids, r["id"].(string))
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("%s = %v, want %v", table, ids, want)
+		}
+	}
+
+	// 完整匯出保留密碼雜湊（cmd/export-archive 搬移整個資料庫）
+	if users := readTable(t, exportArchive(t, db, ""), "users"); len(users) != 2 || users[0]["password_hash"] != "hash-a" {
+		t.Errorf("full archive users = %v, want both with password hashes", users)
+	}
+}
+
+func TestImportToUser(t *testing.T) {
+	src := testdb.Open(t)
+	seedSource(t, src)
+	data := exportArchive(t, src, "")
+
+	dst := testdb.Open(t)
+	seedTarget(t, dst)
+
+	var restored []string
+	result, err := Import(dst, bytes.NewReader(data), int64(len(data)), ImportOptions{
+		UserID: "user-a",
+		AfterRestore: func(q database.Querier, matchIDs []string) error {
+			restored = matchIDs
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	want := map[string]TableResult{
+		"users":          {Rows: 2, Skipped: 2},
+		"seasons":        {Rows: 2, Skipped: 1, Rejected: 1}, // S99 不存在，不會新增
+		"deck_templates": {Rejected: 1},                      // tpl-new 不存在，不會新增
+		"matches":        {Rows: 3, Inserted: 1, Rejected: 2},
+		"match_games":    {Rows: 4, Inserted: 2, Rejected: 2},
+		"match_tags":     {Rows: 1, Inserted: 1},
+		"audit_log":      {Rows: 2, Rejected: 2},
+	}
+	for name, w := range want {
+		got := tableResult(t, result, name)
+		if got.Inserted != w.Inserted || got.Rejected != w.Rejected || (w.Rows > 0 && (got.Rows != w.Rows || got.Skipped != w.Skipped)) {
+			t.Errorf("%s = %+v, want %+v", name, got, w)
+		}
+	}
+	if !reflect.DeepEqual(restored, []string{"m-a"}) {
+		t.Errorf("AfterRestore matches = %v, want [m-a]", restored)
+	}
+
+	// m-a 改屬於 user-a，賽季對應到目標資料庫的 S40
+	var userID, seasonID string
+	if err := dst.QueryRow("SELECT user_id, season_id FROM matches WHERE id = 'm-a'").Scan(&userID, &seasonID); err != nil {
+		t.Fatalf("select m-a: %v", err)
+	}
+	if userID != "user-a" || seasonID != "season-target" {
+		t.Errorf("m-a = %s, %s; want user-a, season-target", userID, seasonID)
+	}
+	// 其他使用者的對局與其各局記錄不受影響
+	var note string
+	if err := dst.QueryRow("SELECT user_id, note FROM matches WHERE id = 'm-b'").Scan(&userID, &note); err != nil || userID != "user-b" || note != "原本的" {
+		t.Errorf("m-b = %s, %q, %v; want user-b's match unchanged", userID, note, err)
+	}
+	if n := countRows(t, dst, "SELECT COUNT(*) FROM match_games WHERE match_id = 'm-b'"); n != 0 {
+		t.Errorf("%d games added to user-b's match, want 0", n)
+	}
+	// 共用資料不會新增
+	if n := countRows(t, dst, "SELECT COUNT(*) FROM seasons WHERE code = 'S99'") + countRows(t, dst, "SELECT COUNT(*) FROM deck_templates WHERE id = 'tpl-new'"); n != 0 {
+		t.Errorf("%d shared rows inserted, want 0", n)
+	}
+	if n := countRows(t, dst, "SELECT COUNT(*) FROM audit_log"); n != 0 {
+		t.Errorf("%d audit_log rows restored, want 0", n)
+	}
+	if n := countRows(t, dst, "SELECT COUNT(*) FROM users WHERE password_hash = 'hash-a'"); n != 0 {
+		t.Error("archived users overwrote or were added to the target")
+	}
+
+	// 再還原一次：對局已存在，不會重複新增
+	result, err = Import(dst, bytes.NewReader(data), int64(len(data)), ImportOptions{UserID: "user-a"})
+	if err != nil {
+		t.Fatalf("second Import: %v", err)
+	}
+	if tr := tableResult(t, result, "matches"); tr.Inserted != 0 || tr.Skipped != 1 {
+		t.Errorf("second import matches = %+v, want m-a skipped", tr)
+	}
+	if tr := tableResult(t, result, "match_games"); tr.Inserted != 0 {
+		t.Errorf("second import match_games = %+v, want none inserted", tr)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	src := testdb.Open(t)
+	seedSource(t, src)
+	data := exportArchive(t, src, "user-a")
+
+	dst := testdb.Open(t)
+	seedTarget(t, dst)
+	tables := []string{"matches", "match_games", "match_tags", "tags", "decks", "audit_log"}
+	before := map[string]int{}
+	for _, table := range tables {
+		before[table] = countRows(t, dst, "SELECT COUNT(*) FROM "+table)
+	}
+
+	result, err := Import(dst, bytes.NewReader(data), int64(len(data)), ImportOptions{
+		UserID: "user-a",
+		DryRun: true,
+		AfterRestore: func(q database.Querier, matchIDs []string) error {
+			_, err := q.Exec("INSERT INTO audit_log (id, user_id, entity, entity_id, action) VALUES ('audit-new', 'user-a', 'match', 'm-a', 'create')")
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !result.DryRun || tableResult(t, result, "matches").Inserted != 1 {
+		t.Errorf("dry run result = %+v, want 1 match counted", result.Tables)
+	}
+	for _, table := range tables {
+		if n := countRows(t, dst, "SELECT COUNT(*) FROM "+table); n != before[table] {
+			t.Errorf("%s has %d rows after a dry run, want %d", table, n, before[table])
+		}
+	}
+}
+
+func TestImportIncompatible(t *testing.T) {
+	src := testdb.Open(t)
+	seedSource(t, src)
+	full := exportArchive(t, src, "")
+	dst := testdb.Open(t)
+	current, err := database.CurrentVersion(dst)
+	if err != nil {
+		t.Fatalf("CurrentVersion: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		userID string
+		reason string
+	}{
+		{"newer schema", rewriteManifest(t, full, func(m *Manifest) { m.SchemaVersion = current + 1 }), "user-a", "schema 版本"},
+		{"newer format", rewriteManifest(t, full, func(m *Manifest) { m.FormatVersion = FormatVersion + 1 }), "user-a", "格式版本"},
+		{"unknown table", rewriteManifest(t, full, func(m *Manifest) { m.Tables = append(m.Tables, TableInfo{Name: "secrets"}) }), "user-a", "未知的資料表"},
+		{"unknown column", rewriteManifest(t, full, func(m *Manifest) {
+			m.table("matches").Columns = append(m.table("matches").Columns, "elo")
+		}), "user-a", "缺少欄位 elo"},
+		{"not an archive", rewriteManifest(t, full, func(m *Manifest) { m.Format = "other" }), "user-a", "不是 DuelLog 封存檔"},
+		{"not a zip", []byte("not a zip"), "user-a", "無法讀取 zip"},
+		{"user archive without user", exportArchive(t, src, "user-a"), "", "只能還原到既有的帳號"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(dst, bytes.NewReader(tt.data), int64(len(tt.data)), ImportOptions{UserID: tt.userID})
+			if !errors.Is(err, ErrIncompatible) || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("Import = %v, want ErrIncompatible containing %q", err, tt.reason)
+			}
+		})
+	}
+	if n := countRows(t, dst, "SELECT COUNT(*) FROM matches"); n != 0 {
+		t.Errorf("%d matches restored from incompatible archives, want 0", n)
+	}
+}
@@ -0,0 +1,162 @@
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// ExportOptions 匯出設定
+type ExportOptions struct {
+	// UserID 非空時只匯出該使用者（users 只含本人且不含密碼雜湊、matches 只含本人的對局）；
+	// games、seasons、decks、deck_templates 為共用資料，一律完整匯出。
+	UserID string
+}
+
+// Export 將資料寫成 zip 封存檔
+// 所有資料表在同一個交易中讀取，匯出期間伺服器仍在寫入也能得到一致的快照。
+func Export(db *database.DB, w io.Writer, opts ExportOptions) (*Manifest, error) {
+	schemaVersion, err := database.CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	manifest := &Manifest{
+		Format:        formatName,
+		FormatVersion: FormatVersion,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     time.Now().UTC(),
+		Dialect:       string(db.Dialect),
+		Tables:        []TableInfo{},
+	}
+	if opts.UserID != "" {
+		if err := tx.QueryRow("SELECT email FROM users WHERE id = ?", opts.UserID).Scan(&manifest.UserEmail); err != nil {
+			return nil, fmt.Errorf("找不到使用者: %w", err)
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	for _, spec := range tables {
+		info, err := exportTable(tx, zw, spec, opts)
+		if err != nil {
+			return nil, fmt.Errorf("匯出 %s 失敗: %w", spec.name, err)
+		}
+		manifest.Tables = append(manifest.Tables, info)
+	}
+
+	mw, err := zw.Create(manifestName)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// exportTable 將一個資料表寫成 JSON Lines（每列一個 JSON 物件）
+func exportTable(q database.Querier, zw *zip.Writer, spec tableSpec, opts ExportOptions) (TableInfo, error) {
+	info := TableInfo{Name: spec.name, File: spec.name + ".jsonl"}
+
+	query := "SELECT * FROM " + spec.name
+	args := []interface{}{}
+	if opts.UserID != "" && spec.userColumn != "" {
+		query += " WHERE " + spec.userColumn + " = ?"
+		args = append(args, opts.UserID)
+	} else if opts.UserID != "" && spec.userFilter != "" {
+		query += " WHERE " + spec.userFilter
+		args = append(args, opts.UserID)
+	}
+	query += " ORDER BY id"
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return info, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return info, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return info, err
+	}
+	omit := map[string]bool{}
+	if opts.UserID != "" {
+		for _, col := range spec.userOmit {
+			omit[col] = true
+		}
+	}
+	for _, col := range columns {
+		if !omit[col] {
+			info.Columns = append(info.Columns, col)
+		}
+	}
+
+	fw, err := zw.Create(info.File)
+	if err != nil {
+		return info, err
+	}
+	bw := bufio.NewWriter(fw)
+	enc := json.NewEncoder(bw)
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return info, err
+		}
+		record := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if !omit[col] {
+				record[col] = exportValue(values[i], strings.ToUpper(types[i].DatabaseTypeName()))
+			}
+		}
+		if err := enc.Encode(record); err != nil {
+			return info, err
+		}
+		info.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return info, err
+	}
+	return info, bw.Flush()
+}
+
+// exportValue 將 driver 回傳的值轉為 JSON 友善的格式
+func exportValue(v interface{}, dbType string) interface{} {
+	switch val := v.(type) {
+	case time.Time:
+		if isDateType(dbType) {
+			return val.Format("2006-01-02")
+		}
+		return val.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(val)
+	case nil:
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,360 @@
+package archive
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// ImportOptions 還原設定
+type ImportOptions struct {
+	// UserID 非空時還原到該使用者：略過封存檔中的 users，所有對局改為屬於此使用者。
+	// 共用的資料（遊戲、賽季、牌組模板 …）只對應到既有的資料列，不會新增；
+	// 參照了封存檔以外（或其他使用者）資料的資料列、既有對局的各局記錄與標籤不會還原，見 TableResult.Rejected
+	UserID string
+	// DryRun 只驗證並計算筆數，不寫入
+	DryRun bool
+	// AfterRestore 所有資料表還原後、commit 前在同一個交易中呼叫，matchIDs 為新增的對局（e.g. 寫入變更紀錄），可為 nil
+	AfterRestore func(q database.Querier, matchIDs []string) error
+}
+
+// TableResult 單一資料表的還原結果
+type TableResult struct {
+	Name     string `json:"name"`
+	Rows     int    `json:"rows"`
+	Inserted int    `json:"inserted"`
+	Skipped  int    `json:"skipped"`  // ID 或內容（見 tableSpec.naturalKey）已存在
+	Rejected int    `json:"rejected"` // 還原到單一使用者時不允許的資料列（見 ImportOptions.UserID）
+}
+
+// ImportResult 還原結果
+type ImportResult struct {
+	DryRun        bool          `json:"dryRun"`
+	Manifest      *Manifest     `json:"manifest"`
+	SchemaVersion int           `json:"schemaVersion"` // 目標資料庫的 schema 版本
+	Tables        []TableResult `json:"tables"`
+}
+
+// ErrIncompatible 封存檔與目前的資料庫 schema 不相容
+var ErrIncompatible = errors.New("封存檔與目前的資料庫不相容")
+
+// ReadManifest 讀取封存檔的 manifest
+func ReadManifest(zr *zip.Reader) (*Manifest, error) {
+	f, err := zr.Open(manifestName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 缺少 %s", ErrIncompatible, manifestName)
+	}
+	defer f.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: manifest 格式錯誤: %v", ErrIncompatible, err)
+	}
+	if m.Format != formatName {
+		return nil, fmt.Errorf("%w: 不是 DuelLog 封存檔", ErrIncompatible)
+	}
+	if m.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%w: 封存檔格式版本 %d 較新（目前支援 %d）", ErrIncompatible, m.FormatVersion, FormatVersion)
+	}
+	return &m, nil
+}
+
+// Verify 檢查封存檔是否能還原到目前的資料庫：
+// schema 版本不可比資料庫新，且每個資料表、欄位都必須存在。
+func Verify(db *database.DB, m *Manifest) (int, error) {
+	current, err := database.CurrentVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if m.SchemaVersion > current {
+		return current, fmt.Errorf("%w: 封存檔的 schema 版本 %d 比資料庫 (%d) 新，請先更新程式並執行 migrations", ErrIncompatible, m.SchemaVersion, current)
+	}
+
+	for _, t := range m.Tables {
+		if specFor(t.Name) == nil {
+			return current, fmt.Errorf("%w: 未知的資料表 %s", ErrIncompatible, t.Name)
+		}
+		cols, err := db.TableColumns(t.Name)
+		if err != nil {
+			return current, err
+		}
+		missing := []string{}
+		for _, c := range t.Columns {
+			if _, ok := cols[c]; !ok {
+				missing = append(missing, c)
+			}
+		}
+		if len(missing) > 0 {
+			return current, fmt.Errorf("%w: 資料表 %s 缺少欄位 %s", ErrIncompatible, t.Name, strings.Join(missing, ", "))
+		}
+	}
+	return current, nil
+}
+
+// Import 還原封存檔（單一交易；已存在的資料列會略過，不會覆寫）
+func Import(db *database.DB, r io.ReaderAt, size int64, opts ImportOptions) (*ImportResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 無法讀取 zip: %v", ErrIncompatible, err)
+	}
+	manifest, err := ReadManifest(zr)
+	if err != nil {
+		return nil, err
+	}
+	current, err := Verify(db, manifest)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.UserEmail != "" && opts.UserID == "" {
+		// 單一使用者的封存檔不含密碼雜湊，無法重新建立帳號
+		return nil, fmt.Errorf("%w: %s 的個人封存檔只能還原到既有的帳號（-user）", ErrIncompatible, manifest.UserEmail)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{DryRun: opts.DryRun, Manifest: manifest, SchemaVersion: current, Tables: []TableResult{}}
+	restorer := &restorer{tx: tx, opts: opts, idMap: map[string]map[string]string{}, inserted: map[string][]string{}, newMatches: map[string]bool{}}
+	for _, spec := range tables {
+		info := manifest.table(spec.name)
+		if info == nil {
+			continue
+		}
+		tr, err := restorer.restoreTable(zr, spec, *info)
+		if err != nil {
+			return nil, fmt.Errorf("還原 %s 失敗: %w", spec.name, err)
+		}
+		result.Tables = append(result.Tables, tr)
+	}
+	if opts.AfterRestore != nil {
+		if err := opts.AfterRestore(tx, restorer.inserted["matches"]); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type restorer struct {
+	tx   *database.Tx
+	opts ImportOptions
+	// idMap 資料表 → 封存檔中的 ID → 資料庫中的 ID（新增或對應到的既有資料列）
+	idMap map[string]map[string]string
+	// inserted 資料表 → 這次新增的 ID（依新增順序）
+	inserted map[string][]string
+	// newMatches 這次新增的對局（見 tableSpec.parent）
+	newMatches map[string]bool
+}
+
+func (r *restorer) restoreTable(zr *zip.Reader, spec tableSpec, info TableInfo) (TableResult, error) {
+	tr := TableResult{Name: spec.name}
+	r.idMap[spec.name] = map[string]string{}
+
+	f, err := zr.Open(info.File)
+	if err != nil {
+		return tr, fmt.Errorf("%w: 缺少 %s", ErrIncompatible, info.File)
+	}
+	defer f.Close()
+
+	types, err := r.columnTypes(spec.name)
+	if err != nil {
+		return tr, err
+	}
+	insert := "INSERT INTO " + spec.name + " (" + strings.Join(info.Columns, ", ") + ") VALUES (" +
+		strings.TrimSuffix(strings.Repeat("?, ", len(info.Columns)), ", ") + ")"
+
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	for {
+		var record map[string]interface{}
+		if err := dec.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return tr, fmt.Errorf("第 %d 列格式錯誤: %w", tr.Rows+1, err)
+		}
+		tr.Rows++
+		id, _ := record["id"].(string)
+
+		// 只還原到單一使用者時，封存檔中的使用者都對應到該使用者
+		if r.opts.UserID != "" && spec.name == "users" {
+			r.idMap["users"][id] = r.opts.UserID
+			tr.Skipped++
+			continue
+		}
+
+		if r.opts.UserID != "" && spec.userRestore == restoreNone {
+			tr.Rejected++
+			continue
+		}
+		if r.opts.UserID != "" && spec.userColumn != "" {
+			record[spec.userColumn] = r.opts.UserID
+		}
+
+		// 還原到單一使用者時，參照只能指向這次還原（新增或對應）的資料列，
+		// 避免封存檔把資料掛到其他使用者的對局、標籤上
+		allowed := true
+		for col, ref := range spec.refs {
+			old, ok := record[col].(string)
+			if !ok || (r.opts.UserID != "" && col == spec.userColumn) {
+				continue
+			}
+			if mapped, ok := r.idMap[ref][old]; ok {
+				record[col] = mapped
+			} else if r.opts.UserID != "" {
+				allowed = false
+			}
+		}
+		if !allowed {
+			tr.Rejected++
+			continue
+		}
+
+		existing, conflict, err := r.existingID(spec, record)
+		if err != nil {
+			return tr, err
+		}
+		if existing != "" {
+			r.idMap[spec.name][id] = existing
+			tr.Skipped++
+			continue
+		}
+		if r.opts.UserID != "" && (conflict || spec.userRestore == restoreMapOnly) {
+			tr.Rejected++
+			continue
+		}
+		if parent, _ := record[spec.parent].(string); r.opts.UserID != "" && spec.parent != "" && !r.newMatches[parent] {
+			tr.Rejected++
+			continue
+		}
+
+		args := make([]interface{}, len(info.Columns))
+		for i, col := range info.Columns {
+			args[i] = importValue(record[col], types[col])
+		}
+		if _, err := r.tx.Exec(insert, args...); err != nil {
+			return tr, fmt.Errorf("id %s: %w", id, err)
+		}
+		r.idMap[spec.name][id] = id
+		r.inserted[spec.name] = append(r.inserted[spec.name], id)
+		if spec.name == "matches" {
+			r.newMatches[id] = true
+		}
+		tr.Inserted++
+	}
+	return tr, nil
+}
+
+// existingID 以 ID 或 naturalKey 找出資料庫中已存在的資料列。
+// 還原到單一使用者時，ID 相同但屬於其他使用者的資料列不算存在，並回傳 conflict（無法以同一個 ID 新增）。
+func (r *restorer) existingID(spec tableSpec, record map[string]interface{}) (id string, conflict bool, err error) {
+	err = r.tx.QueryRow("SELECT id FROM "+spec.name+" WHERE id = ?", record["id"]).Scan(&id)
+	if err == nil && r.opts.UserID != "" && spec.userColumn != "" {
+		var owner string
+		if err := r.tx.QueryRow("SELECT "+spec.userColumn+" FROM "+spec.name+" WHERE id = ?", id).Scan(&owner); err != nil {
+			return "", false, err
+		}
+		if owner != r.opts.UserID {
+			id, conflict, err = "", true, sql.ErrNoRows
+		}
+	}
+	if err == nil || len(spec.naturalKey) == 0 {
+		return id, conflict, ignoreNoRows(err)
+	}
+	if err = ignoreNoRows(err); err != nil {
+		return "", false, err
+	}
+
+	conds := []string{}
+	args := []interface{}{}
+	for _, col := range spec.naturalKey {
+		if record[col] == nil {
+			conds = append(conds, col+" IS NULL")
+			continue
+		}
+		conds = append(conds, col+" = ?")
+		args = append(args, record[col])
+	}
+	err = r.tx.QueryRow("SELECT id FROM "+spec.name+" WHERE "+strings.Join(conds, " AND "), args...).Scan(&id)
+	return id, conflict, ignoreNoRows(err)
+}
+
+// columnTypes 目標資料表各欄位的型別（DATETIME、DATE …）
+func (r *restorer) columnTypes(table string) (map[string]string, error) {
+	rows, err := r.tx.Query("SELECT * FROM " + table + " WHERE 1 = 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(cts))
+	for _, ct := range cts {
+		types[ct.Name()] = strings.ToUpper(ct.DatabaseTypeName())
+	}
+	return types, nil
+}
+
+// importValue 將 JSON 值轉回資料庫欄位的型別
+func importValue(v interface{}, dbType string) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	case string:
+		if isTimestampType(dbType) {
+			if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+				return t
+			}
+		}
+		return val
+	}
+	return v
+}
+
+// table 取得 manifest 中的資料表資訊
+func (m *Manifest) table(name string) *TableInfo {
+	for i := range m.Tables {
+		if m.Tables[i].Name == name {
+			return &m.Tables[i]
+		}
+	}
+	return nil
+}
+
+func specFor(name string) *tableSpec {
+	for i := range tables {
+		if tables[i].name == name {
+			return &tables[i]
+		}
+	}
+	return nil
+}
+
+func ignoreNoRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
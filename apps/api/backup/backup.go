@@ -0,0 +1,196 @@
+// Package backup 在伺服器執行中建立 SQLite 快照（VACUUM INTO），並依保留規則清理舊快照。
+//
+// 快照是完整、可直接開啟的 SQLite 檔案；還原時先以 PRAGMA integrity_check 驗證再替換資料庫檔案。
+package backup
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+const (
+	filePrefix = "duellog-"
+	fileSuffix = ".db"
+	timeLayout = "20060102-150405"
+)
+
+// Snapshot 一個快照檔
+type Snapshot struct {
+	Path string
+	Time time.Time // 由檔名解析（本地時間）
+	Size int64
+}
+
+// Create 在 dir 建立快照，回傳快照檔路徑
+// VACUUM INTO 在單一讀取交易中複製資料庫，伺服器持續寫入時也能得到一致的檔案。
+func Create(db *database.DB, dir string, now time.Time) (string, error) {
+	if db.Dialect != database.SQLite {
+		return "", fmt.Errorf("快照只支援 SQLite（PostgreSQL 請使用 pg_dump 或 cmd/export-archive）")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, filePrefix+now.Format(timeLayout)+fileSuffix)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("快照已存在: %s", path)
+	}
+	// 先寫到暫存檔再改名，清理或列出快照時不會看到寫到一半的檔案
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if _, err := db.Exec("VACUUM INTO ?", tmp); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("vacuum into: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return path, nil
+}
+
+// List 列出 dir 中的快照（由新到舊）；dir 不存在時回傳空列表
+func List(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		t, err := time.ParseInLocation(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix), time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, Snapshot{Path: filepath.Join(dir, name), Time: t, Size: info.Size()})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time.After(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+// Verify 以 PRAGMA integrity_check 檢查 SQLite 檔案，並確認是 DuelLog 的資料庫
+func Verify(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	// mode=ro：驗證時不會建立 journal 或修改檔案
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("integrity_check: %w", err)
+	}
+	defer rows.Close()
+	problems := []string{}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("integrity_check: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity_check 失敗: %s", strings.Join(problems, "; "))
+	}
+
+	var n int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('users', 'matches')").Scan(&n); err != nil {
+		return err
+	}
+	if n != 2 {
+		return fmt.Errorf("%s 不是 DuelLog 的資料庫", path)
+	}
+	return nil
+}
+
+// Restore 以快照取代資料庫檔案 target（伺服器必須先停止）
+// 快照會先驗證，再複製到 target 旁的暫存檔並再次驗證，最後才替換；
+// 原本的資料庫改名為 <target>.before-restore-YYYYMMDD-HHMMSS 保留，回傳該路徑（target 不存在時為空字串）。
+func Restore(snapshot, target string, now time.Time) (string, error) {
+	if err := Verify(snapshot); err != nil {
+		return "", fmt.Errorf("快照驗證失敗: %w", err)
+	}
+
+	tmp := target + ".restoring"
+	if err := copyFile(snapshot, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := Verify(tmp); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("複製後驗證失敗: %w", err)
+	}
+
+	previous := ""
+	if _, err := os.Stat(target); err == nil {
+		previous = target + ".before-restore-" + now.Format(timeLayout)
+		if err := os.Rename(target, previous); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+		// 殘留的 journal 屬於舊的資料庫，一起移走，避免套用到還原後的檔案
+		for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+			if _, err := os.Stat(target + suffix); err == nil {
+				if err := os.Rename(target+suffix, previous+suffix); err != nil {
+					return previous, err
+				}
+			}
+		}
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return previous, err
+	}
+	return previous, nil
+}
+
+// copyFile 複製檔案並 fsync
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
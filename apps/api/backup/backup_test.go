@@ -0,0 +1,171 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// openDuelLogDB 只有 users、matches 兩個資料表的 SQLite 資料庫（Verify 只檢查這兩個），matches 有 n 筆資料
+func openDuelLogDB(t *testing.T, path string, n int) *database.DB {
+	t.Helper()
+	db, err := database.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range []string{
+		"CREATE TABLE users (id TEXT PRIMARY KEY)",
+		"CREATE TABLE matches (id INTEGER PRIMARY KEY, note TEXT)",
+		"CREATE INDEX idx_matches_note ON matches(note)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if _, err := db.Exec("INSERT INTO matches (note) VALUES (?)", strings.Repeat("卡手", 50)); err != nil {
+			t.Fatalf("insert match: %v", err)
+		}
+	}
+	return db
+}
+
+func countMatches(t *testing.T, path string) int {
+	t.Helper()
+	db, err := database.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM matches").Scan(&n); err != nil {
+		t.Fatalf("count matches: %v", err)
+	}
+	return n
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	db := openDuelLogDB(t, filepath.Join(dir, "live.db"), 3)
+	now := time.Date(2025, 3, 12, 15, 4, 5, 0, time.Local)
+
+	snapshot, err := Create(db, filepath.Join(dir, "backups"), now)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if filepath.Base(snapshot) != "duellog-20250312-150405.db" {
+		t.Errorf("Create = %s, want duellog-20250312-150405.db", snapshot)
+	}
+	if _, err := Create(db, filepath.Join(dir, "backups"), now); err == nil {
+		t.Error("second Create with the same time succeeded, want an error")
+	}
+	snapshots, err := List(filepath.Join(dir, "backups"))
+	if err != nil || len(snapshots) != 1 || !snapshots[0].Time.Equal(now) || snapshots[0].Size == 0 {
+		t.Fatalf("List = %+v, %v; want the snapshot", snapshots, err)
+	}
+
+	// 還原到已有資料的資料庫：原本的檔案與 journal 一併改名保留
+	target := filepath.Join(dir, "target.db")
+	openDuelLogDB(t, target, 1).Close()
+	if err := os.WriteFile(target+"-journal", []byte("stale"), 0o644); err != nil {
+		t.Fatalf("write journal: %v", err)
+	}
+	previous, err := Restore(snapshot, target, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if want := target + ".before-restore-20250312-160405"; previous != want {
+		t.Errorf("Restore = %s, want %s", previous, want)
+	}
+	if n := countMatches(t, target); n != 3 {
+		t.Errorf("restored database has %d matches, want 3", n)
+	}
+	if _, err := os.Stat(previous + "-journal"); err != nil {
+		t.Errorf("journal was not moved with the previous database: %v", err)
+	}
+	if n := countMatches(t, previous); n != 1 {
+		t.Errorf("previous database has %d matches, want 1", n)
+	}
+	if _, err := os.Stat(target + ".restoring"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	// target 不存在時不需要保留
+	if previous, err := Restore(snapshot, filepath.Join(dir, "new.db"), now); err != nil || previous != "" {
+		t.Errorf("Restore to a new file = %q, %v; want no previous file", previous, err)
+	}
+}
+
+func TestRestoreRejectsInvalidSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	db := openDuelLogDB(t, filepath.Join(dir, "live.db"), 200)
+	snapshot, err := Create(db, dir, time.Now())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	valid, err := os.ReadFile(snapshot)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	// 第 3 頁之後的內容換成亂碼：檔頭正常，integrity_check 才看得出問題
+	corrupted := append([]byte{}, valid...)
+	for i := 2 * 4096; i < len(corrupted) && i < 6*4096; i++ {
+		corrupted[i] = byte(i * 7)
+	}
+	other := filepath.Join(dir, "other.db")
+	otherDB, err := database.OpenSQLite(other)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	if _, err := otherDB.Exec("CREATE TABLE notes (id TEXT)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	otherDB.Close()
+
+	tests := []struct {
+		name     string
+		snapshot string
+		reason   string
+	}{
+		{"corrupted pages", write("corrupted.db", corrupted), "integrity_check"},
+		{"truncated", write("truncated.db", valid[:len(valid)/2]), "integrity_check"},
+		{"not sqlite", write("text.db", []byte(strings.Repeat("not a database\n", 500))), "integrity_check"},
+		{"other database", other, "不是 DuelLog 的資料庫"},
+		{"missing", filepath.Join(dir, "missing.db"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "target.db")
+			openDuelLogDB(t, target, 1).Close()
+
+			_, err := Restore(tt.snapshot, target, time.Now())
+			if err == nil || !strings.Contains(err.Error(), "快照驗證失敗") || !strings.Contains(err.Error(), tt.reason) {
+				t.Fatalf("Restore = %v, want a verification error containing %q", err, tt.reason)
+			}
+			// 驗證失敗時不會動到原本的資料庫
+			if n := countMatches(t, target); n != 1 {
+				t.Errorf("target has %d matches after a failed restore, want 1", n)
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(target)); len(entries) != 1 {
+				t.Errorf("files next to the target = %v, want only the target", entries)
+			}
+		})
+	}
+
+	if err := Verify(snapshot); err != nil {
+		t.Errorf("Verify(valid snapshot) = %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+)
+
+// Retention 快照保留規則：每天保留當天最新的一份，保留最近 Daily 天；
+// 每週（ISO 週）保留最新的一份，保留最近 Weekly 週。最新的快照一律保留。
+type Retention struct {
+	Daily  int
+	Weekly int
+}
+
+// DefaultRetention 保留 7 份每日快照與 4 份每週快照
+var DefaultRetention = Retention{Daily: 7, Weekly: 4}
+
+// Keep 依保留規則挑出要保留的快照（snapshots 需由新到舊）
+func (r Retention) Keep(snapshots []Snapshot) map[string]bool {
+	keep := map[string]bool{}
+	if len(snapshots) > 0 {
+		keep[snapshots[0].Path] = true
+	}
+
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	for _, s := range snapshots {
+		day := s.Time.Format("2006-01-02")
+		if !days[day] && len(days) < r.Daily {
+			days[day] = true
+			keep[s.Path] = true
+		}
+		year, week := s.Time.ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", year, week)
+		if !weeks[key] && len(weeks) < r.Weekly {
+			weeks[key] = true
+			keep[s.Path] = true
+		}
+	}
+	return keep
+}
+
+// Prune 刪除 dir 中不在保留規則內的快照，回傳被刪除的檔案
+func Prune(dir string, r Retention) ([]string, error) {
+	snapshots, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	keep := r.Keep(snapshots)
+
+	removed := []string{}
+	for _, s := range snapshots {
+		if keep[s.Path] {
+			continue
+		}
+		if err := os.Remove(s.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, s.Path)
+	}
+	return removed, nil
+}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// writeSnapshots 在 dir 建立 from～to 每天 03:00、15:00 兩份（空的）快照檔，回傳由新到舊的列表
+func writeSnapshots(t *testing.T, dir string, from, to time.Time) []Snapshot {
+	t.Helper()
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, hour := range []int{3, 15} {
+			at := day.Add(time.Duration(hour) * time.Hour)
+			if err := os.WriteFile(filepath.Join(dir, filePrefix+at.Format(timeLayout)+fileSuffix), nil, 0o644); err != nil {
+				t.Fatalf("write snapshot: %v", err)
+			}
+		}
+	}
+	snapshots, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	return snapshots
+}
+
+// snapshotNames 快照檔名中的日期時間（MMDD-HH），排序後方便比對
+func snapshotNames(paths []string) []string {
+	names := []string{}
+	for _, p := range paths {
+		t, _ := time.ParseInLocation(timeLayout, filepath.Base(p)[len(filePrefix):len(filePrefix)+len(timeLayout)], time.Local)
+		names = append(names, t.Format("0102-15"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRetentionKeep(t *testing.T) {
+	// 2025-02-01（週六）～ 2025-03-12（週三）：最新的一週（W11）從 03-10 開始
+	snapshots := writeSnapshots(t, t.TempDir(),
+		time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local), time.Date(2025, 3, 12, 0, 0, 0, 0, time.Local))
+
+	tests := []struct {
+		name      string
+		retention Retention
+		want      []string
+	}{
+		{"default", DefaultRetention, []string{"0223-15", "0302-15", "0306-15", "0307-15", "0308-15", "0309-15", "0310-15", "0311-15", "0312-15"}},
+		{"days within the latest week", Retention{Daily: 3, Weekly: 2}, []string{"0309-15", "0310-15", "0311-15", "0312-15"}},
+		{"weekly only", Retention{Daily: 0, Weekly: 3}, []string{"0302-15", "0309-15", "0312-15"}},
+		{"latest always kept", Retention{}, []string{"0312-15"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep := tt.retention.Keep(snapshots)
+			paths := []string{}
+			for path, ok := range keep {
+				if ok {
+					paths = append(paths, path)
+				}
+			}
+			if got := snapshotNames(paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Keep = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if keep := DefaultRetention.Keep(nil); len(keep) != 0 {
+		t.Errorf("Keep(nil) = %v, want empty", keep)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	snapshots := writeSnapshots(t, dir,
+		time.Date(2025, 2, 20, 0, 0, 0, 0, time.Local), time.Date(2025, 3, 12, 0, 0, 0, 0, time.Local))
+	// 不是快照的檔案不會被刪除
+	others := []string{"notes.txt", filePrefix + "manual" + fileSuffix, filePrefix + "20250101-000000" + fileSuffix + ".tmp"}
+	for _, name := range others {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	removed, err := Prune(dir, Retention{Daily: 3, Weekly: 2})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(removed) != len(snapshots)-4 {
+		t.Errorf("Prune removed %d snapshots, want %d", len(removed), len(snapshots)-4)
+	}
+
+	left, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	paths := []string{}
+	for _, s := range left {
+		paths = append(paths, s.Path)
+	}
+	if got, want := snapshotNames(paths), []string{"0309-15", "0310-15", "0311-15", "0312-15"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshots left = %v, want %v", got, want)
+	}
+	for _, name := range others {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+
+	// 再清理一次不會刪除任何檔案
+	if removed, err := Prune(dir, Retention{Daily: 3, Weekly: 2}); err != nil || len(removed) != 0 {
+		t.Errorf("second Prune removed %v, %v; want nothing", removed, err)
+	}
+}
@@ -0,0 +1,61 @@
+package backup
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// Config 排程快照設定
+type Config struct {
+	Dir       string
+	Interval  time.Duration // 0 表示停用排程
+	Retention Retention
+}
+
+// Run 建立一份快照並依保留規則清理
+func Run(db *database.DB, cfg Config) (string, []string, error) {
+	path, err := Create(db, cfg.Dir, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	removed, err := Prune(cfg.Dir, cfg.Retention)
+	return path, removed, err
+}
+
+// Start 在背景依 Interval 定期建立快照，直到 ctx 結束
+// 距離上一份快照已超過 Interval 時（e.g. 伺服器停機一段時間後啟動）會立即補做一次。
+func Start(ctx context.Context, db *database.DB, cfg Config) {
+	if cfg.Interval <= 0 {
+		return
+	}
+
+	delay := time.Duration(0)
+	if snapshots, err := List(cfg.Dir); err == nil && len(snapshots) > 0 {
+		if next := time.Until(snapshots[0].Time.Add(cfg.Interval)); next > 0 {
+			delay = next
+		}
+	}
+
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			path, removed, err := Run(db, cfg)
+			if err != nil {
+				log.Printf("backup: %v", err)
+			} else {
+				log.Printf("✓ Backup snapshot %s (pruned %d)", path, len(removed))
+			}
+			timer.Reset(cfg.Interval)
+		}
+	}()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/backup"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+const usage = `用法: go run ./cmd/backup [flags]
+
+建立 SQLite 資料庫快照（VACUUM INTO），伺服器執行中也可以使用；
+建立後依保留規則刪除舊快照。-list 只列出現有快照。
+
+flags:
+`
+
+func main() {
+	var (
+		dbPath     string
+		dir        string
+		keepDaily  int
+		keepWeekly int
+		list       bool
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&dir, "dir", os.Getenv("BACKUP_DIR"), "snapshot directory (default: BACKUP_DIR env or backups/ next to the db)")
+	flag.IntVar(&keepDaily, "keep-daily", backup.DefaultRetention.Daily, "number of daily snapshots to keep")
+	flag.IntVar(&keepWeekly, "keep-weekly", backup.DefaultRetention.Weekly, "number of weekly snapshots to keep")
+	flag.BoolVar(&list, "list", false, "list snapshots without creating a new one")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(dbPath), "backups")
+	}
+
+	if list {
+		printSnapshots(dir)
+		return
+	}
+
+	if _, err := os.Stat(dbPath); err != nil {
+		log.Fatalf("找不到資料庫 %s: %v", dbPath, err)
+	}
+	db, err := database.OpenSQLite(dbPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	path, removed, err := backup.Run(db, backup.Config{
+		Dir:       dir,
+		Retention: backup.Retention{Daily: keepDaily, Weekly: keepWeekly},
+	})
+	if err != nil {
+		log.Fatalf("backup: %v", err)
+	}
+	for _, p := range removed {
+		fmt.Printf("  - removed %s\n", p)
+	}
+	fmt.Printf("✓ Snapshot %s\n", path)
+}
+
+func printSnapshots(dir string) {
+	snapshots, err := backup.List(dir)
+	if err != nil {
+		log.Fatalf("list: %v", err)
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("(%s 沒有快照)\n", dir)
+		return
+	}
+	for _, s := range snapshots {
+		fmt.Printf("%s  %8.1f KB  %s\n", s.Time.Format(time.DateTime), float64(s.Size)/1024, s.Path)
+	}
+}
@@ -1,50 +1,111 @@
 package main
 
 import (
-	"database/sql"
+	"flag"
 	"fmt"
 	"log"
-	"time"
+	"os"
 
 	"github.com/google/uuid"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
 )
 
+const usage = `用法: go run ./cmd/create-season [flags] <code> [start-date] [end-date]
+
+新增賽季，e.g.
+  go run ./cmd/create-season S50 2026-02-01 2026-02-28
+  go run ./cmd/create-season 2026-02            # 代碼為 YYYY-MM 時自動使用該月份
+
+也可以使用 API：POST /seasons
+
+flags:
+`
+
 func main() {
-	db, err := sql.Open("sqlite3", "./duellog.db")
+	var (
+		dbPath      string
+		databaseURL string
+		gameKey     string
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.StringVar(&gameKey, "game", "master_duel", "game key")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 || flag.NArg() > 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	code, start, end := flag.Arg(0), flag.Arg(1), flag.Arg(2)
+	if start == "" && end == "" {
+		start, end, _ = store.MonthRange(code)
+	}
+
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+
+	db, err := database.Open(databaseURL, dbPath)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("open db: %v", err)
 	}
 	defer db.Close()
 
-	// 取得正確的 game_id
 	var gameID string
-	err = db.QueryRow("SELECT id FROM games WHERE key = 'master_duel'").Scan(&gameID)
-	if err != nil {
-		log.Fatal("找不到遊戲:", err)
+	if err := db.QueryRow("SELECT id FROM games WHERE key = ?", gameKey).Scan(&gameID); err != nil {
+		log.Fatalf("找不到遊戲 %s: %v", gameKey, err)
 	}
-	fmt.Printf("Game ID: %s\n", gameID)
 
-	// 檢查 S49 是否存在
-	var existingID string
-	err = db.QueryRow("SELECT id FROM seasons WHERE code = 'S49' AND game_id = ?", gameID).Scan(&existingID)
-	if err == nil {
-		fmt.Printf("S49 賽季已存在: %s\n", existingID)
+	existing, err := store.FindSeasonID(db, gameID, code)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if existing != "" {
+		fmt.Printf("%s 賽季已存在: %s\n", code, existing)
 		return
 	}
 
-	// 建立 S49 (2026年1月)
 	seasonID := uuid.New().String()
-	startDate := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
-	endDate := time.Date(2026, 1, 31, 23, 59, 59, 0, time.UTC)
+	_, err = db.Exec(
+		"INSERT INTO seasons (id, game_id, code, start_date, end_date) VALUES (?, ?, ?, ?, ?)",
+		seasonID, gameID, code, nullIfEmpty(start), nullIfEmpty(end),
+	)
+	if err != nil {
+		log.Fatalf("建立 %s 賽季失敗: %v", code, err)
+	}
+	fmt.Printf("✓ 建立 %s 賽季成功: %s (%s ~ %s)\n", code, seasonID, orDash(start), orDash(end))
 
-	_, err = db.Exec(`
-		INSERT INTO seasons (id, game_id, code, start_date, end_date)
-		VALUES (?, ?, 'S49', ?, ?)
-	`, seasonID, gameID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	// 提醒日期範圍的問題
+	seasons, err := store.ListSeasons(db, gameID)
 	if err != nil {
-		log.Fatal("建立 S49 賽季失敗:", err)
+		log.Fatal(err)
 	}
+	for _, issue := range store.SeasonIssues(seasons) {
+		for _, c := range issue.Seasons {
+			if c == code {
+				fmt.Printf("  ⚠ %s\n", issue.Message)
+				break
+			}
+		}
+	}
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
 
-	fmt.Printf("✓ 建立 S49 賽季成功: %s\n", seasonID)
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
 }
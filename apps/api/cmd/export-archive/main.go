@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/archive"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+const usage = `用法: go run ./cmd/export-archive [flags]
+
+將資料匯出為可攜式封存檔（zip：manifest.json + 每個資料表一個 JSON Lines 檔），
+保留原始 ID 與時間戳記，可用 cmd/import-archive 還原到 SQLite 或 PostgreSQL。
+匯出在單一交易中讀取，伺服器執行中也能取得一致的快照。
+
+flags:
+`
+
+func main() {
+	var (
+		dbPath      string
+		databaseURL string
+		out         string
+		userEmail   string
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.StringVar(&out, "out", "", "output file (default: duellog-archive-YYYYMMDD-HHMMSS.zip)")
+	flag.StringVar(&userEmail, "user", "", "only export this user's matches (default: all users)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+	if out == "" {
+		out = fmt.Sprintf("duellog-archive-%s.zip", time.Now().Format("20060102-150405"))
+	}
+
+	db, err := database.Open(databaseURL, dbPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatalf("ping db: %v", err)
+	}
+
+	var opts archive.ExportOptions
+	if userEmail != "" {
+		if err := db.QueryRow("SELECT id FROM users WHERE email = ?", userEmail).Scan(&opts.UserID); err != nil {
+			log.Fatalf("找不到使用者 %s: %v", userEmail, err)
+		}
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		log.Fatalf("create %s: %v", out, err)
+	}
+	manifest, err := archive.Export(db, f, opts)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		os.Remove(out)
+		log.Fatalf("export: %v", err)
+	}
+
+	fmt.Printf("Database: %s\n", database.Describe(databaseURL, dbPath))
+	fmt.Printf("Schema version: %d\n\n", manifest.SchemaVersion)
+	for _, t := range manifest.Tables {
+		fmt.Printf("  %-16s %6d rows\n", t.Name, t.Rows)
+	}
+	fmt.Printf("\n✓ Wrote %s\n", out)
+}
@@ -72,7 +72,7 @@ func main() {
 	b.WriteString("-- Source DB: " + dbPath + "\n")
 	b.WriteString("-- Usage: replace the deck_templates section in apps/api/seed.sql\n\n")
 
-	b.WriteString("INSERT OR IGNORE INTO deck_templates (id, game_id, main, theme, deck_type) VALUES\n")
+	b.WriteString("INSERT INTO deck_templates (id, game_id, main, theme, deck_type) VALUES\n")
 	for i, r := range all {
 		line := fmt.Sprintf(
 			"  (%s, %s, %s, %s, %s)",
@@ -83,7 +83,7 @@ func main() {
 			sqlQuote(r.DeckType),
 		)
 		if i == len(all)-1 {
-			line += ";\n"
+			line += "\nON CONFLICT DO NOTHING;\n"
 		} else {
 			line += ",\n"
 		}
@@ -1,55 +1,195 @@
 package main
 
 import (
-	"database/sql"
+	"flag"
 	"fmt"
 	"log"
-	"unicode/utf8"
+	"os"
+	"sort"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
 )
 
+const usage = `用法: go run ./cmd/fix-data [flags]
+
+整理既有的牌組名稱：以與新增對局相同的規則（NFKC、全形轉半形、去除多餘空白）正規化，
+只差在大小寫或寫法的名稱（e.g. "spyral" 與 "Spyral"）合併為同一個，並刪除正規化後為空白的模板。
+
+flags:
+`
+
 func main() {
-	db, err := sql.Open("sqlite3", "./duellog.db")
+	var (
+		dbPath      string
+		databaseURL string
+		gameKey     string
+		dryRun      bool
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.StringVar(&gameKey, "game", "master_duel", "game key")
+	flag.BoolVar(&dryRun, "dry-run", false, "preview changes without writing")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+
+	db, err := database.Open(databaseURL, dbPath)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("open db: %v", err)
 	}
 	defer db.Close()
 
-	// 找出有問題的牌組模板（名稱太短或包含無效 UTF-8）
-	fmt.Println("檢查異常資料:")
-	rows, err := db.Query("SELECT id, main FROM deck_templates")
+	var gameID string
+	if err := db.QueryRow("SELECT id FROM games WHERE key = ?", gameKey).Scan(&gameID); err != nil {
+		log.Fatalf("找不到遊戲 %s: %v", gameKey, err)
+	}
+
+	tx, err := db.Begin()
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer tx.Rollback()
 
-	var badIDs []string
-	for rows.Next() {
-		var id, name string
-		rows.Scan(&id, &name)
-		// 檢查是否有異常
-		if len(name) < 2 || !utf8.ValidString(name) {
-			fmt.Printf("  發現異常: id=%s, name=[%s], bytes=%v\n", id, name, []byte(name))
-			badIDs = append(badIDs, id)
+	names, templates, err := loadNames(tx, gameID)
+	if err != nil {
+		log.Fatalf("讀取牌組名稱失敗: %v", err)
+	}
+
+	// 依折疊後的名稱分組
+	groups := map[string][]string{}
+	for name := range names {
+		key := store.FoldDeckName(name)
+		groups[key] = append(groups[key], name)
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Println("檢查牌組名稱:")
+	changed := 0
+	affected := 0
+	for _, key := range keys {
+		group := groups[key]
+		if key == "" {
+			for _, name := range group {
+				fmt.Printf("  ⚠️ 名稱正規化後為空白: bytes=%v\n", []byte(name))
+				if _, err := tx.Exec("DELETE FROM deck_aliases WHERE template_id IN (SELECT id FROM deck_templates WHERE game_id = ? AND main = ?)", gameID, name); err != nil {
+					log.Fatal(err)
+				}
+				if _, err := tx.Exec("DELETE FROM deck_templates WHERE game_id = ? AND main = ?", gameID, name); err != nil {
+					log.Fatal(err)
+				}
+			}
+			continue
+		}
+
+		target := canonicalName(group, names, templates)
+		sources := []string{}
+		for _, name := range group {
+			if name != target {
+				sources = append(sources, name)
+			}
+		}
+		if len(sources) == 0 {
+			continue
+		}
+		sort.Strings(sources)
+
+		summary, err := store.MergeDeckNames(tx, gameID, "", sources, target)
+		if err != nil {
+			log.Fatalf("合併 %q → %q 失敗: %v", sources, target, err)
 		}
+		fmt.Printf("  %q → %q  (%d 場對局)\n", sources, target, summary.AffectedMatches)
+		changed++
+		affected += summary.AffectedMatches
 	}
-	rows.Close()
 
-	if len(badIDs) == 0 {
-		fmt.Println("  無異常資料")
+	if changed == 0 {
+		fmt.Println("  無需整理")
+	}
+	if dryRun {
+		fmt.Printf("\n(dry-run) %d 組名稱、%d 場對局，未寫入\n", changed, affected)
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("提交失敗: %v", err)
+	}
+	fmt.Printf("\n✓ 整理完成！%d 組名稱、%d 場對局\n", changed, affected)
+}
 
-	// 刪除異常資料
-	fmt.Printf("\n刪除 %d 筆異常資料...\n", len(badIDs))
-	for _, id := range badIDs {
-		_, err := db.Exec("DELETE FROM deck_templates WHERE id = ?", id)
-		if err != nil {
-			fmt.Printf("  刪除 %s 失敗: %v\n", id, err)
-		} else {
-			fmt.Printf("  已刪除: %s\n", id)
+// loadNames 所有出現過的牌組名稱 → 使用次數（對局數），以及模板名稱
+func loadNames(q database.Querier, gameID string) (map[string]int, map[string]bool, error) {
+	names := map[string]int{}
+	templates := map[string]bool{}
+
+	rows, err := q.Query(`
+		SELECT d.main, d.sub, (SELECT COUNT(*) FROM matches m WHERE m.my_deck_id = d.id OR m.opp_deck_id = d.id)
+		FROM decks d WHERE d.game_id = ?
+	`, gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var main string
+		var sub *string
+		var count int
+		if err := rows.Scan(&main, &sub, &count); err != nil {
+			rows.Close()
+			return nil, nil, err
 		}
+		names[main] += count
+		if sub != nil {
+			names[*sub] += count
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
 	}
 
-	fmt.Println("\n✓ 清理完成!")
+	rows, err = q.Query("SELECT main FROM deck_templates WHERE game_id = ?", gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, nil, err
+		}
+		templates[name] = true
+		if _, ok := names[name]; !ok {
+			names[name] = 0
+		}
+	}
+	return names, templates, rows.Err()
+}
+
+// canonicalName 選出一組名稱中要保留的寫法：
+// 優先使用已正規化的模板名稱，其次是使用次數最多、較短的名稱（正規化後）
+func canonicalName(group []string, counts map[string]int, templates map[string]bool) string {
+	sort.Slice(group, func(i, j int) bool {
+		a, b := group[i], group[j]
+		if ta, tb := templates[a] && a == store.NormalizeDeckName(a), templates[b] && b == store.NormalizeDeckName(b); ta != tb {
+			return ta
+		}
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return store.NormalizeDeckName(group[0])
 }
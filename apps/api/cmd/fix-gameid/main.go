@@ -1,59 +1,88 @@
 package main
 
 import (
-	"database/sql"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/harvc/duellog/apps/api/database"
 )
 
+const usage = `用法: go run ./cmd/fix-gameid [flags]
+
+把 game_id 指向不存在遊戲的資料（seasons、matches、decks、deck_templates）改為 -game 指定的遊戲。
+已屬於其他既有遊戲的資料不會被更動。
+
+flags:
+`
+
+// tables 需要檢查 game_id 的資料表
+var tables = []string{"seasons", "matches", "decks", "deck_templates"}
+
 func main() {
-	db, err := sql.Open("sqlite3", "./duellog.db")
-	if err != nil {
-		log.Fatal(err)
+	var (
+		dbPath      string
+		databaseURL string
+		gameKey     string
+		dryRun      bool
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.StringVar(&gameKey, "game", "master_duel", "game key to assign orphaned rows to")
+	flag.BoolVar(&dryRun, "dry-run", false, "preview changes without writing")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
 	}
-	defer db.Close()
+	flag.Parse()
 
-	// 取得正確的 game_id
-	var correctGameID string
-	err = db.QueryRow("SELECT id FROM games WHERE key = 'master_duel'").Scan(&correctGameID)
-	if err != nil {
-		log.Fatal("找不到遊戲:", err)
+	if dbPath == "" {
+		dbPath = "./duellog.db"
 	}
-	fmt.Printf("正確的 game_id: %s\n", correctGameID)
 
-	// 更新 seasons 表
-	result, err := db.Exec("UPDATE seasons SET game_id = ?", correctGameID)
+	db, err := database.Open(databaseURL, dbPath)
 	if err != nil {
-		log.Fatal("更新 seasons 失敗:", err)
+		log.Fatalf("open db: %v", err)
 	}
-	rows, _ := result.RowsAffected()
-	fmt.Printf("更新 seasons: %d 筆\n", rows)
+	defer db.Close()
 
-	// 更新 matches 表
-	result, err = db.Exec("UPDATE matches SET game_id = ?", correctGameID)
-	if err != nil {
-		log.Fatal("更新 matches 失敗:", err)
+	var gameID string
+	if err := db.QueryRow("SELECT id FROM games WHERE key = ?", gameKey).Scan(&gameID); err != nil {
+		log.Fatalf("找不到遊戲 %s: %v", gameKey, err)
 	}
-	rows, _ = result.RowsAffected()
-	fmt.Printf("更新 matches: %d 筆\n", rows)
+	fmt.Printf("目標 game_id: %s (%s)\n", gameID, gameKey)
 
-	// 更新 decks 表
-	result, err = db.Exec("UPDATE decks SET game_id = ?", correctGameID)
+	tx, err := db.Begin()
 	if err != nil {
-		log.Fatal("更新 decks 失敗:", err)
+		log.Fatal(err)
 	}
-	rows, _ = result.RowsAffected()
-	fmt.Printf("更新 decks: %d 筆\n", rows)
+	defer tx.Rollback()
 
-	// 更新 deck_templates 表
-	result, err = db.Exec("UPDATE deck_templates SET game_id = ?", correctGameID)
-	if err != nil {
-		log.Fatal("更新 deck_templates 失敗:", err)
+	total := int64(0)
+	for _, table := range tables {
+		orphaned := "game_id IS NULL OR game_id NOT IN (SELECT id FROM games)"
+
+		var n int64
+		if err := tx.QueryRow("SELECT COUNT(*) FROM " + table + " WHERE " + orphaned).Scan(&n); err != nil {
+			log.Fatalf("查詢 %s 失敗: %v", table, err)
+		}
+		if n > 0 && !dryRun {
+			if _, err := tx.Exec("UPDATE "+table+" SET game_id = ? WHERE "+orphaned, gameID); err != nil {
+				log.Fatalf("更新 %s 失敗: %v", table, err)
+			}
+		}
+		fmt.Printf("%s: %d 筆\n", table, n)
+		total += n
 	}
-	rows, _ = result.RowsAffected()
-	fmt.Printf("更新 deck_templates: %d 筆\n", rows)
 
-	fmt.Println("\n✓ 修復完成!")
+	if dryRun {
+		fmt.Printf("\n（dry-run）共 %d 筆需要修復，未寫入任何變更\n", total)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\n✓ 修復完成! 共 %d 筆\n", total)
 }
@@ -1,77 +1,155 @@
 package main
 
 import (
-	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
 )
 
+const usage = `用法: go run ./cmd/fix-seasons [flags]
+
+修復 season_id 指向不存在賽季的對局：依對局日期改指向範圍包含該日期的賽季，
+沒有任何賽季包含該日期時，建立以月份為代碼的賽季（e.g. "2026-01"）。
+最後列出賽季日期範圍重疊、有空檔或沒有日期的問題。
+
+flags:
+`
+
+// orphan 賽季遺失的對局
+type orphan struct {
+	id     string
+	gameID string
+	date   string
+}
+
 func main() {
-	db, err := sql.Open("sqlite3", "./duellog.db")
+	var (
+		dbPath      string
+		databaseURL string
+		gameKey     string
+		dryRun      bool
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.StringVar(&gameKey, "game", "master_duel", "game key (for the season issue report)")
+	flag.BoolVar(&dryRun, "dry-run", false, "preview changes without writing")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+
+	db, err := database.Open(databaseURL, dbPath)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("open db: %v", err)
 	}
 	defer db.Close()
 
-	// 找出所有在 matches 中但不在 seasons 中的 season_id
-	rows, err := db.Query(`
-		SELECT DISTINCT m.season_id 
-		FROM matches m 
-		LEFT JOIN seasons s ON m.season_id = s.id 
-		WHERE s.id IS NULL
-	`)
+	tx, err := db.Begin()
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var missingSeasonsIDs []string
-	for rows.Next() {
-		var id string
-		rows.Scan(&id)
-		missingSeasonsIDs = append(missingSeasonsIDs, id)
+	orphans, err := findOrphans(tx)
+	if err != nil {
+		log.Fatalf("查詢失敗: %v", err)
 	}
+	fmt.Printf("找到 %d 筆賽季遺失的對局\n", len(orphans))
 
-	fmt.Printf("找到 %d 個缺失的 season_id\n", len(missingSeasonsIDs))
-
-	// 為每個缺失的 season 建立記錄
-	// 由於原始的 season code 已經遺失，我們統一設為 "S48" 或從 matches 中推斷
-	for i, seasonID := range missingSeasonsIDs {
-		// 從該 season 的第一筆 match 取得日期作為參考
-		var minDate, maxDate string
-		db.QueryRow(`
-			SELECT MIN(date), MAX(date) 
-			FROM matches 
-			WHERE season_id = ?
-		`, seasonID).Scan(&minDate, &maxDate)
-
-		// 建立 season 記錄
-		code := fmt.Sprintf("S%d", 48-i) // 假設從 S48 往前推
-		_, err := db.Exec(`
-			INSERT INTO seasons (id, game_id, code, start_date, end_date)
-			VALUES (?, 'game-md', ?, ?, ?)
-		`, seasonID, code, minDate, maxDate)
+	moved := map[string]int{} // 賽季代碼 → 筆數
+	for _, o := range orphans {
+		code, seasonID, err := seasonFor(tx, o)
+		if err != nil {
+			log.Fatalf("處理對局 %s 失敗: %v", o.id, err)
+		}
+		if _, err := tx.Exec("UPDATE matches SET season_id = ? WHERE id = ?", seasonID, o.id); err != nil {
+			log.Fatalf("更新對局 %s 失敗: %v", o.id, err)
+		}
+		moved[code]++
+	}
 
+	codes := make([]string, 0, len(moved))
+	for code := range moved {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Printf("  → %s: %d 筆\n", code, moved[code])
+	}
+
+	var gameID string
+	if err := tx.QueryRow("SELECT id FROM games WHERE key = ?", gameKey).Scan(&gameID); err == nil {
+		seasons, err := store.ListSeasons(tx, gameID)
 		if err != nil {
-			log.Printf("建立 season %s 失敗: %v", seasonID, err)
-		} else {
-			fmt.Printf("建立 season: %s (code: %s, 日期: %s ~ %s)\n", seasonID, code, minDate, maxDate)
+			log.Fatalf("查詢賽季失敗: %v", err)
+		}
+		if issues := store.SeasonIssues(seasons); len(issues) > 0 {
+			fmt.Printf("\n%s 的賽季範圍問題（可用 PATCH /seasons/:id 修正）:\n", gameKey)
+			for _, issue := range issues {
+				fmt.Printf("  ⚠ %s\n", issue.Message)
+			}
 		}
 	}
 
-	// 再次確認
-	var totalSeasons int
-	db.QueryRow("SELECT COUNT(*) FROM seasons").Scan(&totalSeasons)
-	fmt.Printf("\n修復後 seasons 總數: %d\n", totalSeasons)
-
-	// 確認 JOIN 後能查到的 matches 數量
-	var joinedCount int
-	db.QueryRow(`
-		SELECT COUNT(*) 
-		FROM matches m 
-		JOIN seasons s ON m.season_id = s.id
-	`).Scan(&joinedCount)
-	fmt.Printf("JOIN 後可查詢的 matches 數量: %d\n", joinedCount)
+	if dryRun {
+		fmt.Println("\n（dry-run）未寫入任何變更")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("\n✓ 修復完成!")
+}
+
+func findOrphans(q database.Querier) ([]orphan, error) {
+	rows, err := q.Query(`
+		SELECT m.id, m.game_id, m.date
+		FROM matches m
+		LEFT JOIN seasons s ON m.season_id = s.id
+		WHERE s.id IS NULL
+		ORDER BY m.date ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orphans []orphan
+	for rows.Next() {
+		var o orphan
+		if err := rows.Scan(&o.id, &o.gameID, &o.date); err != nil {
+			return nil, err
+		}
+		if len(o.date) > len("2006-01-02") {
+			o.date = o.date[:len("2006-01-02")]
+		}
+		orphans = append(orphans, o)
+	}
+	return orphans, rows.Err()
+}
+
+// seasonFor 範圍包含對局日期的賽季；沒有時取得或建立該月份的賽季
+func seasonFor(q database.Querier, o orphan) (string, string, error) {
+	season, err := store.InferSeason(q, o.gameID, o.date)
+	if err == nil {
+		return season.Code, season.ID, nil
+	}
+	if !errors.Is(err, store.ErrSeasonNotInferred) {
+		return "", "", err
+	}
+	code := o.date[:len("2006-01")]
+	id, err := store.GetOrCreateSeasonID(q, o.gameID, code)
+	return code, id, err
 }
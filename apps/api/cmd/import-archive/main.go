@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/harvc/duellog/apps/api/archive"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+const usage = `用法: go run ./cmd/import-archive [flags] <archive.zip>
+
+還原 cmd/export-archive 產生的封存檔。會先執行 migrations，並檢查封存檔的 schema 版本
+與欄位是否與目前的資料庫相容。已存在的資料（相同 ID 或相同內容）會略過，不會覆寫。
+
+flags:
+`
+
+func main() {
+	var (
+		dbPath      string
+		databaseURL string
+		userEmail   string
+		dryRun      bool
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.StringVar(&userEmail, "user", "", "restore all matches into this existing user instead of the archived users")
+	flag.BoolVar(&dryRun, "dry-run", false, "verify and count rows without writing")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+
+	db, err := database.Open(databaseURL, dbPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if err := database.EnsureSchema(db); err != nil {
+		log.Fatalf("ensure schema: %v", err)
+	}
+
+	var opts archive.ImportOptions
+	opts.DryRun = dryRun
+	if userEmail != "" {
+		if err := db.QueryRow("SELECT id FROM users WHERE email = ?", userEmail).Scan(&opts.UserID); err != nil {
+			log.Fatalf("找不到使用者 %s: %v", userEmail, err)
+		}
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("open archive: %v", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Fatalf("stat archive: %v", err)
+	}
+
+	result, err := archive.Import(db, f, info.Size(), opts)
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+
+	fmt.Printf("Database: %s\n", database.Describe(databaseURL, dbPath))
+	fmt.Printf("Archive:  created %s, schema version %d (database: %d)\n\n",
+		result.Manifest.CreatedAt.Format("2006-01-02 15:04:05"), result.Manifest.SchemaVersion, result.SchemaVersion)
+	for _, t := range result.Tables {
+		fmt.Printf("  %-16s %6d rows  %6d inserted  %6d skipped", t.Name, t.Rows, t.Inserted, t.Skipped)
+		if t.Rejected > 0 {
+			fmt.Printf("  %6d rejected", t.Rejected)
+		}
+		fmt.Println()
+	}
+	if dryRun {
+		fmt.Println("\n(dry-run: rolled back, nothing was written)")
+		return
+	}
+	fmt.Println("\n✓ Restore complete")
+}
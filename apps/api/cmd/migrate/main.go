@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+const usage = `用法: go run ./cmd/migrate [flags] <status|up|down>
+
+  status          列出所有 migrations 與套用狀態
+  up              套用尚未執行的 migrations（-to 指定目標版本）
+  down            回滾最近套用的 migration（-steps 指定回滾數量）
+
+flags:
+`
+
+func main() {
+	var (
+		dbPath      string
+		databaseURL string
+		to          int
+		steps       int
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.IntVar(&to, "to", 0, "up: migrate up to this version (default: latest)")
+	flag.IntVar(&steps, "steps", 1, "down: number of migrations to roll back")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+
+	db, err := database.Open(databaseURL, dbPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatalf("ping db: %v", err)
+	}
+	fmt.Printf("Database: %s\n\n", database.Describe(databaseURL, dbPath))
+
+	switch flag.Arg(0) {
+	case "status":
+		printStatus(db)
+	case "up":
+		applied, err := database.MigrateUp(db, to)
+		for _, m := range applied {
+			fmt.Printf("  ✓ up   %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("  已是最新版本")
+		}
+	case "down":
+		if steps < 1 {
+			log.Fatal("-steps 必須大於 0")
+		}
+		reverted, err := database.MigrateDown(db, steps)
+		for _, m := range reverted {
+			fmt.Printf("  ✓ down %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("  沒有可回滾的 migration")
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func printStatus(db *database.DB) {
+	statuses, err := database.Status(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pending := 0
+	for _, s := range statuses {
+		state := "pending"
+		appliedAt := ""
+		if s.Applied {
+			state = "applied"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+		} else {
+			pending++
+		}
+		fmt.Printf("  %03d  %-8s %-19s %s\n", s.Version, state, appliedAt, s.Name)
+	}
+	fmt.Printf("\n共 %d 個 migrations，%d 個待套用\n", len(statuses), pending)
+}
@@ -2,110 +2,126 @@ package main
 
 import (
 	"bufio"
-	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
 )
 
-var db *sql.DB
+const usage = `用法: go run ./cmd/rename-deck [flags] <舊名稱> [<舊名稱>...] <新名稱>
+
+將牌組名稱（大軸、小軸與牌組模板）改為新名稱；指定多個舊名稱時全部合併為新名稱。
+新名稱已存在時，使用舊名稱的對局會改指向既有的牌組，重複的資料列會刪除。
+沒有參數時以互動模式輸入。
+
+flags:
+`
 
 func main() {
-	var err error
-	db, err = sql.Open("sqlite3", "./duellog.db")
-	if err != nil {
-		log.Fatal(err)
+	var (
+		dbPath      string
+		databaseURL string
+		gameKey     string
+		dryRun      bool
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "postgres connection string (default: DATABASE_URL env; overrides -db)")
+	flag.StringVar(&gameKey, "game", "master_duel", "game key")
+	flag.BoolVar(&dryRun, "dry-run", false, "preview changes without writing")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if dbPath == "" {
+		dbPath = "./duellog.db"
 	}
-	defer db.Close()
 
-	if len(os.Args) < 3 {
+	args := flag.Args()
+	if len(args) == 0 {
 		// 互動模式
 		fmt.Println("=== 牌組重命名工具 ===")
-		fmt.Println("用法: go run main.go <舊名稱> <新名稱>")
-		fmt.Println("")
-		fmt.Println("或者輸入要重命名的牌組：")
-		
 		reader := bufio.NewReader(os.Stdin)
-		
+
 		fmt.Print("舊名稱: ")
 		oldName, _ := reader.ReadString('\n')
-		oldName = strings.TrimSpace(oldName)
-		
 		fmt.Print("新名稱: ")
 		newName, _ := reader.ReadString('\n')
-		newName = strings.TrimSpace(newName)
-		
-		if oldName == "" || newName == "" {
-			fmt.Println("名稱不能為空")
-			return
+		args = []string{strings.TrimSpace(oldName), strings.TrimSpace(newName)}
+	}
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	sources, target := args[:len(args)-1], args[len(args)-1]
+	for _, s := range args {
+		if s == "" {
+			log.Fatal("名稱不能為空")
 		}
-		
-		renameDeck(oldName, newName)
-	} else {
-		oldName := os.Args[1]
-		newName := os.Args[2]
-		renameDeck(oldName, newName)
 	}
-}
 
-func renameDeck(oldName, newName string) {
-	fmt.Printf("\n重命名: [%s] → [%s]\n", oldName, newName)
-	
-	// 檢查舊名稱是否存在
-	var count int
-	db.QueryRow("SELECT COUNT(*) FROM decks WHERE main = ? OR sub = ?", oldName, oldName).Scan(&count)
-	if count == 0 {
-		// 也檢查 deck_templates
-		db.QueryRow("SELECT COUNT(*) FROM deck_templates WHERE main = ?", oldName).Scan(&count)
-		if count == 0 {
-			fmt.Printf("  ⚠️ 找不到名稱為 [%s] 的牌組\n", oldName)
-			return
-		}
+	db, err := database.Open(databaseURL, dbPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	var gameID string
+	if err := db.QueryRow("SELECT id FROM games WHERE key = ?", gameKey).Scan(&gameID); err != nil {
+		log.Fatalf("找不到遊戲 %s: %v", gameKey, err)
 	}
-	
-	// 開始事務
+
 	tx, err := db.Begin()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer tx.Rollback()
-	
-	// 1. 更新 deck_templates
-	result, err := tx.Exec("UPDATE deck_templates SET main = ? WHERE main = ?", newName, oldName)
-	if err != nil {
-		fmt.Printf("  ❌ 更新 deck_templates 失敗: %v\n", err)
+
+	fmt.Printf("\n重命名: %v → [%s]\n", sources, target)
+	summary, err := store.MergeDeckNames(tx, gameID, "", sources, target)
+	if errors.Is(err, store.ErrDeckNotFound) {
+		fmt.Printf("  ⚠️ 找不到名稱為 %v 的牌組\n", sources)
 		return
 	}
-	rows1, _ := result.RowsAffected()
-	fmt.Printf("  ✓ deck_templates: %d 筆\n", rows1)
-	
-	// 2. 更新 decks 表的 main 欄位
-	result, err = tx.Exec("UPDATE decks SET main = ? WHERE main = ?", newName, oldName)
 	if err != nil {
-		fmt.Printf("  ❌ 更新 decks.main 失敗: %v\n", err)
-		return
+		log.Fatalf("  ❌ 重命名失敗: %v", err)
 	}
-	rows2, _ := result.RowsAffected()
-	fmt.Printf("  ✓ decks.main: %d 筆\n", rows2)
-	
-	// 3. 更新 decks 表的 sub 欄位
-	result, err = tx.Exec("UPDATE decks SET sub = ? WHERE sub = ?", newName, oldName)
-	if err != nil {
-		fmt.Printf("  ❌ 更新 decks.sub 失敗: %v\n", err)
+
+	for _, d := range summary.Decks {
+		line := fmt.Sprintf("  %s/%s → %s/%s", d.Main, deref(d.Sub), d.NewMain, deref(d.NewSub))
+		if d.Action == "merge" {
+			line += "（合併到既有牌組）"
+		}
+		fmt.Printf("%s  %d 場對局\n", line, d.Matches)
+	}
+	for _, t := range summary.Templates {
+		action := "改名"
+		if t.Action == "merge" {
+			action = "合併"
+		}
+		fmt.Printf("  模板 %s (%s): %s\n", t.Name, t.DeckType, action)
+	}
+
+	if dryRun {
+		fmt.Printf("\n(dry-run) 影響 %d 場對局，未寫入\n", summary.AffectedMatches)
 		return
 	}
-	rows3, _ := result.RowsAffected()
-	fmt.Printf("  ✓ decks.sub: %d 筆\n", rows3)
-	
-	// 提交事務
 	if err := tx.Commit(); err != nil {
-		fmt.Printf("  ❌ 提交失敗: %v\n", err)
-		return
+		log.Fatalf("  ❌ 提交失敗: %v", err)
+	}
+	fmt.Printf("\n✅ 重命名完成！影響 %d 場對局\n", summary.AffectedMatches)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return "無"
 	}
-	
-	total := rows1 + rows2 + rows3
-	fmt.Printf("\n✅ 重命名完成！共更新 %d 筆記錄\n", total)
+	return *s
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/harvc/duellog/apps/api/backup"
+)
+
+const usage = `用法: go run ./cmd/restore [flags] <snapshot.db|latest>
+
+以快照取代 SQLite 資料庫檔案。請先停止伺服器。
+快照會先以 PRAGMA integrity_check 驗證，通過後才替換；原本的資料庫會改名保留
+（<db>.before-restore-YYYYMMDD-HHMMSS）。"latest" 表示備份目錄中最新的快照。
+
+flags:
+`
+
+func main() {
+	var (
+		dbPath     string
+		dir        string
+		verifyOnly bool
+	)
+
+	flag.StringVar(&dbPath, "db", os.Getenv("DB_PATH"), "path to sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&dir, "dir", os.Getenv("BACKUP_DIR"), "snapshot directory for \"latest\" (default: BACKUP_DIR env or backups/ next to the db)")
+	flag.BoolVar(&verifyOnly, "verify-only", false, "only run integrity_check on the snapshot")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if dbPath == "" {
+		dbPath = "./duellog.db"
+	}
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(dbPath), "backups")
+	}
+
+	snapshot := flag.Arg(0)
+	if snapshot == "latest" {
+		snapshots, err := backup.List(dir)
+		if err != nil {
+			log.Fatalf("list: %v", err)
+		}
+		if len(snapshots) == 0 {
+			log.Fatalf("%s 沒有快照", dir)
+		}
+		snapshot = snapshots[0].Path
+	}
+
+	if verifyOnly {
+		if err := backup.Verify(snapshot); err != nil {
+			log.Fatalf("✗ %s: %v", snapshot, err)
+		}
+		fmt.Printf("✓ %s: integrity_check ok\n", snapshot)
+		return
+	}
+
+	previous, err := backup.Restore(snapshot, dbPath, time.Now())
+	if err != nil {
+		log.Fatalf("restore: %v", err)
+	}
+	if previous != "" {
+		fmt.Printf("  原本的資料庫已保留為 %s\n", previous)
+	}
+	fmt.Printf("✓ Restored %s from %s\n", dbPath, snapshot)
+}
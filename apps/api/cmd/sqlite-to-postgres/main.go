@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// copyTables 依外鍵順序複製（sessions 不複製，搬移後請重新登入）
+var copyTables = []string{
+	"users",
+	"games",
+	"seasons",
+	"decks",
+	"deck_templates",
+	"deck_aliases",
+	"matches",
+	"match_games",
+	"tags",
+	"match_tags",
+	"audit_log",
+}
+
+func main() {
+	var (
+		sqlitePath  string
+		databaseURL string
+		dryRun      bool
+	)
+
+	flag.StringVar(&sqlitePath, "sqlite", os.Getenv("DB_PATH"), "path to source sqlite db (default: DB_PATH env or ./duellog.db)")
+	flag.StringVar(&databaseURL, "database-url", os.Getenv("DATABASE_URL"), "target postgres connection string (default: DATABASE_URL env)")
+	flag.BoolVar(&dryRun, "dry-run", false, "copy inside a transaction and roll back at the end")
+	flag.Parse()
+
+	if sqlitePath == "" {
+		sqlitePath = "./duellog.db"
+	}
+	if databaseURL == "" {
+		log.Fatal("請以 -database-url 或 DATABASE_URL 指定目標 PostgreSQL")
+	}
+	if _, err := os.Stat(sqlitePath); err != nil {
+		log.Fatalf("找不到來源資料庫 %s: %v", sqlitePath, err)
+	}
+
+	src, err := database.OpenSQLite(sqlitePath)
+	if err != nil {
+		log.Fatalf("open sqlite: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := database.OpenPostgres(databaseURL)
+	if err != nil {
+		log.Fatalf("open postgres: %v", err)
+	}
+	defer dst.Close()
+	if err := dst.Ping(); err != nil {
+		log.Fatalf("ping postgres: %v", err)
+	}
+
+	// 目標資料庫先建好 schema
+	if err := database.EnsureSchema(dst); err != nil {
+		log.Fatalf("ensure postgres schema: %v", err)
+	}
+
+	tx, err := dst.Begin()
+	if err != nil {
+		log.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	fmt.Printf("複製 %s → %s\n", database.Describe("", sqlitePath), database.Describe(databaseURL, ""))
+	for _, table := range copyTables {
+		copied, skipped, err := copyTable(src, dst, tx, table)
+		if err != nil {
+			log.Fatalf("複製 %s 失敗: %v", table, err)
+		}
+		fmt.Printf("  %-15s 新增 %d 筆，略過 %d 筆（已存在）\n", table, copied, skipped)
+	}
+
+	if dryRun {
+		fmt.Println("\n(dry-run) 已復原，未寫入任何資料")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("commit: %v", err)
+	}
+	fmt.Println("\n✓ 搬移完成！請在 apps/api/.env 設定 DATABASE_URL 後重新啟動後端")
+}
+
+// copyTable 複製單一資料表（只複製兩邊都有的欄位；主鍵或唯一鍵衝突時略過）
+func copyTable(src, dst *database.DB, tx *database.Tx, table string) (copied, skipped int64, err error) {
+	srcCols, err := src.TableColumns(table)
+	if err != nil {
+		return 0, 0, err
+	}
+	dstCols, err := dst.TableColumns(table)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	cols := []string{}
+	for c := range srcCols {
+		if _, ok := dstCols[c]; ok {
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) == 0 {
+		return 0, 0, nil
+	}
+	sort.Strings(cols)
+
+	rows, err := src.Query("SELECT " + strings.Join(cols, ", ") + " FROM " + table)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	insert := "INSERT INTO " + table + " (" + strings.Join(cols, ", ") + ") VALUES (" + placeholders + ") ON CONFLICT DO NOTHING"
+
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return copied, skipped, err
+		}
+
+		result, err := tx.Exec(insert, values...)
+		if err != nil {
+			return copied, skipped, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			copied += n
+		} else {
+			skipped++
+		}
+	}
+	return copied, skipped, rows.Err()
+}
@@ -0,0 +1,34 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{"sqlite unchanged", SQLite, "SELECT * FROM matches WHERE id = ?", "SELECT * FROM matches WHERE id = ?"},
+		{"no placeholders", Postgres, "SELECT 1", "SELECT 1"},
+		{"placeholders", Postgres, "UPDATE matches SET note = ? WHERE id = ? AND user_id = ?",
+			"UPDATE matches SET note = $1 WHERE id = $2 AND user_id = $3"},
+		{"string literal", Postgres, "SELECT '?', ? FROM t WHERE a = 'it''s ?'", "SELECT '?', $1 FROM t WHERE a = 'it''s ?'"},
+		{"quoted identifier", Postgres, `SELECT "a?b" FROM t WHERE c = ?`, `SELECT "a?b" FROM t WHERE c = $1`},
+		{"line comment", Postgres, "SELECT ? -- why?\nFROM t WHERE a = ?", "SELECT $1 -- why?\nFROM t WHERE a = $2"},
+		{"line comment at end", Postgres, "SELECT ? -- trailing ?", "SELECT $1 -- trailing ?"},
+		{"block comment", Postgres, "SELECT /* ? */ ? FROM t", "SELECT /* ? */ $1 FROM t"},
+		{"multi-line block comment", Postgres, "/* a ?\n b ? */\nSELECT ?", "/* a ?\n b ? */\nSELECT $1"},
+		{"nested block comment", Postgres, "SELECT /* a /* ? */ ? */ ?", "SELECT /* a /* ? */ ? */ $1"},
+		{"quotes inside comments", Postgres, "SELECT ? -- it's\nFROM t /* \" */ WHERE a = ?", "SELECT $1 -- it's\nFROM t /* \" */ WHERE a = $2"},
+		{"comment markers inside strings", Postgres, "SELECT '--', '/*', ? FROM t", "SELECT '--', '/*', $1 FROM t"},
+		{"minus and divide operators", Postgres, "SELECT a - ?, b / ? FROM t", "SELECT a - $1, b / $2 FROM t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Rebind(tt.dialect, tt.query); got != tt.want {
+				t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"log"
+	"strings"
+)
+
+// noteSearchTriggers 讓 matches_fts 與 matches.note 保持同步的 triggers
+var noteSearchTriggers = map[string]string{
+	"matches_fts_ai": `
+		CREATE TRIGGER matches_fts_ai AFTER INSERT ON matches
+		WHEN NEW.note IS NOT NULL AND NEW.note <> '' BEGIN
+			INSERT INTO matches_fts (match_id, note) VALUES (NEW.id, NEW.note);
+		END`,
+	"matches_fts_au": `
+		CREATE TRIGGER matches_fts_au AFTER UPDATE OF note ON matches BEGIN
+			DELETE FROM matches_fts WHERE match_id = OLD.id;
+			INSERT INTO matches_fts (match_id, note)
+			SELECT NEW.id, NEW.note WHERE NEW.note IS NOT NULL AND NEW.note <> '';
+		END`,
+	"matches_fts_ad": `
+		CREATE TRIGGER matches_fts_ad AFTER DELETE ON matches BEGIN
+			DELETE FROM matches_fts WHERE match_id = OLD.id;
+		END`,
+}
+
+// sqliteHasFTS5 SQLite 是否有編譯 FTS5（go build/run 需加 -tags sqlite_fts5）
+func sqliteHasFTS5(db *DB) (bool, error) {
+	var used bool
+	err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used)
+	return used, err
+}
+
+// NoteIndexReady matches_fts 與同步用的 triggers 是否都在，且這個執行檔支援 FTS5；
+// 備註搜尋在使用 MATCH 前檢查，任何一項不成立時改用 LIKE（PostgreSQL 一律為 true）
+func (db *DB) NoteIndexReady() (bool, error) {
+	if db.Dialect == Postgres {
+		return true, nil
+	}
+	if !db.FullText {
+		return false, nil
+	}
+	complete, _, err := sqliteNoteIndexExists(db)
+	return complete, err
+}
+
+// sqliteNoteIndexExists matches_fts 與所有同步 triggers 是否存在；triggers 為已存在的 trigger 數量
+func sqliteNoteIndexExists(db *DB) (complete bool, triggers int, err error) {
+	names := make([]string, 0, len(noteSearchTriggers))
+	args := make([]interface{}, 0, len(noteSearchTriggers))
+	for name := range noteSearchTriggers {
+		names = append(names, "?")
+		args = append(args, name)
+	}
+	err = db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ("+strings.Join(names, ", ")+")", args...,
+	).Scan(&triggers)
+	if err != nil || triggers != len(noteSearchTriggers) {
+		return false, triggers, err
+	}
+	complete, err = db.TableExists("matches_fts")
+	return complete, triggers, err
+}
+
+// ensureNoteSearch 準備對局備註的全文索引，結果記在 db.FullText；不會因為沒有索引而讓啟動失敗
+//   - PostgreSQL：migration 013 建立的 tsvector 索引
+//   - SQLite：matches_fts（FTS5，trigram tokenizer，可搜尋中日文的子字串）與同步用的 triggers，
+//     缺少任何一個時整個重建並補上既有的備註；沒有 FTS5 時備註搜尋改用 LIKE
+//
+// SQLite 的索引只是 matches.note 的副本，不屬於 migrations 管理的 schema：
+// 沒有 FTS5 的執行檔開啟已建立索引的資料庫時，只移除同步用的 triggers（triggers 會寫入 fts5 的資料表，
+// 沒有 FTS5 時寫入對局會失敗），之後再以 FTS5 編譯的執行檔開啟時會因為 triggers 不完整而整個重建。
+func ensureNoteSearch(db *DB) error {
+	if db.Dialect == Postgres {
+		db.FullText = true
+		return nil
+	}
+
+	ok, err := sqliteHasFTS5(db)
+	if err != nil {
+		return err
+	}
+	complete, triggers, err := sqliteNoteIndexExists(db)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if triggers > 0 {
+			if err := dropNoteSearchTriggers(db); err != nil {
+				log.Printf("⚠️  Failed to drop note search triggers; writing matches may fail: %v", err)
+			} else {
+				log.Println("⚠️  Database has a note search index but SQLite was built without FTS5 (-tags sqlite_fts5); " +
+					"index disabled until a FTS5 build rebuilds it, note search falls back to LIKE")
+			}
+			return nil
+		}
+		log.Println("ℹ️  SQLite built without FTS5 (-tags sqlite_fts5); note search falls back to LIKE")
+		return nil
+	}
+	if complete {
+		db.FullText = true
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		"DROP TABLE IF EXISTS matches_fts",
+		"CREATE VIRTUAL TABLE matches_fts USING fts5(match_id UNINDEXED, note, tokenize = 'trigram')",
+		"INSERT INTO matches_fts (match_id, note) SELECT id, note FROM matches WHERE note IS NOT NULL AND note <> ''",
+	}
+	for name, create := range noteSearchTriggers {
+		stmts = append(stmts, "DROP TRIGGER IF EXISTS "+name, create)
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println("✓ Built note search index (FTS5)")
+	db.FullText = true
+	return nil
+}
+
+// dropNoteSearchTriggers 移除同步用的 triggers（不需要 FTS5）；matches_fts 保留，重建時會一併取代
+func dropNoteSearchTriggers(db *DB) error {
+	for name := range noteSearchTriggers {
+		if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import "testing"
+
+func TestEnsureNoteSearchWithoutFTS5(t *testing.T) {
+	db := openTestSQLite(t)
+	if ok, err := sqliteHasFTS5(db); err != nil || ok {
+		t.Skipf("SQLite built with FTS5 (%v)", err)
+	}
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+
+	// 以 FTS5 編譯的執行檔留下的 triggers（matches_fts 本身沒有 FTS5 無法建立）
+	for name, create := range noteSearchTriggers {
+		if _, err := db.Exec(create); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+
+	if err := ensureNoteSearch(db); err != nil {
+		t.Fatalf("ensureNoteSearch: %v", err)
+	}
+	if db.FullText {
+		t.Error("FullText = true without FTS5")
+	}
+	if _, triggers, err := sqliteNoteIndexExists(db); err != nil || triggers != 0 {
+		t.Errorf("%d note search triggers left, %v; want 0", triggers, err)
+	}
+	if ready, err := db.NoteIndexReady(); err != nil || ready {
+		t.Errorf("NoteIndexReady = %v, %v; want false", ready, err)
+	}
+
+	// triggers 移除後，沒有 FTS5 也能寫入有備註的對局
+	_, err := db.Exec(`INSERT INTO matches (id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id, play_order, result, note)
+		VALUES ('m1', 'u', 'g', 's', '2025-01-02', '金 IV', 'd1', 'd2', '先攻', 'W', '卡手')`)
+	if err != nil {
+		t.Errorf("insert match with a note: %v", err)
+	}
+}
+
+func TestEnsureNoteSearchRebuild(t *testing.T) {
+	db := openTestSQLite(t)
+	if ok, err := sqliteHasFTS5(db); err != nil || !ok {
+		t.Skipf("SQLite built without FTS5 (%v); run with -tags sqlite_fts5", err)
+	}
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if err := ensureNoteSearch(db); err != nil || !db.FullText {
+		t.Fatalf("ensureNoteSearch = %v, FullText %v", err, db.FullText)
+	}
+
+	// 沒有 FTS5 的執行檔停用索引後寫入的備註，重建時要補上
+	if err := dropNoteSearchTriggers(db); err != nil {
+		t.Fatalf("dropNoteSearchTriggers: %v", err)
+	}
+	_, err := db.Exec(`INSERT INTO matches (id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id, play_order, result, note)
+		VALUES ('m1', 'u', 'g', 's', '2025-01-02', '金 IV', 'd1', 'd2', '先攻', 'W', '對手卡手很久')`)
+	if err != nil {
+		t.Fatalf("insert match: %v", err)
+	}
+
+	db.FullText = false
+	if err := ensureNoteSearch(db); err != nil || !db.FullText {
+		t.Fatalf("ensureNoteSearch after dropping triggers = %v, FullText %v", err, db.FullText)
+	}
+	var id string
+	if err := db.QueryRow("SELECT match_id FROM matches_fts WHERE matches_fts MATCH '卡手很'").Scan(&id); err != nil || id != "m1" {
+		t.Errorf("MATCH after rebuild = %q, %v; want m1", id, err)
+	}
+}
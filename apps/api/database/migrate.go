@@ -0,0 +1,288 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration 一個 Goose 格式的 migration 檔（NNN_name.sql）
+type Migration struct {
+	Version int
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+// MigrationStatus migration 的套用狀態
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// LoadMigrations 讀取對應 dialect 的所有 migrations（依版本排序）
+func LoadMigrations(dialect Dialect) ([]Migration, error) {
+	dir, err := findMigrationsDir(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	seen := map[int]string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := migrationFilePattern.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s, %s", version, prev, e.Name())
+		}
+		seen[version] = e.Name()
+
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		up, down := parseGooseSections(string(b))
+		migrations = append(migrations, Migration{Version: version, Name: m[2], UpSQL: up, DownSQL: down})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Status 列出所有 migrations 與套用狀態
+func Status(db *DB) ([]MigrationStatus, error) {
+	migrations, err := prepareMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = at
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// CurrentVersion 目前已套用的最新 migration 版本（尚未套用任何 migration 時為 0）
+func CurrentVersion(db *DB) (int, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// MigrateUp 依序套用尚未執行的 migrations（target 為 0 時套用全部，否則套用到該版本為止）
+// 每個 migration 各自在一個交易內執行，失敗時已套用的部分會保留。
+func MigrateUp(db *DB, target int) ([]Migration, error) {
+	migrations, err := prepareMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, m := range migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := runMigration(db, m, m.UpSQL, true); err != nil {
+			return done, fmt.Errorf("migrate up %03d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown 依版本由新到舊，執行 steps 個已套用 migration 的 Down 區段
+func MigrateDown(db *DB, steps int) ([]Migration, error) {
+	migrations, err := prepareMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if strings.TrimSpace(m.DownSQL) == "" {
+			return done, fmt.Errorf("migrate down %03d_%s: no Down section", m.Version, m.Name)
+		}
+		if err := runMigration(db, m, m.DownSQL, false); err != nil {
+			return done, fmt.Errorf("migrate down %03d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// prepareMigrations 建立 schema_migrations、補登舊版資料庫並讀取 migration 檔
+func prepareMigrations(db *DB) ([]Migration, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	migrations, err := LoadMigrations(db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := baselineLegacySchema(db, migrations); err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+func ensureMigrationsTable(db *DB) error {
+	timestampType := "DATETIME"
+	if db.Dialect == Postgres {
+		timestampType = "TIMESTAMP"
+	}
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at ` + timestampType + ` DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+func appliedVersions(db *DB) (map[int]*time.Time, error) {
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]*time.Time{}
+	for rows.Next() {
+		var version int
+		var at *time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// runMigration 在單一交易內執行 SQL 並更新 schema_migrations
+func runMigration(db *DB, m Migration, body string, up bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(body) != "" {
+		if _, err := tx.Exec(body); err != nil {
+			return err
+		}
+	}
+
+	if up {
+		_, err = tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name)
+	} else {
+		_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// parseGooseSections 拆出 Goose migration 的 Up 與 Down 區段
+// 不是 Goose 格式的檔案整份視為 Up，沒有 Down。
+func parseGooseSections(fileContents string) (string, string) {
+	if !strings.Contains(fileContents, "+goose") {
+		return fileContents, ""
+	}
+
+	lines := strings.Split(fileContents, "\n")
+	var up, down []string
+	var section *[]string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "-- +goose Up"):
+			section = &up
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose Down"):
+			section = &down
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose"):
+			// Skip Goose directives; keep everything else (including SQL and comments).
+			continue
+		}
+		if section != nil {
+			*section = append(*section, line)
+		}
+	}
+	return strings.Join(up, "\n"), strings.Join(down, "\n")
+}
+
+// MigrationsDir migrations 所在子目錄（PostgreSQL 版本放在 migrations/postgres）
+func MigrationsDir(dialect Dialect) string {
+	if dialect == Postgres {
+		return filepath.Join("migrations", "postgres")
+	}
+	return "migrations"
+}
+
+func findMigrationsDir(dialect Dialect) (string, error) {
+	// Try common working directories:
+	// - when running from apps/api: ./migrations
+	// - when running from repo root: ./apps/api/migrations
+	dir := MigrationsDir(dialect)
+	candidates := []string{
+		dir,
+		filepath.Join("apps", "api", dir),
+	}
+	for _, p := range candidates {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("migrations directory %s not found", dir)
+}
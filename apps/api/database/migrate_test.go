@@ -0,0 +1,151 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openTestSQLite 在暫存目錄開一個 SQLite 檔案（in-memory 資料庫每個連線各自獨立，不適合 database/sql 的連線池）
+// 並切換到 apps/api，讓 LoadMigrations 找得到 migrations 目錄
+func openTestSQLite(t *testing.T) *DB {
+	t.Helper()
+	t.Chdir("..")
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestParseGooseSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		up       string
+		down     string
+	}{
+		{"plain sql", "CREATE TABLE t (id INTEGER);", "CREATE TABLE t (id INTEGER);", ""},
+		{"up and down", "-- +goose Up\nCREATE TABLE t (id INTEGER);\n-- +goose Down\nDROP TABLE t;",
+			"CREATE TABLE t (id INTEGER);", "DROP TABLE t;"},
+		{"up only", "-- +goose Up\nCREATE TABLE t (id INTEGER);", "CREATE TABLE t (id INTEGER);", ""},
+		{"statement directives skipped", "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n-- +goose StatementEnd\n-- +goose Down\nSELECT 2;",
+			"SELECT 1;", "SELECT 2;"},
+		{"comments kept", "-- header\n-- +goose Up\n-- why\nSELECT 1;\n-- +goose Down\nSELECT 2;", "-- why\nSELECT 1;", "SELECT 2;"},
+		{"indented directives", "  -- +goose Up\nSELECT 1;\n\t-- +goose Down\nSELECT 2;", "SELECT 1;", "SELECT 2;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down := parseGooseSections(tt.contents)
+			if up != tt.up || down != tt.down {
+				t.Errorf("parseGooseSections(%q) = (%q, %q), want (%q, %q)", tt.contents, up, down, tt.up, tt.down)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Chdir("..")
+
+	versions := map[Dialect][]string{}
+	for _, dialect := range []Dialect{SQLite, Postgres} {
+		migrations, err := LoadMigrations(dialect)
+		if err != nil {
+			t.Fatalf("LoadMigrations(%v): %v", dialect, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("LoadMigrations(%v): no migrations", dialect)
+		}
+		for i, m := range migrations {
+			if m.Version != i+1 {
+				t.Errorf("%v: migration %d has version %d, want %d", dialect, i, m.Version, i+1)
+			}
+			if strings.TrimSpace(m.UpSQL) == "" || strings.TrimSpace(m.DownSQL) == "" {
+				t.Errorf("%v: %03d_%s is missing an Up or Down section", dialect, m.Version, m.Name)
+			}
+			versions[dialect] = append(versions[dialect], m.Name)
+		}
+	}
+
+	sqlite, postgres := versions[SQLite], versions[Postgres]
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("sqlite has %d migrations, postgres has %d", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i] != postgres[i] {
+			t.Errorf("migration %03d: sqlite %q, postgres %q", i+1, sqlite[i], postgres[i])
+		}
+	}
+}
+
+func TestMigrateUpDownSQLite(t *testing.T) {
+	db := openTestSQLite(t)
+
+	migrations, err := LoadMigrations(SQLite)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	applied, err := MigrateUp(db, 0)
+	if err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("MigrateUp applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	if v, err := CurrentVersion(db); err != nil || v != latest {
+		t.Errorf("CurrentVersion = %d, %v; want %d", v, err, latest)
+	}
+
+	applied, err = MigrateUp(db, 0)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("second MigrateUp = %d migrations, %v; want none", len(applied), err)
+	}
+
+	reverted, err := MigrateDown(db, len(migrations))
+	if err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if len(reverted) != len(migrations) {
+		t.Errorf("MigrateDown reverted %d migrations, want %d", len(reverted), len(migrations))
+	}
+	if v, err := CurrentVersion(db); err != nil || v != 0 {
+		t.Errorf("CurrentVersion after MigrateDown = %d, %v; want 0", v, err)
+	}
+	if exists, err := db.TableExists("matches"); err != nil || exists {
+		t.Errorf("TableExists(matches) after MigrateDown = %v, %v; want false", exists, err)
+	}
+
+	// Down 必須把 schema 還原到可以重新套用 Up 的狀態
+	if _, err := MigrateUp(db, 0); err != nil {
+		t.Fatalf("MigrateUp after MigrateDown: %v", err)
+	}
+	if v, err := CurrentVersion(db); err != nil || v != latest {
+		t.Errorf("CurrentVersion after re-applying = %d, %v; want %d", v, err, latest)
+	}
+}
+
+func TestMigrateUpTarget(t *testing.T) {
+	db := openTestSQLite(t)
+
+	applied, err := MigrateUp(db, 3)
+	if err != nil {
+		t.Fatalf("MigrateUp(3): %v", err)
+	}
+	if len(applied) != 3 {
+		t.Errorf("MigrateUp(3) applied %d migrations, want 3", len(applied))
+	}
+	if v, err := CurrentVersion(db); err != nil || v != 3 {
+		t.Errorf("CurrentVersion = %d, %v; want 3", v, err)
+	}
+
+	reverted, err := MigrateDown(db, 1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != 3 {
+		t.Fatalf("MigrateDown(1) = %v, %v; want migration 3", reverted, err)
+	}
+	if v, err := CurrentVersion(db); err != nil || v != 2 {
+		t.Errorf("CurrentVersion after MigrateDown(1) = %d, %v; want 2", v, err)
+	}
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"fmt"
+	"log"
+)
+
+// EnsureSchema 確保 schema 為最新：套用所有尚未執行的 migrations，並準備備註的全文索引（見 ensureNoteSearch）
+// （舊版資料庫第一次執行時會先依現有資料表補登 schema_migrations，見 baselineLegacySchema）
+func EnsureSchema(db *DB) error {
+	applied, err := MigrateUp(db, 0)
+	if err != nil {
+		return err
+	}
+	for _, m := range applied {
+		log.Printf("✓ Applied migration %03d_%s", m.Version, m.Name)
+	}
+	return ensureNoteSearch(db)
+}
+
+// legacyChecks 在 schema_migrations 出現前建立的資料庫，用來判斷各 migration 是否已生效
+var legacyChecks = map[int]func(db *DB) (bool, error){
+	1: func(db *DB) (bool, error) { return db.TableExists("matches") },
+	2: func(db *DB) (bool, error) { return db.TableExists("deck_templates") },
+	3: func(db *DB) (bool, error) { return db.ColumnExists("matches", "mode") },
+	4: func(db *DB) (bool, error) { return db.TableExists("sessions") },
+}
+
+// baselineLegacySchema 舊版資料庫（有 matches 但沒有 schema_migrations 紀錄）補登已生效的 migrations
+func baselineLegacySchema(db *DB, migrations []Migration) error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	legacy, err := db.TableExists("matches")
+	if err != nil || !legacy {
+		return err
+	}
+
+	for _, m := range migrations {
+		check, ok := legacyChecks[m.Version]
+		if !ok {
+			continue
+		}
+		done, err := check(db)
+		if err != nil {
+			return err
+		}
+		if !done {
+			continue
+		}
+		if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+			return fmt.Errorf("baseline %03d: %w", m.Version, err)
+		}
+		log.Printf("ℹ️  Existing schema already includes %03d_%s; marked as applied", m.Version, m.Name)
+	}
+	return nil
+}
+
+// ColumnExists 檢查資料表是否有某欄位
+func (db *DB) ColumnExists(table, column string) (bool, error) {
+	cols, err := db.TableColumns(table)
+	if err != nil {
+		return false, err
+	}
+	_, ok := cols[column]
+	return ok, nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/archive"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// ArchiveHandler 處理封存檔備份/還原
+type ArchiveHandler struct {
+	db *database.DB
+}
+
+// NewArchiveHandler 建立新的 archive handler
+func NewArchiveHandler(db *database.DB) *ArchiveHandler {
+	return &ArchiveHandler{db: db}
+}
+
+// ExportArchive 下載目前使用者的封存檔 (GET /archive)
+// 內容為本人的帳號與對局，以及共用的遊戲、賽季、牌組資料；格式見 archive 套件。
+func (h *ArchiveHandler) ExportArchive(c *fiber.Ctx) error {
+	userID := currentUserID(c)
+	filename := fmt.Sprintf("duellog-archive-%s.zip", time.Now().Format("20060102-150405"))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	// 回應開始後無法再改變狀態碼，串流途中的錯誤只能記錄下來
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := archive.Export(h.db, w, archive.ExportOptions{UserID: userID}); err != nil {
+			log.Printf("export archive: %v", err)
+		}
+	})
+	return nil
+}
+
+// RestoreArchive 還原封存檔到目前使用者 (POST /archive/restore)
+// multipart/form-data：
+//   - file: GET /archive 或 cmd/export-archive 產生的 zip
+//   - dryRun: "true" 時只驗證並計算筆數，不寫入
+//
+// 封存檔中的對局一律還原為目前使用者的對局，每筆新增的對局都會寫入變更紀錄；已存在的資料會略過，
+// 既有的對局不會被加上封存檔中的各局記錄或標籤。
+// 共用的遊戲、賽季、牌組模板、階級只會對應到既有的資料，不會新增；參照封存檔以外資料的資料列（e.g. 其他使用者的對局）
+// 與 audit_log 不會還原，計入各資料表的 rejected（見 archive.ImportOptions）。
+func (h *ArchiveHandler) RestoreArchive(c *fiber.Ctx) error {
+	dryRun := false
+	if v := c.FormValue("dryRun"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return invalidBody(c, fmt.Errorf("dryRun: %w", err))
+		}
+		dryRun = b
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return validationFailed(c, fieldErrors{{Field: "file", Code: FieldRequired, Message: "請上傳封存檔"}})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return internalError(c, "讀取檔案失敗", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return internalError(c, "讀取檔案失敗", err)
+	}
+
+	userID := currentUserID(c)
+	result, err := archive.Import(h.db, bytes.NewReader(data), int64(len(data)), archive.ImportOptions{
+		UserID: userID,
+		DryRun: dryRun,
+		AfterRestore: func(q database.Querier, matchIDs []string) error {
+			return auditMatchCreates(q, userID, matchIDs)
+		},
+	})
+	if errors.Is(err, archive.ErrIncompatible) {
+		return validationFailed(c, fieldErrors{{Field: "file", Code: FieldInvalid, Message: err.Error()}})
+	}
+	if err != nil {
+		return internalError(c, "還原失敗", err)
+	}
+	return c.JSON(result)
+}
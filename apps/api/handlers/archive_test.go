@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/archive"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+func newArchiveTestApp(db *database.DB) *fiber.App {
+	app := newAuditTestApp(db)
+	archiveHandler := NewArchiveHandler(db)
+	app.Get("/archive", archiveHandler.ExportArchive)
+	app.Post("/archive/restore", archiveHandler.RestoreArchive)
+	return app
+}
+
+// uploadArchive 以 multipart 上傳封存檔，回傳狀態碼與回應
+func uploadArchive(t *testing.T, app *fiber.App, user string, data []byte, dryRun string) (int, []byte) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if data != nil {
+		fw, err := mw.CreateFormFile("file", "archive.zip")
+		if err != nil {
+			t.Fatalf("CreateFormFile: %v", err)
+		}
+		fw.Write(data)
+	}
+	if dryRun != "" {
+		mw.WriteField("dryRun", dryRun)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/archive/restore", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("X-Test-User", user)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("POST /archive/restore: %v", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, b
+}
+
+func TestArchiveExportAndRestore(t *testing.T) {
+	db := testdb.Open(t)
+	testdb.MustExec(t, db, "INSERT INTO users (id, email, password_hash) VALUES (?, 'a@example.com', 'secret-hash'), (?, 'b@example.com', 'x')", testUser, otherUser)
+	app := newArchiveTestApp(db)
+	id := createTestMatch(t, app)
+
+	req := httptest.NewRequest("GET", "/archive", nil)
+	req.Header.Set("X-Test-User", testUser)
+	resp, err := app.Test(req, -1)
+	if err != nil || resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET /archive = %v, %v", resp, err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if bytes.Contains(data, []byte("secret-hash")) {
+		t.Error("GET /archive includes the password hash")
+	}
+	if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("GET /archive is not a zip: %v", err)
+	}
+
+	// 還原到另一個使用者：對局以原本的 ID 存在且屬於 testUser，全部拒絕
+	status, body := uploadArchive(t, app, otherUser, data, "")
+	var result archive.ImportResult
+	if err := json.Unmarshal(body, &result); status != fiber.StatusOK || err != nil {
+		t.Fatalf("restore = %d %s", status, body)
+	}
+	for _, tr := range result.Tables {
+		if tr.Name == "matches" && (tr.Inserted != 0 || tr.Rejected != 1) {
+			t.Errorf("matches = %+v, want the other user's match rejected", tr)
+		}
+	}
+
+	// 刪除後還原：dryRun 不寫入，實際還原時寫入新增的變更紀錄
+	if status := doJSON(t, app, "DELETE", "/matches/"+id, testUser, nil, nil); status != fiber.StatusOK {
+		t.Fatalf("DELETE /matches/%s = %d", id, status)
+	}
+	if status, body := uploadArchive(t, app, testUser, data, "true"); status != fiber.StatusOK {
+		t.Fatalf("dry run restore = %d %s", status, body)
+	}
+	if len(matchHistory(t, app, id)) != 2 {
+		t.Fatalf("dry run wrote to the audit log")
+	}
+	if snapshot, err := loadMatchSnapshot(db, testUser, id); err != nil || snapshot != nil {
+		t.Fatalf("match after a dry run = %+v, %v; want still deleted", snapshot, err)
+	}
+	if status, body := uploadArchive(t, app, testUser, data, ""); status != fiber.StatusOK {
+		t.Fatalf("restore = %d %s", status, body)
+	}
+	if snapshot, err := loadMatchSnapshot(db, testUser, id); err != nil || snapshot == nil || len(snapshot.Games) != 2 || len(snapshot.Tags) != 1 {
+		t.Errorf("restored match = %+v, %v", snapshot, err)
+	}
+	if history := matchHistory(t, app, id); len(history) != 3 || history[0].Action != "create" {
+		t.Errorf("history after restore = %+v, want a new create entry", history)
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		dryRun string
+		status int
+	}{
+		{"missing file", nil, "", fiber.StatusUnprocessableEntity},
+		{"not a zip", []byte("not a zip"), "", fiber.StatusUnprocessableEntity},
+		{"invalid dryRun", data, "maybe", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := uploadArchive(t, app, testUser, tt.data, tt.dryRun); status != tt.status {
+				t.Errorf("restore = %d %s, want %d", status, body, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,749 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/models"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// audit_log 的 entity
+const (
+	auditMatch        = "match"
+	auditDeckTemplate = "deck_template"
+	auditSeason       = "season"
+	auditGame         = "game"
+)
+
+// errTemplateConflict 還原牌組模板時，名稱已被同遊戲的其他模板使用
+var errTemplateConflict = errors.New("牌組模板名稱已存在")
+
+// AuditHandler 處理變更紀錄的查詢與還原
+type AuditHandler struct {
+	db *database.DB
+}
+
+// NewAuditHandler 建立新的 audit handler
+func NewAuditHandler(db *database.DB) *AuditHandler {
+	return &AuditHandler{db: db}
+}
+
+// AuditEntry 一筆變更紀錄
+type AuditEntry struct {
+	ID        string          `json:"id"`
+	Entity    string          `json:"entity"` // "match" | "deck_template" | "season" | "game"
+	EntityID  string          `json:"entityId"`
+	Action    string          `json:"action"` // "create" | "update" | "delete"
+	UserID    string          `json:"userId"` // 做出變更的使用者
+	UserEmail string          `json:"userEmail"`
+	Before    json.RawMessage `json:"before"`            // 變更前的內容（新增時為 null）
+	After     json.RawMessage `json:"after"`             // 變更後的內容（刪除時為 null）
+	Changes   []string        `json:"changes,omitempty"` // 修改時有變動的欄位
+	RevertOf  *string         `json:"revertOf"`          // 由還原產生時為被還原的紀錄 ID
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// matchSnapshot 對局在某個時間點的內容（audit_log 的 before/after）：GET /matches 的格式再加上 gameId
+type matchSnapshot struct {
+	models.MatchWithDetails
+	GameID string `json:"gameId"`
+}
+
+// templateSnapshot 牌組模板在某個時間點的內容，包含指向它的別名（刪除模板時會一併刪除）
+type templateSnapshot struct {
+	ID        string    `json:"id"`
+	GameID    string    `json:"gameId"`
+	Name      string    `json:"name"`
+	Theme     string    `json:"theme"`
+	DeckType  string    `json:"deckType"`
+	Aliases   []string  `json:"aliases"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// seasonSnapshot 賽季在某個時間點的內容
+type seasonSnapshot struct {
+	store.Season
+	GameID string `json:"gameId"`
+}
+
+// gameSnapshot 遊戲在某個時間點的內容
+type gameSnapshot struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// recordAudit 寫入一筆變更紀錄（請與變更在同一個交易中呼叫）；
+// before 為 nil 時記為新增、after 為 nil 時記為刪除
+func recordAudit(q database.Querier, userID, entity, entityID string, before, after interface{}, revertOf *string) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	action := "update"
+	if beforeJSON == nil {
+		action = "create"
+	} else if afterJSON == nil {
+		action = "delete"
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO audit_log (id, user_id, entity, entity_id, action, before_data, after_data, revert_of, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, uuid.New().String(), userID, entity, entityID, action, beforeJSON, afterJSON, revertOf, time.Now().UTC())
+	return err
+}
+
+// auditJSON 內容轉為 JSON 字串（nil 或 nil 指標為 NULL）
+func auditJSON(v interface{}) (*string, error) {
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// auditChanges 修改前後有變動的欄位（依名稱排序，不含 updatedAt）
+func auditChanges(before, after json.RawMessage) []string {
+	var b, a map[string]json.RawMessage
+	if json.Unmarshal(before, &b) != nil || json.Unmarshal(after, &a) != nil || b == nil || a == nil {
+		return nil
+	}
+	changes := []string{}
+	for key, v := range a {
+		if key != "updatedAt" && !bytes.Equal(b[key], v) {
+			changes = append(changes, key)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			changes = append(changes, key)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// loadMatchSnapshot 讀取使用者對局目前的內容，不存在時回傳 nil
+func loadMatchSnapshot(q database.Querier, userID, matchID string) (*matchSnapshot, error) {
+	var gameID string
+	err := q.QueryRow("SELECT game_id FROM matches WHERE id = ? AND user_id = ?", matchID, userID).Scan(&gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query("SELECT"+matchSelectColumns+matchesFromClause+" AND m.id = ?", matchID)
+	if err != nil {
+		return nil, err
+	}
+	var matches []models.MatchWithDetails
+	for rows.Next() {
+		m, err := scanMatch(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	if err := loadMatchGames(q, matches); err != nil {
+		return nil, err
+	}
+	if err := loadMatchTags(q, matches); err != nil {
+		return nil, err
+	}
+	return &matchSnapshot{MatchWithDetails: matches[0], GameID: gameID}, nil
+}
+
+// auditMatchUpdates 批次修改對局時（e.g. 牌組合併、標籤改名）在 fn 前後讀取使用者這些對局的內容，
+// 為內容有變動的對局寫入修改紀錄；請與 fn 在同一個交易中呼叫
+func auditMatchUpdates(q database.Querier, userID string, matchIDs []string, fn func() error) error {
+	before := make([]*matchSnapshot, len(matchIDs))
+	for i, id := range matchIDs {
+		s, err := loadMatchSnapshot(q, userID, id)
+		if err != nil {
+			return err
+		}
+		before[i] = s
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	for i, id := range matchIDs {
+		after, err := loadMatchSnapshot(q, userID, id)
+		if err != nil {
+			return err
+		}
+		if before[i] == nil && after == nil {
+			continue
+		}
+		if before[i] != nil && after != nil {
+			b, _ := json.Marshal(before[i])
+			a, _ := json.Marshal(after)
+			if len(auditChanges(b, a)) == 0 {
+				continue
+			}
+		}
+		if err := recordAudit(q, userID, auditMatch, id, before[i], after, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditMatchCreates 為批次新增的對局（e.g. 匯入、封存檔還原）寫入新增紀錄；請與新增在同一個交易中呼叫
+func auditMatchCreates(q database.Querier, userID string, matchIDs []string) error {
+	for _, id := range matchIDs {
+		after, err := loadMatchSnapshot(q, userID, id)
+		if err != nil {
+			return err
+		}
+		if after == nil {
+			continue
+		}
+		if err := recordAudit(q, userID, auditMatch, id, nil, after, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditTemplateUpdates 與 auditMatchUpdates 相同，對象為牌組模板（e.g. 新增或修改別名）
+func auditTemplateUpdates(q database.Querier, userID string, templateIDs []string, fn func() error) error {
+	before := make([]*templateSnapshot, len(templateIDs))
+	for i, id := range templateIDs {
+		s, err := loadTemplateSnapshot(q, id)
+		if err != nil {
+			return err
+		}
+		before[i] = s
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	for i, id := range templateIDs {
+		after, err := loadTemplateSnapshot(q, id)
+		if err != nil {
+			return err
+		}
+		if before[i] == nil && after == nil {
+			continue
+		}
+		if before[i] != nil && after != nil {
+			b, _ := json.Marshal(before[i])
+			a, _ := json.Marshal(after)
+			if len(auditChanges(b, a)) == 0 {
+				continue
+			}
+		}
+		if err := recordAudit(q, userID, auditDeckTemplate, id, before[i], after, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreMatch 將對局改回快照的內容（已刪除時以原本的 ID 重新建立），各局記錄與標籤一併還原。
+// 牌組與賽季依名稱重新對應（期間被合併或改名也能還原）。
+func restoreMatch(q database.Querier, userID string, s *matchSnapshot) error {
+	seasonID, err := store.GetOrCreateSeasonID(q, s.GameID, s.SeasonCode)
+	if err != nil {
+		return err
+	}
+	myDeckID, err := store.FindOrCreateDeck(q, s.GameID, s.MyDeck.Main, s.MyDeck.Sub)
+	if err != nil {
+		return err
+	}
+	oppDeckID, err := store.FindOrCreateDeck(q, s.GameID, s.OppDeck.Main, s.OppDeck.Sub)
+	if err != nil {
+		return err
+	}
+
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM matches WHERE id = ?", s.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		_, err = q.Exec(`
+			UPDATE matches SET
+				season_id = ?, date = ?, mode = ?, rank = ?, rank_id = ?,
+				my_deck_id = ?, opp_deck_id = ?, play_order = ?, result = ?, outcome_reason = ?,
+				coin_toss = ?, toss_choice = ?, note = ?, updated_at = ?
+			WHERE id = ? AND user_id = ?
+		`,
+			seasonID, dateOnly(s.Date), s.Mode, s.Rank, s.RankID,
+			myDeckID, oppDeckID, s.PlayOrder, s.Result, s.OutcomeReason,
+			s.CoinToss, s.Choice, s.Note, time.Now(),
+			s.ID, userID,
+		)
+	} else {
+		_, err = q.Exec(`
+			INSERT INTO matches (
+				id, user_id, game_id, season_id, date, mode, rank, rank_id,
+				my_deck_id, opp_deck_id, play_order, result, outcome_reason,
+				coin_toss, toss_choice, note,
+				created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			s.ID, userID, s.GameID, seasonID, dateOnly(s.Date), s.Mode, s.Rank, s.RankID,
+			myDeckID, oppDeckID, s.PlayOrder, s.Result, s.OutcomeReason,
+			s.CoinToss, s.Choice, s.Note,
+			s.CreatedAt, time.Now(),
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	games := make([]models.MatchGameForm, len(s.Games))
+	for i, g := range s.Games {
+		games[i] = models.MatchGameForm{PlayOrder: g.PlayOrder, Result: g.Result, SideIn: g.SideIn, SideOut: g.SideOut, Note: g.Note}
+	}
+	if err := replaceMatchGames(q, s.ID, games); err != nil {
+		return err
+	}
+	return store.SetMatchTags(q, userID, s.ID, s.Tags)
+}
+
+// deleteMatchRows 刪除使用者的對局與各局記錄、標籤（SQLite 不會執行 ON DELETE CASCADE），回傳刪除的對局數
+func deleteMatchRows(q database.Querier, userID, matchID string) (int64, error) {
+	result, err := q.Exec("DELETE FROM matches WHERE id = ? AND user_id = ?", matchID, userID)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+	for _, table := range []string{"match_games", "match_tags"} {
+		if _, err := q.Exec("DELETE FROM "+table+" WHERE match_id = ?", matchID); err != nil {
+			return 0, err
+		}
+	}
+	return rowsAffected, nil
+}
+
+// loadTemplateSnapshot 讀取牌組模板目前的內容，不存在時回傳 nil
+func loadTemplateSnapshot(q database.Querier, id string) (*templateSnapshot, error) {
+	s := templateSnapshot{ID: id, Aliases: []string{}}
+	var createdAt sql.NullTime
+	err := q.QueryRow(
+		"SELECT game_id, main, theme, deck_type, created_at FROM deck_templates WHERE id = ?", id,
+	).Scan(&s.GameID, &s.Name, &s.Theme, &s.DeckType, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		s.CreatedAt = createdAt.Time
+	}
+
+	rows, err := q.Query("SELECT alias FROM deck_aliases WHERE template_id = ? ORDER BY alias", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		s.Aliases = append(s.Aliases, alias)
+	}
+	return &s, rows.Err()
+}
+
+// restoreTemplate 將牌組模板改回快照的內容（已刪除時以原本的 ID 重新建立），並補回不存在的別名。
+// 名稱已被同遊戲的其他模板使用時回傳 errTemplateConflict。
+func restoreTemplate(q database.Querier, s *templateSnapshot) error {
+	var conflict int
+	err := q.QueryRow(
+		"SELECT COUNT(*) FROM deck_templates WHERE game_id = ? AND main = ? AND deck_type = ? AND id <> ?",
+		s.GameID, s.Name, s.DeckType, s.ID,
+	).Scan(&conflict)
+	if err != nil {
+		return err
+	}
+	if conflict > 0 {
+		return errTemplateConflict
+	}
+
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM deck_templates WHERE id = ?", s.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		_, err = q.Exec("UPDATE deck_templates SET main = ?, theme = ? WHERE id = ?", s.Name, s.Theme, s.ID)
+	} else {
+		_, err = q.Exec(`
+			INSERT INTO deck_templates (id, game_id, main, theme, deck_type, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, s.ID, s.GameID, s.Name, s.Theme, s.DeckType, s.CreatedAt)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range s.Aliases {
+		var used int
+		if err := q.QueryRow("SELECT COUNT(*) FROM deck_aliases WHERE game_id = ? AND alias = ?", s.GameID, alias).Scan(&used); err != nil {
+			return err
+		}
+		if used > 0 {
+			continue
+		}
+		_, err := q.Exec(
+			"INSERT INTO deck_aliases (id, game_id, alias, template_id) VALUES (?, ?, ?, ?)",
+			uuid.New().String(), s.GameID, alias, s.ID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTemplateRows 刪除牌組模板與指向它的別名，回傳刪除的模板數
+func deleteTemplateRows(q database.Querier, id string) (int64, error) {
+	// 一併刪除指向此模板的別名（SQLite 未啟用外鍵時不會自動 CASCADE）
+	if _, err := q.Exec(`DELETE FROM deck_aliases WHERE template_id = ?`, id); err != nil {
+		return 0, err
+	}
+	result, err := q.Exec(`DELETE FROM deck_templates WHERE id = ?`, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// loadSeasonSnapshot 讀取賽季目前的內容，不存在時回傳 nil
+func loadSeasonSnapshot(q database.Querier, id string) (*seasonSnapshot, error) {
+	var gameID string
+	err := q.QueryRow("SELECT game_id FROM seasons WHERE id = ?", id).Scan(&gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	season, err := store.GetSeason(q, id)
+	if err != nil {
+		return nil, err
+	}
+	return &seasonSnapshot{Season: season, GameID: gameID}, nil
+}
+
+// loadGameSnapshot 讀取遊戲目前的內容，不存在時回傳 nil
+func loadGameSnapshot(q database.Querier, id string) (*gameSnapshot, error) {
+	g := gameSnapshot{ID: id}
+	err := q.QueryRow("SELECT key, name FROM games WHERE id = ?", id).Scan(&g.Key, &g.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// auditColumns 變更紀錄的 SELECT 欄位（對應 scanAudit）
+const auditColumns = `
+	SELECT a.id, a.entity, a.entity_id, a.action, a.user_id, COALESCE(u.email, ''),
+		a.before_data, a.after_data, a.revert_of, a.created_at
+	FROM audit_log a
+	LEFT JOIN users u ON u.id = a.user_id
+`
+
+// scanAudit 讀取一筆 auditColumns 查詢結果
+func scanAudit(scan func(dest ...interface{}) error) (AuditEntry, error) {
+	var e AuditEntry
+	var before, after, revertOf sql.NullString
+	var createdAt sql.NullTime
+	err := scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.UserID, &e.UserEmail, &before, &after, &revertOf, &createdAt)
+	if err != nil {
+		return e, err
+	}
+	e.Before, e.After = json.RawMessage("null"), json.RawMessage("null")
+	if before.Valid {
+		e.Before = json.RawMessage(before.String)
+	}
+	if after.Valid {
+		e.After = json.RawMessage(after.String)
+	}
+	if revertOf.Valid {
+		e.RevertOf = &revertOf.String
+	}
+	if createdAt.Valid {
+		e.CreatedAt = createdAt.Time
+	}
+	if e.Action == "update" {
+		e.Changes = auditChanges(e.Before, e.After)
+	}
+	return e, nil
+}
+
+// history 某個對象的變更紀錄（新的在前）；userID 不為空時只列出該使用者的變更
+func (h *AuditHandler) history(entity, entityID, userID string) ([]AuditEntry, error) {
+	query := auditColumns + " WHERE a.entity = ? AND a.entity_id = ?"
+	args := []interface{}{entity, entityID}
+	if userID != "" {
+		query += " AND a.user_id = ?"
+		args = append(args, userID)
+	}
+	rows, err := h.db.Query(query+" ORDER BY a.created_at DESC, a.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		e, err := scanAudit(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetMatchHistory 對局的變更紀錄 (GET /matches/:id/history)
+// 已刪除的對局也查得到（可用 POST /audit/:id/revert 還原刪除）
+func (h *AuditHandler) GetMatchHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID := currentUserID(c)
+	entries, err := h.history(auditMatch, id, userID)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if len(entries) == 0 {
+		// 變更紀錄出現前建立的對局沒有紀錄
+		var exists int
+		if err := h.db.QueryRow("SELECT COUNT(*) FROM matches WHERE id = ? AND user_id = ?", id, userID).Scan(&exists); err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		if exists == 0 {
+			return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到對局")
+		}
+	}
+	return c.JSON(fiber.Map{
+		"history": entries,
+		"total":   len(entries),
+	})
+}
+
+// GetDeckTemplateHistory 牌組模板的變更紀錄 (GET /deck-templates/:id/history)
+// 牌組模板為所有使用者共用，列出所有人的變更
+func (h *AuditHandler) GetDeckTemplateHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	entries, err := h.history(auditDeckTemplate, id, "")
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if len(entries) == 0 {
+		var exists int
+		if err := h.db.QueryRow("SELECT COUNT(*) FROM deck_templates WHERE id = ?", id).Scan(&exists); err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		if exists == 0 {
+			return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到牌組模板")
+		}
+	}
+	return c.JSON(fiber.Map{
+		"history": entries,
+		"total":   len(entries),
+	})
+}
+
+// GetSeasonHistory 賽季的變更紀錄 (GET /seasons/:id/history)
+// 賽季為所有使用者共用，列出所有人（管理員）的變更
+func (h *AuditHandler) GetSeasonHistory(c *fiber.Ctx) error {
+	return h.sharedHistory(c, auditSeason, "seasons", "找不到賽季")
+}
+
+// GetGameHistory 遊戲的變更紀錄 (GET /games/:id/history)
+func (h *AuditHandler) GetGameHistory(c *fiber.Ctx) error {
+	return h.sharedHistory(c, auditGame, "games", "找不到遊戲")
+}
+
+// sharedHistory 共用資料（賽季、遊戲）的變更紀錄；已刪除的也查得到
+func (h *AuditHandler) sharedHistory(c *fiber.Ctx, entity, table, notFound string) error {
+	id := c.Params("id")
+	entries, err := h.history(entity, id, "")
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if len(entries) == 0 {
+		var exists int
+		if err := h.db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE id = ?", id).Scan(&exists); err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		if exists == 0 {
+			return apiError(c, fiber.StatusNotFound, CodeNotFound, notFound)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"history": entries,
+		"total":   len(entries),
+	})
+}
+
+// RevertRequest 還原請求
+type RevertRequest struct {
+	Force bool `json:"force"` // 之後還有其他變更時仍然還原（會蓋掉之後的變更）
+}
+
+// RevertAudit 還原一筆變更 (POST /audit/:id/revert)
+// 回到該筆變更前的內容：新增 → 刪除、修改 → 改回 before、刪除 → 以原本的 ID 重新建立。
+// 之後還有其他變更時回傳 409（避免蓋掉別人後來的修正），確定要還原請帶 {"force": true}。
+// 還原本身也會記一筆變更（revertOf 為被還原的紀錄），可以再還原回來。
+func (h *AuditHandler) RevertAudit(c *fiber.Ctx) error {
+	var req RevertRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return invalidBody(c, err)
+		}
+	}
+
+	userID := currentUserID(c)
+	entry, err := scanAudit(h.db.QueryRow(auditColumns+" WHERE a.id = ?", c.Params("id")).Scan)
+	// 對局只有擁有者能還原；牌組模板為共用
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && entry.Entity == auditMatch && entry.UserID != userID) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到變更紀錄")
+	} else if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	// 賽季與遊戲只有管理員能修改，紀錄只供查詢，請直接用 PATCH /seasons/:id、PATCH /games/:id 改回
+	if entry.Entity != auditMatch && entry.Entity != auditDeckTemplate {
+		return apiError(c, fiber.StatusUnprocessableEntity, CodeValidationFailed, "賽季與遊戲的變更紀錄不支援還原")
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	var latest string
+	err = tx.QueryRow(
+		"SELECT id FROM audit_log WHERE entity = ? AND entity_id = ? ORDER BY created_at DESC, id DESC LIMIT 1",
+		entry.Entity, entry.EntityID,
+	).Scan(&latest)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if latest != entry.ID && !req.Force {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  "之後還有其他變更，還原會蓋掉這些變更（確定要還原請帶 force: true）",
+			"code":   CodeConflict,
+			"latest": latest,
+		})
+	}
+
+	var before, after interface{}
+	switch entry.Entity {
+	case auditMatch:
+		current, err := loadMatchSnapshot(tx, userID, entry.EntityID)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		var target *matchSnapshot
+		if err := json.Unmarshal(entry.Before, &target); err != nil {
+			return internalError(c, "解析變更紀錄失敗", err)
+		}
+		if current == nil && target == nil {
+			return apiError(c, fiber.StatusConflict, CodeConflict, "對局已經刪除")
+		}
+		if target == nil {
+			_, err = deleteMatchRows(tx, userID, entry.EntityID)
+		} else {
+			// 與新增、修改對局相同：重新建立或改到其他賽季時，已結束的賽季不能再加入對局
+			if current == nil || current.SeasonCode != target.SeasonCode {
+				_, errs, err := matchSeason(tx, target.GameID, target.SeasonCode, "")
+				if err != nil {
+					return internalError(c, "處理賽季失敗", err)
+				}
+				if len(errs) > 0 {
+					return validationFailed(c, errs)
+				}
+			}
+			err = restoreMatch(tx, userID, target)
+		}
+		if err != nil {
+			return internalError(c, "還原失敗", err)
+		}
+		restored, err := loadMatchSnapshot(tx, userID, entry.EntityID)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		before, after = current, restored
+
+	case auditDeckTemplate:
+		current, err := loadTemplateSnapshot(tx, entry.EntityID)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		var target *templateSnapshot
+		if err := json.Unmarshal(entry.Before, &target); err != nil {
+			return internalError(c, "解析變更紀錄失敗", err)
+		}
+		if current == nil && target == nil {
+			return apiError(c, fiber.StatusConflict, CodeConflict, "牌組模板已經刪除")
+		}
+		if target == nil {
+			_, err = deleteTemplateRows(tx, entry.EntityID)
+		} else {
+			err = restoreTemplate(tx, target)
+		}
+		if errors.Is(err, errTemplateConflict) {
+			return apiError(c, fiber.StatusConflict, CodeConflict, "牌組模板已存在："+target.Name)
+		} else if err != nil {
+			return internalError(c, "還原失敗", err)
+		}
+		restored, err := loadTemplateSnapshot(tx, entry.EntityID)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		before, after = current, restored
+	}
+
+	if err := recordAudit(tx, userID, entry.Entity, entry.EntityID, before, after, &entry.ID); err != nil {
+		return internalError(c, "還原失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "還原失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "已還原",
+		"entity":   entry.Entity,
+		"entityId": entry.EntityID,
+		"revertOf": entry.ID,
+	})
+}
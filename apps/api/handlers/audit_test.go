@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+const (
+	testUser  = "user-a"
+	otherUser = "user-b"
+)
+
+// newAuditTestApp 對局與變更紀錄的路由；X-Test-User 指定目前的使用者（取代登入）
+func newAuditTestApp(db *database.DB) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(userIDKey, c.Get("X-Test-User", testUser))
+		return c.Next()
+	})
+	matches := NewMatchesHandler(db)
+	audit := NewAuditHandler(db)
+	app.Post("/matches", matches.CreateMatch)
+	app.Patch("/matches/:id", matches.UpdateMatch)
+	app.Delete("/matches/:id", matches.DeleteMatch)
+	app.Get("/matches/:id/history", audit.GetMatchHistory)
+	app.Post("/audit/:id/revert", audit.RevertAudit)
+	return app
+}
+
+// doJSON 送出請求並解析 JSON 回應
+func doJSON(t *testing.T, app *fiber.App, method, path, user string, body interface{}, out interface{}) int {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", user)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+type historyResponse struct {
+	History []AuditEntry `json:"history"`
+}
+
+func matchHistory(t *testing.T, app *fiber.App, id string) []AuditEntry {
+	t.Helper()
+	var resp historyResponse
+	if status := doJSON(t, app, "GET", "/matches/"+id+"/history", testUser, nil, &resp); status != fiber.StatusOK {
+		t.Fatalf("GET history = %d", status)
+	}
+	return resp.History
+}
+
+// createTestMatch 新增一筆三戰兩勝、有標籤的對局
+func createTestMatch(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	body := fiber.Map{
+		"gameKey": "master_duel", "seasonCode": "S40", "date": "2025-01-02", "rank": "金4",
+		"myDeck": fiber.Map{"main": "蛇眼"}, "oppDeck": fiber.Map{"main": "天盃龍"},
+		"note": "原本的備註", "tags": []string{"卡手"},
+		"games": []fiber.Map{
+			{"playOrder": "先攻", "result": "W"},
+			{"playOrder": "後攻", "result": "W", "sideIn": "增殖的G"},
+		},
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if status := doJSON(t, app, "POST", "/matches", testUser, body, &created); status != fiber.StatusCreated {
+		t.Fatalf("POST /matches = %d", status)
+	}
+	return created.ID
+}
+
+func TestAuditChanges(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          []string
+	}{
+		{"no changes", `{"a":1,"b":"x"}`, `{"a":1,"b":"x"}`, []string{}},
+		{"changed and sorted", `{"note":"a","rank":"金 IV","result":"W"}`, `{"note":"b","rank":"金 IV","result":"L"}`, []string{"note", "result"}},
+		{"updatedAt ignored", `{"note":"a","updatedAt":"2025-01-01"}`, `{"note":"a","updatedAt":"2025-01-02"}`, []string{}},
+		{"added and removed keys", `{"a":1,"tags":["x"]}`, `{"a":1,"games":[]}`, []string{"games", "tags"}},
+		{"nested values", `{"myDeck":{"main":"A"}}`, `{"myDeck":{"main":"B"}}`, []string{"myDeck"}},
+		{"null side", `null`, `{"a":1}`, nil},
+		{"invalid json", `{`, `{"a":1}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auditChanges(json.RawMessage(tt.before), json.RawMessage(tt.after)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("auditChanges = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevertMatchUpdate(t *testing.T) {
+	db := testdb.Open(t)
+	app := newAuditTestApp(db)
+	id := createTestMatch(t, app)
+
+	patch := fiber.Map{"note": "改過的備註", "tags": []string{"失誤"}, "games": []fiber.Map{}, "playOrder": "後攻", "result": "L"}
+	if status := doJSON(t, app, "PATCH", "/matches/"+id, testUser, patch, nil); status != fiber.StatusOK {
+		t.Fatalf("PATCH /matches/%s = %d", id, status)
+	}
+	// 其他使用者的對局：404，也不會記錄
+	if status := doJSON(t, app, "PATCH", "/matches/"+id, otherUser, fiber.Map{"note": "別人的備註"}, nil); status != fiber.StatusNotFound {
+		t.Errorf("PATCH by another user = %d, want 404", status)
+	}
+
+	history := matchHistory(t, app, id)
+	if len(history) != 2 || history[0].Action != "update" || history[1].Action != "create" {
+		t.Fatalf("history = %+v, want update then create", history)
+	}
+	update, create := history[0], history[1]
+	for _, field := range []string{"games", "note", "playOrder", "result", "tags"} {
+		found := false
+		for _, c := range update.Changes {
+			found = found || c == field
+		}
+		if !found {
+			t.Errorf("update changes = %v, want %s", update.Changes, field)
+		}
+	}
+
+	// 其他使用者看不到也不能還原
+	if status := doJSON(t, app, "POST", "/audit/"+update.ID+"/revert", otherUser, nil, nil); status != fiber.StatusNotFound {
+		t.Errorf("revert by another user = %d, want 404", status)
+	}
+	// 不是最新的變更時需要 force
+	if status := doJSON(t, app, "POST", "/audit/"+create.ID+"/revert", testUser, nil, nil); status != fiber.StatusConflict {
+		t.Errorf("revert of an older entry = %d, want 409", status)
+	}
+
+	if status := doJSON(t, app, "POST", "/audit/"+update.ID+"/revert", testUser, nil, nil); status != fiber.StatusOK {
+		t.Fatalf("revert = %d, want 200", status)
+	}
+	restored, err := loadMatchSnapshot(db, testUser, id)
+	if err != nil || restored == nil {
+		t.Fatalf("loadMatchSnapshot = %v, %v", restored, err)
+	}
+	if restored.Note == nil || *restored.Note != "原本的備註" || restored.Result != "W" || restored.PlayOrder != "先攻" {
+		t.Errorf("restored match = %+v", restored.MatchWithDetails)
+	}
+	if !reflect.DeepEqual(restored.Tags, []string{"卡手"}) {
+		t.Errorf("restored tags = %v, want [卡手]", restored.Tags)
+	}
+	if len(restored.Games) != 2 || restored.Games[1].SideIn == nil || *restored.Games[1].SideIn != "增殖的G" {
+		t.Errorf("restored games = %+v", restored.Games)
+	}
+
+	// 還原本身也是一筆變更，可以再還原回來
+	history = matchHistory(t, app, id)
+	if len(history) != 3 || history[0].RevertOf == nil || *history[0].RevertOf != update.ID {
+		t.Fatalf("history after revert = %+v", history)
+	}
+	if status := doJSON(t, app, "POST", "/audit/"+history[0].ID+"/revert", testUser, nil, nil); status != fiber.StatusOK {
+		t.Fatalf("revert of the revert = %d, want 200", status)
+	}
+	again, err := loadMatchSnapshot(db, testUser, id)
+	if err != nil || again == nil || again.Note == nil || *again.Note != "改過的備註" || len(again.Games) != 0 {
+		t.Errorf("match after reverting the revert = %+v, %v", again, err)
+	}
+}
+
+func TestRevertMatchDeleteAndCreate(t *testing.T) {
+	db := testdb.Open(t)
+	app := newAuditTestApp(db)
+	id := createTestMatch(t, app)
+	original, err := loadMatchSnapshot(db, testUser, id)
+	if err != nil || original == nil {
+		t.Fatalf("loadMatchSnapshot = %v, %v", original, err)
+	}
+
+	if status := doJSON(t, app, "DELETE", "/matches/"+id, testUser, nil, nil); status != fiber.StatusOK {
+		t.Fatalf("DELETE /matches/%s = %d", id, status)
+	}
+	history := matchHistory(t, app, id)
+	if len(history) != 2 || history[0].Action != "delete" || string(history[0].After) != "null" {
+		t.Fatalf("history after delete = %+v", history)
+	}
+
+	// 還原刪除：以原本的 ID 重新建立，包含各局與標籤
+	if status := doJSON(t, app, "POST", "/audit/"+history[0].ID+"/revert", testUser, nil, nil); status != fiber.StatusOK {
+		t.Fatalf("revert delete = %d, want 200", status)
+	}
+	restored, err := loadMatchSnapshot(db, testUser, id)
+	if err != nil || restored == nil {
+		t.Fatalf("loadMatchSnapshot after revert = %v, %v", restored, err)
+	}
+	restored.UpdatedAt = original.UpdatedAt
+	if !reflect.DeepEqual(restored, original) {
+		t.Errorf("restored match = %+v, want %+v", restored.MatchWithDetails, original.MatchWithDetails)
+	}
+
+	// 還原新增（force，因為之後還有變更）：刪除對局
+	create := matchHistory(t, app, id)[2]
+	if create.Action != "create" {
+		t.Fatalf("oldest entry = %+v, want create", create)
+	}
+	if status := doJSON(t, app, "POST", "/audit/"+create.ID+"/revert", testUser, fiber.Map{"force": true}, nil); status != fiber.StatusOK {
+		t.Fatalf("revert create = %d, want 200", status)
+	}
+	if gone, err := loadMatchSnapshot(db, testUser, id); err != nil || gone != nil {
+		t.Errorf("match after reverting its creation = %+v, %v; want deleted", gone, err)
+	}
+	var games int
+	if err := db.QueryRow("SELECT COUNT(*) FROM match_games WHERE match_id = ?", id).Scan(&games); err != nil || games != 0 {
+		t.Errorf("%d match_games rows left, %v; want 0", games, err)
+	}
+
+	// 已經刪除時再還原新增為 409
+	if status := doJSON(t, app, "POST", "/audit/"+create.ID+"/revert", testUser, fiber.Map{"force": true}, nil); status != fiber.StatusConflict {
+		t.Errorf("reverting the creation twice = %d, want 409", status)
+	}
+}
+
+func TestRevertSeasonAuditRejected(t *testing.T) {
+	db := testdb.Open(t)
+	app := newAuditTestApp(db)
+	if err := recordAudit(db, testUser, auditSeason, "season-1", nil, fiber.Map{"code": "S40"}, nil); err != nil {
+		t.Fatalf("recordAudit: %v", err)
+	}
+	var id string
+	if err := db.QueryRow("SELECT id FROM audit_log WHERE entity = ?", auditSeason).Scan(&id); err != nil {
+		t.Fatalf("select audit: %v", err)
+	}
+	if status := doJSON(t, app, "POST", "/audit/"+id+"/revert", testUser, nil, nil); status != fiber.StatusUnprocessableEntity {
+		t.Errorf("revert season entry = %d, want 422", status)
+	}
+}
+
+func TestRevertMatchClosedSeason(t *testing.T) {
+	db := testdb.Open(t)
+	app := newAuditTestApp(db)
+	id := createTestMatch(t, app)
+
+	for _, patch := range []fiber.Map{{"seasonCode": "S41"}, {"note": "改過的備註"}} {
+		if status := doJSON(t, app, "PATCH", "/matches/"+id, testUser, patch, nil); status != fiber.StatusOK {
+			t.Fatalf("PATCH /matches/%s %v = %d", id, patch, status)
+		}
+	}
+	testdb.MustExec(t, db, "UPDATE seasons SET closed_at = ? WHERE code IN ('S40', 'S41')", time.Now())
+	history := matchHistory(t, app, id) // 改備註、改賽季、新增
+
+	// 與 PATCH /matches 相同：不能把對局移回已結束的賽季
+	var resp struct {
+		Fields []FieldError `json:"fields"`
+	}
+	status := doJSON(t, app, "POST", "/audit/"+history[1].ID+"/revert", testUser, fiber.Map{"force": true}, &resp)
+	if status != fiber.StatusUnprocessableEntity || len(resp.Fields) != 1 || resp.Fields[0].Message != "賽季 S40 已結束" {
+		t.Fatalf("revert into a closed season = %d %+v, want 422", status, resp)
+	}
+	if snapshot, err := loadMatchSnapshot(db, testUser, id); err != nil || snapshot.SeasonCode != "S41" {
+		t.Fatalf("match after a rejected revert = %+v, %v; want still in S41", snapshot, err)
+	}
+
+	// 賽季沒有改變的還原不受影響
+	if status := doJSON(t, app, "POST", "/audit/"+history[0].ID+"/revert", testUser, nil, nil); status != fiber.StatusOK {
+		t.Errorf("revert a note change in a closed season = %d, want 200", status)
+	}
+
+	// 刪除後也不能重新建立在已結束的賽季
+	if status := doJSON(t, app, "DELETE", "/matches/"+id, testUser, nil, nil); status != fiber.StatusOK {
+		t.Fatalf("DELETE /matches/%s = %d", id, status)
+	}
+	if status := doJSON(t, app, "POST", "/audit/"+matchHistory(t, app, id)[0].ID+"/revert", testUser, nil, nil); status != fiber.StatusUnprocessableEntity {
+		t.Errorf("revert a delete in a closed season = %d, want 422", status)
+	}
+	if snapshot, err := loadMatchSnapshot(db, testUser, id); err != nil || snapshot != nil {
+		t.Errorf("match after a rejected revert = %+v, %v; want still deleted", snapshot, err)
+	}
+
+	// 重新開啟賽季後可以還原
+	testdb.MustExec(t, db, "UPDATE seasons SET closed_at = NULL WHERE code = 'S41'")
+	if status := doJSON(t, app, "POST", "/audit/"+matchHistory(t, app, id)[0].ID+"/revert", testUser, nil, nil); status != fiber.StatusOK {
+		t.Errorf("revert a delete after reopening the season = %d, want 200", status)
+	}
+}
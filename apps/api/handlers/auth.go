@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// userIDKey c.Locals 中存放目前使用者 ID 的 key
+const userIDKey = "userID"
+
+// localUserEmail 未登入時（AUTH_REQUIRED=false）沿用的本機單人帳號（見 seed.sql）
+const localUserEmail = "demo@duellog.com"
+
+// minPasswordLength 密碼最短長度
+const minPasswordLength = 8
+
+// maxPasswordBytes 密碼最大長度（bcrypt 只接受 72 bytes 以內）
+const maxPasswordBytes = 72
+
+// dummyPasswordHash 登入的帳號不存在時也比對一次密碼，讓回應時間與密碼錯誤相同，無法藉此判斷帳號是否存在
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("duellog-dummy-password"), bcrypt.DefaultCost)
+
+// AuthHandler 處理註冊/登入與 session 驗證
+type AuthHandler struct {
+	db             *database.DB
+	sessionTTL     time.Duration
+	allowAnonymous bool
+	admins         map[string]bool // 管理員的 email
+}
+
+// NewAuthHandler 建立新的 auth handler
+// allowAnonymous 為 true 時，沒有帶 token 的請求會以本機單人帳號身分執行（單機模式相容）；
+// adminEmails 可以修改共用資料（遊戲、賽季）的帳號，見 RequireAdmin
+func NewAuthHandler(db *database.DB, sessionTTL time.Duration, allowAnonymous bool, adminEmails []string) *AuthHandler {
+	admins := map[string]bool{}
+	for _, email := range adminEmails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			admins[email] = true
+		}
+	}
+	return &AuthHandler{db: db, sessionTTL: sessionTTL, allowAnonymous: allowAnonymous, admins: admins}
+}
+
+// AuthRequest 註冊/登入請求
+type AuthRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Register 註冊 (POST /auth/register)
+func (h *AuthHandler) Register(c *fiber.Ctx) error {
+	var req AuthRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var errs fieldErrors
+	if errs.required("email", email) && !strings.Contains(email, "@") {
+		errs.add("email", FieldInvalid, "Email 格式錯誤")
+	}
+	if len(req.Password) < minPasswordLength {
+		errs.add("password", FieldTooShort, "密碼長度至少 8 個字元")
+	} else if len(req.Password) > maxPasswordBytes {
+		errs.add("password", FieldTooLong, "密碼長度不可超過 72 bytes")
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	var exists bool
+	if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", email).Scan(&exists); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if exists {
+		return apiError(c, fiber.StatusConflict, CodeConflict, "Email 已被註冊")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return internalError(c, "密碼處理失敗", nil)
+	}
+
+	userID := uuid.New().String()
+	now := time.Now()
+	_, err = h.db.Exec(
+		"INSERT INTO users (id, email, password_hash, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		userID, email, string(hash), now, now,
+	)
+	if err != nil {
+		return internalError(c, "註冊失敗", err)
+	}
+
+	token, expiresAt, err := h.createSession(userID)
+	if err != nil {
+		return internalError(c, "建立 session 失敗", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"token":     token,
+		"expiresAt": expiresAt,
+		"user":      fiber.Map{"id": userID, "email": email},
+	})
+}
+
+// Login 登入 (POST /auth/login)
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var req AuthRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	var userID, hash string
+	err := h.db.QueryRow("SELECT id, password_hash FROM users WHERE email = ?", email).Scan(&userID, &hash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return internalError(c, "查詢失敗", err)
+	}
+	if err != nil {
+		hash = string(dummyPasswordHash)
+	}
+	// 帳號不存在與密碼錯誤回傳相同訊息（也同樣比對一次密碼），避免洩漏帳號是否存在
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil || err != nil {
+		return apiError(c, fiber.StatusUnauthorized, CodeUnauthorized, "帳號或密碼錯誤")
+	}
+
+	token, expiresAt, err := h.createSession(userID)
+	if err != nil {
+		return internalError(c, "建立 session 失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"token":     token,
+		"expiresAt": expiresAt,
+		"user":      fiber.Map{"id": userID, "email": email},
+	})
+}
+
+// Logout 登出 (POST /auth/logout)
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	token := bearerToken(c)
+	if token == "" {
+		return apiError(c, fiber.StatusUnauthorized, CodeUnauthorized, "未登入")
+	}
+
+	if _, err := h.db.Exec("DELETE FROM sessions WHERE id = ?", hashToken(token)); err != nil {
+		return internalError(c, "登出失敗", err)
+	}
+
+	return c.JSON(fiber.Map{"message": "已登出"})
+}
+
+// Me 目前使用者 (GET /auth/me)
+func (h *AuthHandler) Me(c *fiber.Ctx) error {
+	userID := currentUserID(c)
+
+	var email string
+	err := h.db.QueryRow("SELECT email FROM users WHERE id = ?", userID).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到使用者")
+	}
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"id":        userID,
+		"email":     email,
+		"anonymous": bearerToken(c) == "",
+		"admin":     h.isAdminEmail(email),
+	})
+}
+
+// RequireUser 驗證 bearer token 並將使用者 ID 放入 c.Locals
+func (h *AuthHandler) RequireUser(c *fiber.Ctx) error {
+	token := bearerToken(c)
+	if token == "" {
+		if !h.allowAnonymous {
+			return apiError(c, fiber.StatusUnauthorized, CodeUnauthorized, "未登入")
+		}
+		var userID string
+		if err := h.db.QueryRow("SELECT id FROM users WHERE email = ?", localUserEmail).Scan(&userID); err != nil {
+			return apiError(c, fiber.StatusUnauthorized, CodeUnauthorized, "未登入")
+		}
+		c.Locals(userIDKey, userID)
+		return c.Next()
+	}
+
+	var userID string
+	var expiresAt time.Time
+	err := h.db.QueryRow("SELECT user_id, expires_at FROM sessions WHERE id = ?", hashToken(token)).Scan(&userID, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusUnauthorized, CodeUnauthorized, "登入已失效")
+	}
+	if err != nil {
+		return internalError(c, "驗證失敗", err)
+	}
+	if time.Now().After(expiresAt) {
+		_, _ = h.db.Exec("DELETE FROM sessions WHERE id = ?", hashToken(token))
+		return apiError(c, fiber.StatusUnauthorized, CodeUnauthorized, "登入已失效")
+	}
+
+	c.Locals(userIDKey, userID)
+	return c.Next()
+}
+
+// RequireAdmin 只允許管理員（需放在 RequireUser 之後），用於修改所有人共用的資料（遊戲、賽季）
+func (h *AuthHandler) RequireAdmin(c *fiber.Ctx) error {
+	var email string
+	err := h.db.QueryRow("SELECT email FROM users WHERE id = ?", currentUserID(c)).Scan(&email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return internalError(c, "驗證失敗", err)
+	}
+	if !h.isAdminEmail(email) {
+		return apiError(c, fiber.StatusForbidden, CodeForbidden, "需要管理員權限")
+	}
+	return c.Next()
+}
+
+// isAdminEmail 是否為管理員：ADMIN_EMAILS 中的帳號，本機單人模式（allowAnonymous）時本機帳號也是
+func (h *AuthHandler) isAdminEmail(email string) bool {
+	return email != "" && (h.admins[email] || (h.allowAnonymous && email == localUserEmail))
+}
+
+// createSession 產生新的 token 並寫入 sessions（只存雜湊）
+func (h *AuthHandler) createSession(userID string) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	now := time.Now().UTC()
+	expiresAt := now.Add(h.sessionTTL)
+
+	_, err := h.db.Exec(
+		"INSERT INTO sessions (id, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		hashToken(token), userID, now, expiresAt,
+	)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// currentUserID 取得 RequireUser 設定的使用者 ID
+func currentUserID(c *fiber.Ctx) string {
+	userID, _ := c.Locals(userIDKey).(string)
+	return userID
+}
+
+// bearerToken 從 Authorization header 取出 token
+func bearerToken(c *fiber.Ctx) string {
+	auth := c.Get(fiber.HeaderAuthorization)
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// hashToken token 以 SHA-256 雜湊後存放，資料庫外洩也無法直接冒用
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
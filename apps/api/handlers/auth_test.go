@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+const adminEmail = "admin@example.com"
+
+// newAuthTestApp 註冊/登入與需要登入（/auth/me）、需要管理員（/admin）的路由
+func newAuthTestApp(db *database.DB, allowAnonymous bool) *fiber.App {
+	app := fiber.New()
+	auth := NewAuthHandler(db, time.Hour, allowAnonymous, []string{" Admin@Example.com "})
+	app.Post("/auth/register", auth.Register)
+	app.Post("/auth/login", auth.Login)
+	app.Use(auth.RequireUser)
+	app.Get("/auth/me", auth.Me)
+	app.Get("/admin", auth.RequireAdmin, func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"userId": currentUserID(c)})
+	})
+	return app
+}
+
+// authRequest 以 bearer token（空字串表示不帶）送出請求，回傳狀態碼與 JSON 回應
+func authRequest(t *testing.T, app *fiber.App, method, path, token string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	out := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("%s %s: decode response: %v", method, path, err)
+	}
+	return resp.StatusCode, out
+}
+
+// register 註冊並回傳 token
+func register(t *testing.T, app *fiber.App, email string) string {
+	t.Helper()
+	status, resp := authRequest(t, app, "POST", "/auth/register", "", AuthRequest{Email: email, Password: "password123"})
+	token, _ := resp["token"].(string)
+	if status != fiber.StatusCreated || token == "" {
+		t.Fatalf("register %s = %d %v", email, status, resp)
+	}
+	return token
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	db := testdb.Open(t)
+	app := newAuthTestApp(db, false)
+	register(t, app, "a@example.com")
+
+	// 同一個 email（大小寫、前後空白不同）不能重複註冊
+	status, resp := authRequest(t, app, "POST", "/auth/register", "", AuthRequest{Email: " A@Example.com", Password: "password456"})
+	if status != fiber.StatusConflict || resp["code"] != CodeConflict {
+		t.Errorf("duplicate register = %d %v, want 409 %s", status, resp, CodeConflict)
+	}
+
+	status, resp = authRequest(t, app, "POST", "/auth/login", "", AuthRequest{Email: "A@example.com ", Password: "password123"})
+	if token, _ := resp["token"].(string); status != fiber.StatusOK || token == "" {
+		t.Fatalf("login = %d %v", status, resp)
+	}
+
+	// 帳號不存在與密碼錯誤的回應完全相同
+	_, wrongPassword := authRequest(t, app, "POST", "/auth/login", "", AuthRequest{Email: "a@example.com", Password: "wrong-password"})
+	tests := []struct {
+		name string
+		req  AuthRequest
+	}{
+		{"wrong password", AuthRequest{Email: "a@example.com", Password: "wrong-password"}},
+		{"unknown email", AuthRequest{Email: "nobody@example.com", Password: "password123"}},
+		{"empty", AuthRequest{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := authRequest(t, app, "POST", "/auth/login", "", tt.req)
+			if status != fiber.StatusUnauthorized {
+				t.Errorf("login = %d, want 401", status)
+			}
+			if resp["code"] != wrongPassword["code"] || resp["error"] != wrongPassword["error"] {
+				t.Errorf("login = %v, want the same response as a wrong password %v", resp, wrongPassword)
+			}
+		})
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	db := testdb.Open(t)
+	app := newAuthTestApp(db, false)
+	token := register(t, app, "a@example.com")
+
+	if status, resp := authRequest(t, app, "GET", "/auth/me", token, nil); status != fiber.StatusOK || resp["email"] != "a@example.com" || resp["anonymous"] != false {
+		t.Errorf("GET /auth/me = %d %v", status, resp)
+	}
+
+	// 過期的 session 會被刪除
+	var userID string
+	if err := db.QueryRow("SELECT id FROM users WHERE email = 'a@example.com'").Scan(&userID); err != nil {
+		t.Fatalf("query user: %v", err)
+	}
+	const expired = "expired-token"
+	testdb.MustExec(t, db, "INSERT INTO sessions (id, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		hashToken(expired), userID, time.Now().Add(-2*time.Hour).UTC(), time.Now().Add(-time.Hour).UTC())
+	if status, resp := authRequest(t, app, "GET", "/auth/me", expired, nil); status != fiber.StatusUnauthorized || resp["code"] != CodeUnauthorized {
+		t.Errorf("expired session = %d %v, want 401", status, resp)
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sessions WHERE id = ?", hashToken(expired)).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expired session left %d rows, %v; want deleted", n, err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"no token", ""},
+		{"unknown token", "unknown-token"},
+		{"expired token used again", expired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := authRequest(t, app, "GET", "/auth/me", tt.token, nil); status != fiber.StatusUnauthorized {
+				t.Errorf("GET /auth/me = %d, want 401", status)
+			}
+		})
+	}
+}
+
+func TestRequireUserAnonymous(t *testing.T) {
+	db := testdb.Open(t)
+
+	// 還沒有本機單人帳號時，允許匿名也無法使用
+	app := newAuthTestApp(db, true)
+	if status, _ := authRequest(t, app, "GET", "/auth/me", "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous without the local user = %d, want 401", status)
+	}
+
+	testdb.MustExec(t, db, "INSERT INTO users (id, email, password_hash) VALUES ('user-001', ?, 'x')", localUserEmail)
+	status, resp := authRequest(t, app, "GET", "/auth/me", "", nil)
+	if status != fiber.StatusOK || resp["id"] != "user-001" || resp["anonymous"] != true || resp["admin"] != true {
+		t.Errorf("anonymous GET /auth/me = %d %v, want the local user as admin", status, resp)
+	}
+	if status, resp := authRequest(t, app, "GET", "/admin", "", nil); status != fiber.StatusOK || resp["userId"] != "user-001" {
+		t.Errorf("anonymous GET /admin = %d %v, want 200", status, resp)
+	}
+
+	// 帶 token 時仍以 token 為準
+	token := register(t, app, "a@example.com")
+	if status, resp := authRequest(t, app, "GET", "/auth/me", token, nil); status != fiber.StatusOK || resp["email"] != "a@example.com" {
+		t.Errorf("GET /auth/me with a token = %d %v", status, resp)
+	}
+	if status, _ := authRequest(t, app, "GET", "/auth/me", "unknown-token", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("unknown token with anonymous allowed = %d, want 401", status)
+	}
+
+	// 不允許匿名時，即使有本機單人帳號，未帶 token 也是 401
+	app = newAuthTestApp(db, false)
+	if status, _ := authRequest(t, app, "GET", "/auth/me", "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous with auth required = %d, want 401", status)
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	db := testdb.Open(t)
+	app := newAuthTestApp(db, false)
+	userToken := register(t, app, "a@example.com")
+	adminToken := register(t, app, adminEmail)
+
+	if status, resp := authRequest(t, app, "GET", "/admin", userToken, nil); status != fiber.StatusForbidden || resp["code"] != CodeForbidden {
+		t.Errorf("non-admin GET /admin = %d %v, want 403", status, resp)
+	}
+	if status, resp := authRequest(t, app, "GET", "/admin", adminToken, nil); status != fiber.StatusOK {
+		t.Errorf("admin GET /admin = %d %v, want 200", status, resp)
+	}
+	if status, resp := authRequest(t, app, "GET", "/auth/me", adminToken, nil); status != fiber.StatusOK || resp["admin"] != true {
+		t.Errorf("admin GET /auth/me = %d %v, want admin", status, resp)
+	}
+}
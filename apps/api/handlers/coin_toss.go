@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// significanceLevel 二項檢定的顯著水準
+const significanceLevel = 0.05
+
+// TossChoiceStats 擲硬幣結果 × 選擇的勝率
+type TossChoiceStats struct {
+	CoinToss  string   `json:"coinToss"`  // "won" | "lost"
+	Choice    string   `json:"choice"`    // 猜中的一方選擇的先後攻
+	PlayOrder string   `json:"playOrder"` // 自己的先後攻
+	Games     int      `json:"games"`
+	Wins      int      `json:"wins"`
+	Losses    int      `json:"losses"`
+	Draws     int      `json:"draws"`
+	WinRate   *float64 `json:"winRate"`
+	CI        Interval `json:"ci"` // Wilson score interval (95%)
+}
+
+// GetCoinToss 擲硬幣統計 (GET /stats/coin-toss)
+// 篩選條件同 GET /matches（e.g. myDeckMain 看某套牌組猜中時該選先攻還是後攻）；
+// 只統計有記錄擲硬幣的對局。pValue 為猜中率是否偏離 50% 的雙尾二項檢定
+func (h *StatsHandler) GetCoinToss(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := `SELECT m.coin_toss, m.toss_choice, COUNT(*),
+			COALESCE(SUM(CASE WHEN m.result = 'W' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.result = 'D' THEN 1 ELSE 0 END), 0)` +
+		matchesFromClause + where + " GROUP BY m.coin_toss, m.toss_choice"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	type key struct{ toss, choice string }
+	counts := map[key]TossChoiceStats{}
+	won, recorded, unrecorded := 0, 0, 0
+	for rows.Next() {
+		var toss, choice sql.NullString
+		var s TossChoiceStats
+		if err := rows.Scan(&toss, &choice, &s.Games, &s.Wins, &s.Draws); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		if !toss.Valid {
+			unrecorded += s.Games
+			continue
+		}
+		recorded += s.Games
+		if toss.String == "won" {
+			won += s.Games
+		}
+		counts[key{toss.String, choice.String}] = s
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	// 依固定順序列出四種組合（沒有對局的為 0）
+	byChoice := make([]TossChoiceStats, 0, 4)
+	for _, toss := range validCoinTosses {
+		for _, choice := range validPlayOrders {
+			s := counts[key{toss, choice}]
+			s.CoinToss, s.Choice = toss, choice
+			s.PlayOrder = choice
+			if toss == "lost" {
+				s.PlayOrder = opposite(choice)
+			}
+			s.Losses = s.Games - s.Wins - s.Draws
+			s.WinRate = ratio(s.Wins, s.Games)
+			s.CI = wilsonInterval(s.Wins, s.Games)
+			byChoice = append(byChoice, s)
+		}
+	}
+
+	pValue := binomialTest(won, recorded)
+	return c.JSON(fiber.Map{
+		"recorded":    recorded,
+		"unrecorded":  unrecorded,
+		"won":         won,
+		"lost":        recorded - won,
+		"tossWinRate": ratio(won, recorded),
+		"ci":          wilsonInterval(won, recorded),
+		"pValue":      pValue,
+		"significant": pValue < significanceLevel,
+		"byChoice":    byChoice,
+	})
+}
+
+// binomialTest 雙尾精確二項檢定（H0: p = 0.5）的 p 值；n 為 0 時回傳 1
+func binomialTest(k, n int) float64 {
+	if n == 0 {
+		return 1
+	}
+	// p = 0.5 時分布對稱：p 值 = 2 × P(X ≤ min(k, n-k))
+	m := k
+	if n-k < m {
+		m = n - k
+	}
+	lgN, _ := math.Lgamma(float64(n + 1))
+	tail := 0.0
+	for i := 0; i <= m; i++ {
+		lgI, _ := math.Lgamma(float64(i + 1))
+		lgNI, _ := math.Lgamma(float64(n - i + 1))
+		tail += math.Exp(lgN - lgI - lgNI - float64(n)*math.Ln2)
+	}
+	return math.Min(1, 2*tail)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBinomialTest(t *testing.T) {
+	tests := []struct {
+		k, n int
+		want float64
+	}{
+		{0, 0, 1},
+		{0, 1, 1},
+		{1, 1, 1},
+		{5, 10, 1},
+		{0, 10, 2.0 / 1024},
+		{2, 10, 112.0 / 1024},
+		{8, 10, 112.0 / 1024},
+		{9, 20, 0.823803},
+		{15, 20, 0.041389},
+	}
+	for _, tt := range tests {
+		if got := binomialTest(tt.k, tt.n); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("binomialTest(%d, %d) = %.6f, want %.6f", tt.k, tt.n, got, tt.want)
+		}
+	}
+
+	// 大樣本時仍需是有限值（以 Lgamma 計算，不會溢位）
+	if got := binomialTest(5000, 10000); math.IsNaN(got) || got < 0.99 {
+		t.Errorf("binomialTest(5000, 10000) = %v, want ≈ 1", got)
+	}
+	if got := binomialTest(4800, 10000); math.IsNaN(got) || got > 1e-4 {
+		t.Errorf("binomialTest(4800, 10000) = %v, want < 1e-4", got)
+	}
+}
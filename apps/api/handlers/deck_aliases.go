@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// DeckAliasesHandler 處理牌組別名
+type DeckAliasesHandler struct {
+	db *database.DB
+}
+
+// NewDeckAliasesHandler 建立新的 deck aliases handler
+func NewDeckAliasesHandler(db *database.DB) *DeckAliasesHandler {
+	return &DeckAliasesHandler{db: db}
+}
+
+// DeckAlias 牌組別名（Alias 寫入時會轉為模板名稱 Name）
+type DeckAlias struct {
+	ID         string    `json:"id"`
+	Alias      string    `json:"alias"`
+	TemplateID string    `json:"templateId"`
+	Name       string    `json:"name"`     // 模板的正式名稱
+	DeckType   string    `json:"deckType"` // 模板類型 "main" or "sub"
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CreateDeckAliasRequest 新增牌組別名請求
+type CreateDeckAliasRequest struct {
+	GameKey    string `json:"gameKey"` // 預設 "master_duel"
+	Alias      string `json:"alias"`
+	TemplateID string `json:"templateId"`
+}
+
+// UpdateDeckAliasRequest 更新牌組別名請求
+type UpdateDeckAliasRequest struct {
+	Alias      string `json:"alias,omitempty"`
+	TemplateID string `json:"templateId,omitempty"`
+}
+
+// GetDeckAliases 取得牌組別名 (GET /deck-aliases)
+// query: gameKey（預設 master_duel）、templateId（只列出某個模板的別名）
+func (h *DeckAliasesHandler) GetDeckAliases(c *fiber.Ctx) error {
+	query := `
+		SELECT a.id, a.alias, a.template_id, t.main, t.deck_type, a.created_at
+		FROM deck_aliases a
+		JOIN deck_templates t ON t.id = a.template_id
+		JOIN games g ON g.id = a.game_id
+		WHERE g.key = ?
+	`
+	args := []interface{}{c.Query("gameKey", "master_duel")}
+	if templateID := c.Query("templateId"); templateID != "" {
+		query += " AND a.template_id = ?"
+		args = append(args, templateID)
+	}
+	query += " ORDER BY t.main ASC, a.alias ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	aliases := []DeckAlias{}
+	for rows.Next() {
+		var a DeckAlias
+		var createdAt sql.NullTime
+		if err := rows.Scan(&a.ID, &a.Alias, &a.TemplateID, &a.Name, &a.DeckType, &createdAt); err != nil {
+			return internalError(c, "讀取資料失敗", err)
+		}
+		if createdAt.Valid {
+			a.CreatedAt = createdAt.Time
+		}
+		aliases = append(aliases, a)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "讀取資料失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"aliases": aliases,
+		"total":   len(aliases),
+	})
+}
+
+// CreateDeckAlias 新增牌組別名 (POST /deck-aliases)
+// 目前使用者已經以別名建立的對局牌組（e.g. 之前輸入過「天盃」）會一併合併到模板名稱，統計不再分散。
+func (h *DeckAliasesHandler) CreateDeckAlias(c *fiber.Ctx) error {
+	var req CreateDeckAliasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateCreateDeckAlias(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	gameID, errResp := gameIDForBody(c, h.db, req.GameKey)
+	if gameID == "" {
+		return errResp
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	name, errResp := h.checkAlias(c, tx, gameID, "", req.Alias, req.TemplateID)
+	if errResp != nil || name == "" {
+		return errResp
+	}
+
+	// 別名是模板內容的一部分（見 templateSnapshot），記在模板的變更紀錄
+	id := uuid.New().String()
+	err = auditTemplateUpdates(tx, currentUserID(c), []string{req.TemplateID}, func() error {
+		_, err := tx.Exec(`
+			INSERT INTO deck_aliases (id, game_id, alias, template_id, created_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, id, gameID, req.Alias, req.TemplateID)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "新增牌組別名失敗", err)
+	}
+
+	merged, err := mergeAliasDecks(tx, gameID, currentUserID(c), req.Alias, name)
+	if err != nil {
+		return internalError(c, "合併既有牌組失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "新增牌組別名失敗", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"id":      id,
+		"message": "牌組別名新增成功",
+		"merged":  merged,
+	})
+}
+
+// UpdateDeckAlias 更新牌組別名 (PATCH /deck-aliases/:id)
+func (h *DeckAliasesHandler) UpdateDeckAlias(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少別名 ID")
+	}
+
+	var req UpdateDeckAliasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateUpdateDeckAlias(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	if req.Alias == "" && req.TemplateID == "" {
+		return apiError(c, fiber.StatusUnprocessableEntity, CodeValidationFailed, "沒有要更新的欄位")
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	var gameID, alias, oldTemplateID string
+	err = tx.QueryRow("SELECT game_id, alias, template_id FROM deck_aliases WHERE id = ?", id).Scan(&gameID, &alias, &oldTemplateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到牌組別名")
+	}
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if req.Alias != "" {
+		alias = req.Alias
+	}
+	templateID := oldTemplateID
+	if req.TemplateID != "" {
+		templateID = req.TemplateID
+	}
+
+	name, errResp := h.checkAlias(c, tx, gameID, id, alias, templateID)
+	if errResp != nil || name == "" {
+		return errResp
+	}
+
+	// 改指向其他模板時，原本與新的模板都記一筆變更
+	templateIDs := []string{oldTemplateID}
+	if templateID != oldTemplateID {
+		templateIDs = append(templateIDs, templateID)
+	}
+	err = auditTemplateUpdates(tx, currentUserID(c), templateIDs, func() error {
+		_, err := tx.Exec("UPDATE deck_aliases SET alias = ?, template_id = ? WHERE id = ?", alias, templateID, id)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "更新牌組別名失敗", err)
+	}
+	merged, err := mergeAliasDecks(tx, gameID, currentUserID(c), alias, name)
+	if err != nil {
+		return internalError(c, "合併既有牌組失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "更新牌組別名失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "牌組別名更新成功",
+		"merged":  merged,
+	})
+}
+
+// DeleteDeckAlias 刪除牌組別名 (DELETE /deck-aliases/:id)
+// 已經寫入的對局不受影響（它們已使用正式名稱）。
+func (h *DeckAliasesHandler) DeleteDeckAlias(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少別名 ID")
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	var templateID string
+	err = tx.QueryRow("SELECT template_id FROM deck_aliases WHERE id = ?", id).Scan(&templateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到牌組別名")
+	}
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	err = auditTemplateUpdates(tx, currentUserID(c), []string{templateID}, func() error {
+		_, err := tx.Exec("DELETE FROM deck_aliases WHERE id = ?", id)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "刪除牌組別名失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "刪除牌組別名失敗", err)
+	}
+
+	return c.JSON(fiber.Map{"message": "牌組別名刪除成功"})
+}
+
+// checkAlias 檢查模板存在、別名不是模板本身的名稱，且不與遊戲中其他模板名稱或別名相同
+// （FoldDeckName 後比對，否則輸入時無法判斷要對應到哪個模板）；回傳模板名稱
+// 檢查失敗時已寫入錯誤回應，回傳的名稱為空字串。
+func (h *DeckAliasesHandler) checkAlias(c *fiber.Ctx, q database.Querier, gameID, id, alias, templateID string) (string, error) {
+	var name string
+	err := q.QueryRow("SELECT main FROM deck_templates WHERE id = ? AND game_id = ?", templateID, gameID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", validationFailed(c, fieldErrors{{Field: "templateId", Code: FieldNotFound, Message: "找不到牌組模板"}})
+	}
+	if err != nil {
+		return "", internalError(c, "查詢失敗", err)
+	}
+	if store.FoldDeckName(alias) == store.FoldDeckName(name) {
+		return "", validationFailed(c, fieldErrors{{Field: "alias", Code: FieldInvalid, Message: "別名與模板名稱相同"}})
+	}
+
+	conflict, err := store.FindDeckNameConflict(q, gameID, alias, id)
+	if err != nil {
+		return "", internalError(c, "查詢失敗", err)
+	}
+	if conflict != nil && conflict.Alias != "" {
+		return "", apiError(c, fiber.StatusConflict, CodeConflict, "別名已存在："+conflict.Alias+" → "+conflict.Name)
+	}
+	if conflict != nil {
+		return "", apiError(c, fiber.StatusConflict, CodeConflict, "別名與牌組模板名稱相同："+conflict.Name)
+	}
+	return name, nil
+}
+
+// mergeAliasDecks 將使用者之前以別名建立的對局牌組合併到正式名稱（見 store.MergeDeckNames），
+// 並寫入對局的變更紀錄；沒有時回傳 nil
+func mergeAliasDecks(q database.Querier, gameID, userID, alias, name string) (*store.DeckMergeSummary, error) {
+	ids, err := store.DeckNameMatchIDs(q, gameID, userID, []string{alias})
+	if err != nil {
+		return nil, err
+	}
+	var summary *store.DeckMergeSummary
+	err = auditMatchUpdates(q, userID, ids, func() (err error) {
+		summary, err = store.MergeDeckNames(q, gameID, userID, []string{alias}, name)
+		return err
+	})
+	if errors.Is(err, store.ErrDeckNotFound) {
+		return nil, nil
+	}
+	return summary, err
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// DecksHandler 處理牌組改名與合併
+type DecksHandler struct {
+	db *database.DB
+}
+
+// NewDecksHandler 建立新的 decks handler
+func NewDecksHandler(db *database.DB) *DecksHandler {
+	return &DecksHandler{db: db}
+}
+
+// RenameDeckRequest 牌組改名請求
+type RenameDeckRequest struct {
+	GameKey string `json:"gameKey"` // 預設 "master_duel"
+	From    string `json:"from"`
+	To      string `json:"to"`
+	DryRun  bool   `json:"dryRun"` // 只預覽，不寫入
+}
+
+// MergeDecksRequest 牌組合併請求（e.g. 把「天盃」合併到「天盃龍」）
+type MergeDecksRequest struct {
+	GameKey string   `json:"gameKey"` // 預設 "master_duel"
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+	DryRun  bool     `json:"dryRun"` // 只預覽，不寫入
+}
+
+// RenameDeck 牌組改名 (POST /decks/rename)
+// 目前使用者對局中的大軸、小軸名稱都會改；新名稱已存在時等同合併（見 MergeDecks）。
+func (h *DecksHandler) RenameDeck(c *fiber.Ctx) error {
+	var req RenameDeckRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+
+	req.To = store.NormalizeDeckName(req.To)
+
+	var errs fieldErrors
+	if errs.required("from", req.From) {
+		errs.maxLength("from", req.From, maxDeckNameLength)
+	}
+	if errs.required("to", req.To) {
+		errs.maxLength("to", req.To, maxDeckNameLength)
+	}
+	if req.From != "" && req.From == req.To {
+		errs.add("to", FieldInvalid, "新名稱與原名稱相同")
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	return h.merge(c, req.GameKey, []string{req.From}, req.To, req.DryRun)
+}
+
+// MergeDecks 將多個牌組名稱合併為一個 (POST /decks/merge)
+// 目前使用者使用舊名稱的對局改指向合併後的牌組，不再使用的牌組資料列會刪除；全部在同一個交易中完成。
+// 其他使用者的對局不受影響；共用的牌組模板改名或合併，舊名稱成為別名（見 store.MergeDeckNames）。
+// 每筆改動的對局與模板都會寫入變更紀錄。
+func (h *DecksHandler) MergeDecks(c *fiber.Ctx) error {
+	var req MergeDecksRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+
+	req.Target = store.NormalizeDeckName(req.Target)
+
+	var errs fieldErrors
+	if len(req.Sources) == 0 {
+		errs.add("sources", FieldRequired, "必填")
+	}
+	for _, s := range req.Sources {
+		if errs.required("sources", s) {
+			errs.maxLength("sources", s, maxDeckNameLength)
+		}
+		if s != "" && s == req.Target {
+			errs.add("sources", FieldInvalid, "不可包含合併目標 "+s)
+		}
+	}
+	if errs.required("target", req.Target) {
+		errs.maxLength("target", req.Target, maxDeckNameLength)
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	return h.merge(c, req.GameKey, req.Sources, req.Target, req.DryRun)
+}
+
+// merge 在單一交易中執行改名/合併；dryRun 時回傳相同的結果但不寫入
+func (h *DecksHandler) merge(c *fiber.Ctx, gameKey string, sources []string, target string, dryRun bool) error {
+	if gameKey == "" {
+		gameKey = "master_duel"
+	}
+	gameID, errResp := gameIDForBody(c, h.db, gameKey)
+	if gameID == "" {
+		return errResp
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	userID := currentUserID(c)
+	ids, err := store.DeckNameMatchIDs(tx, gameID, userID, sources)
+	if err != nil {
+		return internalError(c, "合併牌組失敗", err)
+	}
+	templateIDs, err := store.DeckTemplateIDs(tx, gameID, append([]string{target}, sources...))
+	if err != nil {
+		return internalError(c, "合併牌組失敗", err)
+	}
+	var summary *store.DeckMergeSummary
+	err = auditTemplateUpdates(tx, userID, templateIDs, func() error {
+		return auditMatchUpdates(tx, userID, ids, func() (err error) {
+			summary, err = store.MergeDeckNames(tx, gameID, userID, sources, target)
+			return err
+		})
+	})
+	if errors.Is(err, store.ErrDeckNotFound) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到牌組")
+	}
+	if err != nil {
+		return internalError(c, "合併牌組失敗", err)
+	}
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return internalError(c, "合併牌組失敗", err)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"dryRun":  dryRun,
+		"summary": summary,
+	})
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
 )
 
 // DeckTemplate 牌組模板（前端選項用）
@@ -19,6 +21,7 @@ type DeckTemplate struct {
 
 // CreateDeckTemplateRequest 新增牌組模板請求
 type CreateDeckTemplateRequest struct {
+	GameKey  string `json:"gameKey"`
 	Name     string `json:"name"`
 	Theme    string `json:"theme"`
 	DeckType string `json:"deckType"` // "main" or "sub"
@@ -30,18 +33,18 @@ type UpdateDeckTemplateRequest struct {
 	Theme string `json:"theme,omitempty"`
 }
 
-// GetDeckTemplates 取得所有牌組模板
-func GetDeckTemplates(c *fiber.Ctx, db *sql.DB) error {
+// GetDeckTemplates 取得遊戲的所有牌組模板 (GET /deck-templates?gameKey=)
+func GetDeckTemplates(c *fiber.Ctx, db *database.DB) error {
 	deckType := c.Query("type", "") // "main", "sub", or "" for all
 
 	var query string
-	var args []interface{}
+	args := []interface{}{currentGameID(c)}
 
 	if deckType != "" {
 		query = `
 			SELECT id, main as name, theme, deck_type, created_at
 			FROM deck_templates
-			WHERE deck_type = ?
+			WHERE game_id = ? AND deck_type = ?
 			ORDER BY name ASC
 		`
 		args = append(args, deckType)
@@ -49,6 +52,7 @@ func GetDeckTemplates(c *fiber.Ctx, db *sql.DB) error {
 		query = `
 			SELECT id, main as name, theme, deck_type, created_at
 			FROM deck_templates
+			WHERE game_id = ?
 			ORDER BY deck_type ASC, name ASC
 		`
 	}
@@ -86,56 +90,145 @@ func GetDeckTemplates(c *fiber.Ctx, db *sql.DB) error {
 	})
 }
 
+// DeckSuggestion 牌組名稱建議
+type DeckSuggestion struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Theme    string  `json:"theme"`
+	DeckType string  `json:"deckType"`
+	Alias    string  `json:"alias,omitempty"` // 由別名比對到時的別名
+	Score    float64 `json:"score"`           // 0~1，越高越接近
+	Reason   string  `json:"reason"`          // "exact" | "prefix" | "contains" | "similar"
+}
+
+// SuggestDeckTemplates 牌組名稱建議 (GET /deck-templates/suggest?gameKey=&q=)
+// 依相似度排序列出既有的模板（含別名），新增前可先確認是否已有相同的牌組。
+// query: q（必填）、type（"main" / "sub"）、limit（預設 10，最多 50）
+func SuggestDeckTemplates(c *fiber.Ctx, db *database.DB) error {
+	q := c.Query("q")
+	if store.NormalizeDeckName(q) == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "q 為必填")
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 || limit > 50 {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "limit 必須介於 1 到 50")
+	}
+	deckType := c.Query("type")
+	if deckType != "" && deckType != "main" && deckType != "sub" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "type 必須為 main 或 sub")
+	}
+
+	names, err := store.DeckNames(db, currentGameID(c))
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if deckType != "" {
+		filtered := names[:0]
+		for _, n := range names {
+			if n.DeckType == deckType {
+				filtered = append(filtered, n)
+			}
+		}
+		names = filtered
+	}
+
+	suggestions := []DeckSuggestion{}
+	for _, s := range store.Suggest(names, q, limit) {
+		suggestions = append(suggestions, DeckSuggestion{
+			ID:       s.TemplateID,
+			Name:     s.Name,
+			Theme:    s.Theme,
+			DeckType: s.DeckType,
+			Alias:    s.Alias,
+			Score:    s.Score,
+			Reason:   s.Reason,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"query":       store.NormalizeDeckName(q),
+		"suggestions": suggestions,
+		"total":       len(suggestions),
+	})
+}
+
 // CreateDeckTemplate 新增牌組模板
-func CreateDeckTemplate(c *fiber.Ctx, db *sql.DB) error {
+func CreateDeckTemplate(c *fiber.Ctx, db *database.DB) error {
 	var req CreateDeckTemplateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return invalidBody(c, err)
 	}
 
-	if req.Name == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Name is required"})
+	if errs := validateCreateDeckTemplate(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
-	if req.Theme == "" {
-		req.Theme = "連結"
+
+	gameID, errResp := gameIDForBody(c, db, req.GameKey)
+	if gameID == "" {
+		return errResp
 	}
-	if req.DeckType == "" {
-		req.DeckType = "main"
+
+	// 只差在大小寫、全形半形或是別名時，視為同一個牌組
+	name, err := store.ResolveDeckName(db, gameID, req.Name)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
 	}
+	req.Name = name
 
 	// 檢查是否已存在
 	var exists bool
-	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM deck_templates WHERE main = ? AND deck_type = ?)`, req.Name, req.DeckType).Scan(&exists)
+	err = db.QueryRow(`SELECT EXISTS(SELECT 1 FROM deck_templates WHERE game_id = ? AND main = ? AND deck_type = ?)`, gameID, req.Name, req.DeckType).Scan(&exists)
 	if err == nil && exists {
-		return c.Status(400).JSON(fiber.Map{"error": "Deck template already exists"})
+		return apiError(c, fiber.StatusConflict, CodeConflict, "牌組模板已存在："+req.Name)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return internalError(c, "新增牌組模板失敗", err)
 	}
+	defer tx.Rollback()
 
 	id := uuid.New().String()
-	_, err = db.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO deck_templates (id, game_id, main, theme, deck_type, created_at)
-		VALUES (?, 'game-md', ?, ?, ?, CURRENT_TIMESTAMP)
-	`, id, req.Name, req.Theme, req.DeckType)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, id, gameID, req.Name, req.Theme, req.DeckType)
+
+	if err != nil {
+		return internalError(c, "新增牌組模板失敗", err)
+	}
 
+	// 變更紀錄
+	after, err := loadTemplateSnapshot(tx, id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create deck template: " + err.Error()})
+		return internalError(c, "新增牌組模板失敗", err)
+	}
+	if err := recordAudit(tx, currentUserID(c), auditDeckTemplate, id, nil, after, nil); err != nil {
+		return internalError(c, "新增牌組模板失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "新增牌組模板失敗", err)
 	}
 
 	return c.Status(201).JSON(fiber.Map{
 		"id":      id,
-		"message": "Deck template created successfully",
+		"message": "牌組模板新增成功",
 	})
 }
 
 // UpdateDeckTemplate 更新牌組模板
-func UpdateDeckTemplate(c *fiber.Ctx, db *sql.DB) error {
+func UpdateDeckTemplate(c *fiber.Ctx, db *database.DB) error {
 	id := c.Params("id")
 	if id == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "ID is required"})
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少模板 ID")
 	}
 
 	var req UpdateDeckTemplateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return invalidBody(c, err)
+	}
+	if errs := validateUpdateDeckTemplate(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
 	// 建構動態更新語句
@@ -152,43 +245,78 @@ func UpdateDeckTemplate(c *fiber.Ctx, db *sql.DB) error {
 	}
 
 	if len(updates) == 0 {
-		return c.Status(400).JSON(fiber.Map{"error": "No fields to update"})
+		return apiError(c, fiber.StatusUnprocessableEntity, CodeValidationFailed, "沒有要更新的欄位")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return internalError(c, "更新牌組模板失敗", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadTemplateSnapshot(tx, id)
+	if err != nil {
+		return internalError(c, "更新牌組模板失敗", err)
+	}
+	if before == nil {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到牌組模板")
 	}
 
 	args = append(args, id)
 	query := "UPDATE deck_templates SET " + joinStrings(updates, ", ") + " WHERE id = ?"
 
-	result, err := db.Exec(query, args...)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update deck template"})
+	if _, err := tx.Exec(query, args...); err != nil {
+		return internalError(c, "更新牌組模板失敗", err)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Deck template not found"})
+	// 變更紀錄（牌組模板為共用，記下是誰改的）
+	after, err := loadTemplateSnapshot(tx, id)
+	if err != nil {
+		return internalError(c, "更新牌組模板失敗", err)
+	}
+	if err := recordAudit(tx, currentUserID(c), auditDeckTemplate, id, before, after, nil); err != nil {
+		return internalError(c, "更新牌組模板失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "更新牌組模板失敗", err)
 	}
 
-	return c.JSON(fiber.Map{"message": "Deck template updated successfully"})
+	return c.JSON(fiber.Map{"message": "牌組模板更新成功"})
 }
 
 // DeleteDeckTemplate 刪除牌組模板
-func DeleteDeckTemplate(c *fiber.Ctx, db *sql.DB) error {
+func DeleteDeckTemplate(c *fiber.Ctx, db *database.DB) error {
 	id := c.Params("id")
 	if id == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "ID is required"})
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少模板 ID")
 	}
 
-	result, err := db.Exec(`DELETE FROM deck_templates WHERE id = ?`, id)
+	tx, err := db.Begin()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete deck template"})
+		return internalError(c, "刪除牌組模板失敗", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Deck template not found"})
+	// 刪除前的內容（含別名）記在變更紀錄中，可用 POST /audit/:id/revert 還原
+	before, err := loadTemplateSnapshot(tx, id)
+	if err != nil {
+		return internalError(c, "刪除牌組模板失敗", err)
+	}
+	if before == nil {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到牌組模板")
+	}
+
+	if _, err := deleteTemplateRows(tx, id); err != nil {
+		return internalError(c, "刪除牌組模板失敗", err)
+	}
+	if err := recordAudit(tx, currentUserID(c), auditDeckTemplate, id, before, nil, nil); err != nil {
+		return internalError(c, "刪除牌組模板失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "刪除牌組模板失敗", err)
 	}
 
-	return c.JSON(fiber.Map{"message": "Deck template deleted successfully"})
+	return c.JSON(fiber.Map{"message": "牌組模板刪除成功"})
 }
 
 // Note: joinStrings is defined in matches.go
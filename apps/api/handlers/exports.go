@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/importer"
+	"github.com/harvc/duellog/apps/api/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportsHandler 處理資料匯出
+type ExportsHandler struct {
+	db *database.DB
+}
+
+// NewExportsHandler 建立新的 exports handler
+func NewExportsHandler(db *database.DB) *ExportsHandler {
+	return &ExportsHandler{db: db}
+}
+
+// exportColumns CSV/XLSX 欄位順序，與 cmd/import、POST /imports 的預設欄位相同，匯出檔可直接重新匯入
+// （OutcomeReason 之後的欄位格式見 importer.NormalizeTags、importer.FormatGames）
+var exportColumns = []string{
+	"Rank", "Account", "本家(我方)", "小軸(我方)", "勝負", "先後攻",
+	"本家(敵方)", "小軸(敵方)", "備註", "Date", "Season", "Mode",
+	"OutcomeReason", "CoinToss", "Choice", "Tags", "Games",
+}
+
+// exportBatch 每批讀取的對局數（各局記錄與標籤以批次查詢）
+const exportBatch = 500
+
+// ExportMatches 匯出對局 (GET /exports/matches)
+// query:
+//   - format: "csv"（預設）、"json" 或 "xlsx"（每個賽季一個工作表）
+//   - 篩選條件與 GET /matches 相同（見 buildMatchFilters）
+//   - sort: 同 GET /matches（預設依日期由舊到新）
+//
+// 結果以串流寫出，不會一次載入所有對局。
+func (h *ExportsHandler) ExportMatches(c *fiber.Ctx) error {
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "json" && format != "xlsx" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "format 必須為 csv、json 或 xlsx")
+	}
+	orderBy, err := parseSort(c, matchSortColumns, "m.date ASC, m.created_at ASC")
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	if format == "xlsx" {
+		// 依賽季分工作表，同一賽季的對局需連續
+		orderBy = "s.code ASC, " + orderBy
+	}
+
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := "SELECT" + matchSelectColumns + matchesFromClause + where + " ORDER BY " + orderBy + ", m.id ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	filename := fmt.Sprintf("matches-%s.%s", time.Now().Format("20060102"), format)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	var write func(w *bufio.Writer, each matchIterator) error
+	switch format {
+	case "csv":
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		write = writeMatchesCSV
+	case "json":
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		write = writeMatchesJSON
+	case "xlsx":
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		write = writeMatchesXLSX
+	}
+
+	// 回應開始後無法再改變狀態碼，串流途中的錯誤只能記錄下來
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer rows.Close()
+		each := func(fn func(models.MatchWithDetails) error) error {
+			return forEachExportMatch(h.db, rows, fn)
+		}
+		if err := write(w, each); err != nil {
+			log.Printf("export matches (%s): %v", format, err)
+		}
+	})
+	return nil
+}
+
+// matchIterator 依序把每一筆對局交給 fn
+type matchIterator func(fn func(models.MatchWithDetails) error) error
+
+// forEachExportMatch 依序讀出 rows 中的對局，每 exportBatch 筆載入各局記錄與標籤後交給 fn
+func forEachExportMatch(q database.Querier, rows *sql.Rows, fn func(models.MatchWithDetails) error) error {
+	batch := make([]models.MatchWithDetails, 0, exportBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := loadMatchGames(q, batch); err != nil {
+			return err
+		}
+		if err := loadMatchTags(q, batch); err != nil {
+			return err
+		}
+		for _, m := range batch {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		m, err := scanMatch(rows)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, m)
+		if len(batch) == exportBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// exportRecord 一筆對局轉為匯出欄位（順序見 exportColumns）
+func exportRecord(m models.MatchWithDetails) ([]string, error) {
+	games, err := importer.FormatGames(m.Games)
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		m.Rank,
+		"",
+		m.MyDeck.Main,
+		derefString(m.MyDeck.Sub),
+		m.Result,
+		m.PlayOrder,
+		m.OppDeck.Main,
+		derefString(m.OppDeck.Sub),
+		derefString(m.Note),
+		dateOnly(m.Date),
+		m.SeasonCode,
+		m.Mode,
+		m.OutcomeReason,
+		derefString(m.CoinToss),
+		derefString(m.Choice),
+		importer.FormatTags(m.Tags),
+		games,
+	}, nil
+}
+
+// writeMatchesCSV 寫出 CSV（含 UTF-8 BOM，讓 Excel 正確顯示中文）
+func writeMatchesCSV(w *bufio.Writer, each matchIterator) error {
+	if _, err := w.WriteString("\uFEFF"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportColumns); err != nil {
+		return err
+	}
+	err := each(func(m models.MatchWithDetails) error {
+		record, err := exportRecord(m)
+		if err != nil {
+			return err
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMatchesJSON 寫出 {"matches": [...], "total": n}（matches 格式與 GET /matches 相同）
+func writeMatchesJSON(w *bufio.Writer, each matchIterator) error {
+	if _, err := w.WriteString(`{"matches":[`); err != nil {
+		return err
+	}
+	total := 0
+	err := each(func(m models.MatchWithDetails) error {
+		m.Date = dateOnly(m.Date)
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		total++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, `],"total":%d}`, total)
+	return err
+}
+
+// writeMatchesXLSX 寫出 XLSX，每個賽季一個工作表（rows 需依賽季排序）
+func writeMatchesXLSX(w *bufio.Writer, each matchIterator) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	header := make([]interface{}, len(exportColumns))
+	for i, col := range exportColumns {
+		header[i] = col
+	}
+
+	var sw *excelize.StreamWriter
+	var season string
+	sheets := map[string]bool{} // 已使用的工作表名稱（小寫）
+	rowNum := 0
+
+	// startSheet 結束目前的工作表並開始新的工作表（第一個沿用預設的 Sheet1）
+	startSheet := func(name string) error {
+		if sw != nil {
+			if err := sw.Flush(); err != nil {
+				return err
+			}
+		}
+		name = xlsxSheetName(name, sheets)
+		if len(sheets) == 0 {
+			if err := f.SetSheetName("Sheet1", name); err != nil {
+				return err
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			return err
+		}
+		sheets[strings.ToLower(name)] = true
+
+		var err error
+		if sw, err = f.NewStreamWriter(name); err != nil {
+			return err
+		}
+		rowNum = 1
+		return sw.SetRow("A1", header)
+	}
+
+	err := each(func(m models.MatchWithDetails) error {
+		if sw == nil || m.SeasonCode != season {
+			season = m.SeasonCode
+			if err := startSheet(season); err != nil {
+				return err
+			}
+		}
+
+		record, err := exportRecord(m)
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(record))
+		for i, v := range record {
+			values[i] = v
+		}
+		rowNum++
+		cell, err := excelize.CoordinatesToCellName(1, rowNum)
+		if err != nil {
+			return err
+		}
+		return sw.SetRow(cell, values)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 沒有資料時仍輸出只有標題列的工作表
+	if sw == nil {
+		if err := startSheet("Matches"); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// maxSheetName 工作表名稱的最大字數
+const maxSheetName = 31
+
+// xlsxSheetName 可用的工作表名稱：[]:*?/\ 換成 _、去掉開頭與結尾的 '、最多 31 個字；
+// used 為已使用的名稱（小寫，Excel 不分大小寫），重複時截短後加上 " (2)"、" (3)"…
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, "'")
+	if name == "" || strings.EqualFold(name, "History") { // History 為 Excel 保留名稱
+		name = "Matches"
+	}
+
+	base := []rune(name)
+	for n := 1; ; n++ {
+		suffix := ""
+		if n > 1 {
+			suffix = fmt.Sprintf(" (%d)", n)
+		}
+		candidate := base
+		if limit := maxSheetName - len(suffix); len(candidate) > limit {
+			candidate = candidate[:limit]
+		}
+		name = strings.TrimRight(string(candidate), "'") + suffix
+		if !used[strings.ToLower(name)] {
+			return name
+		}
+	}
+}
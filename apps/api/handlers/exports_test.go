@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXlsxSheetName(t *testing.T) {
+	long := strings.Repeat("賽", 40)
+	tests := []struct {
+		name string
+		used []string
+		want string
+	}{
+		{"S40", nil, "S40"},
+		{"S40/S41: [test]?", nil, "S40_S41_ _test__"},
+		{"'quoted'", nil, "quoted"},
+		{"", nil, "Matches"},
+		{"history", nil, "Matches"},
+		{"s40", []string{"s40"}, "s40 (2)"},
+		{"S40", []string{"s40", "s40 (2)"}, "S40 (3)"},
+		{long, nil, strings.Repeat("賽", maxSheetName)},
+		{long, []string{strings.Repeat("賽", maxSheetName)}, strings.Repeat("賽", maxSheetName-4) + " (2)"},
+	}
+	for _, tt := range tests {
+		used := map[string]bool{}
+		for _, u := range tt.used {
+			used[u] = true
+		}
+		if got := xlsxSheetName(tt.name, used); got != tt.want {
+			t.Errorf("xlsxSheetName(%q, %v) = %q, want %q", tt.name, tt.used, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// gameIDKey c.Locals 中存放目前遊戲 ID 的 key（由 RequireGameKey 設定）
+const gameIDKey = "gameID"
+
+// GamesHandler 處理遊戲（Master Duel、PTCG …）
+type GamesHandler struct {
+	db *database.DB
+}
+
+// NewGamesHandler 建立新的 games handler
+func NewGamesHandler(db *database.DB) *GamesHandler {
+	return &GamesHandler{db: db}
+}
+
+// Game 遊戲
+type Game struct {
+	ID      string `json:"id"`
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Matches int    `json:"matches"` // 目前使用者在此遊戲的對局數
+}
+
+// CreateGameRequest 新增遊戲請求
+type CreateGameRequest struct {
+	Key  string `json:"key"` // e.g. "ptcg"，建立後作為各 API 的 gameKey
+	Name string `json:"name"`
+}
+
+// UpdateGameRequest 更新遊戲請求
+type UpdateGameRequest struct {
+	Key  string `json:"key,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// RequireGameKey 需要 gameKey 查詢參數的路由（對局、統計、牌組模板）使用
+// 解析後的遊戲 ID 存在 c.Locals，由 currentGameID 取得。
+func (h *GamesHandler) RequireGameKey(c *fiber.Ctx) error {
+	key := c.Query("gameKey")
+	if key == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "gameKey 為必填")
+	}
+	var gameID string
+	err := h.db.QueryRow("SELECT id FROM games WHERE key = ?", key).Scan(&gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "找不到遊戲："+key)
+	}
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	c.Locals(gameIDKey, gameID)
+	return c.Next()
+}
+
+// gameIDForBody 以請求內容中的 gameKey 查詢遊戲 ID：找不到時回傳 422，查詢失敗時回傳 500
+// 失敗時已寫入錯誤回應，回傳的 ID 為空字串。
+func gameIDForBody(c *fiber.Ctx, q database.Querier, key string) (string, error) {
+	var gameID string
+	err := q.QueryRow("SELECT id FROM games WHERE key = ?", key).Scan(&gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", validationFailed(c, fieldErrors{{Field: "gameKey", Code: FieldNotFound, Message: "找不到遊戲"}})
+	}
+	if err != nil {
+		return "", internalError(c, "查詢遊戲失敗", err)
+	}
+	return gameID, nil
+}
+
+// currentGameID 取得 RequireGameKey 設定的遊戲 ID
+func currentGameID(c *fiber.Ctx) string {
+	gameID, _ := c.Locals(gameIDKey).(string)
+	return gameID
+}
+
+// GetGames 取得所有遊戲 (GET /games)
+func (h *GamesHandler) GetGames(c *fiber.Ctx) error {
+	rows, err := h.db.Query(`
+		SELECT g.id, g.key, g.name, COUNT(m.id)
+		FROM games g
+		LEFT JOIN matches m ON m.game_id = g.id AND m.user_id = ?
+		GROUP BY g.id, g.key, g.name
+		ORDER BY g.name ASC
+	`, currentUserID(c))
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	games := []Game{}
+	for rows.Next() {
+		var g Game
+		if err := rows.Scan(&g.ID, &g.Key, &g.Name, &g.Matches); err != nil {
+			return internalError(c, "讀取資料失敗", err)
+		}
+		games = append(games, g)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "讀取資料失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"games": games,
+		"total": len(games),
+	})
+}
+
+// CreateGame 新增遊戲 (POST /games)
+func (h *GamesHandler) CreateGame(c *fiber.Ctx) error {
+	var req CreateGameRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateCreateGame(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	var exists bool
+	if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM games WHERE key = ?)", req.Key).Scan(&exists); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if exists {
+		return apiError(c, fiber.StatusConflict, CodeConflict, "遊戲已存在")
+	}
+
+	id := "game-" + uuid.New().String()
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("INSERT INTO games (id, key, name) VALUES (?, ?, ?)", id, req.Key, req.Name); err != nil {
+		return internalError(c, "新增遊戲失敗", err)
+	}
+	after := gameSnapshot{ID: id, Key: req.Key, Name: req.Name}
+	if err := recordAudit(tx, currentUserID(c), auditGame, id, nil, after, nil); err != nil {
+		return internalError(c, "新增遊戲失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "新增遊戲失敗", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"id":      id,
+		"message": "遊戲新增成功",
+	})
+}
+
+// UpdateGame 更新遊戲 (PATCH /games/:id)
+func (h *GamesHandler) UpdateGame(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少遊戲 ID")
+	}
+
+	var req UpdateGameRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateUpdateGame(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	updates := []string{}
+	args := []interface{}{}
+	if req.Key != "" {
+		var exists bool
+		if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM games WHERE key = ? AND id <> ?)", req.Key, id).Scan(&exists); err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		if exists {
+			return apiError(c, fiber.StatusConflict, CodeConflict, "遊戲已存在")
+		}
+		updates = append(updates, "key = ?")
+		args = append(args, req.Key)
+	}
+	if req.Name != "" {
+		updates = append(updates, "name = ?")
+		args = append(args, req.Name)
+	}
+	if len(updates) == 0 {
+		return apiError(c, fiber.StatusUnprocessableEntity, CodeValidationFailed, "沒有要更新的欄位")
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadGameSnapshot(tx, id)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if before == nil {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到遊戲")
+	}
+	args = append(args, id)
+	if _, err := tx.Exec("UPDATE games SET "+joinStrings(updates, ", ")+" WHERE id = ?", args...); err != nil {
+		return internalError(c, "更新遊戲失敗", err)
+	}
+	after, err := loadGameSnapshot(tx, id)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if err := recordAudit(tx, currentUserID(c), auditGame, id, before, after, nil); err != nil {
+		return internalError(c, "更新遊戲失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "更新遊戲失敗", err)
+	}
+
+	return c.JSON(fiber.Map{"message": "遊戲更新成功"})
+}
+
+// DeleteGame 刪除遊戲 (DELETE /games/:id)
+// 已有對局（任何使用者）的遊戲不可刪除；否則一併刪除其賽季、牌組、模板、別名與階級。
+func (h *GamesHandler) DeleteGame(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少遊戲 ID")
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	var matches int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM matches WHERE game_id = ?", id).Scan(&matches); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if matches > 0 {
+		return apiError(c, fiber.StatusConflict, CodeConflict, "此遊戲已有對局記錄，無法刪除")
+	}
+
+	game, err := loadGameSnapshot(tx, id)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if game == nil {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到遊戲")
+	}
+
+	// 一併刪除的牌組模板與賽季也各記一筆刪除（模板的變更紀錄可以還原）
+	userID := currentUserID(c)
+	for _, deleted := range []struct {
+		entity, table string
+		load          func(q database.Querier, id string) (interface{}, error)
+	}{
+		{auditDeckTemplate, "deck_templates", func(q database.Querier, id string) (interface{}, error) { return loadTemplateSnapshot(q, id) }},
+		{auditSeason, "seasons", func(q database.Querier, id string) (interface{}, error) { return loadSeasonSnapshot(q, id) }},
+	} {
+		ids, err := idsForGame(tx, deleted.table, id)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		for _, entityID := range ids {
+			before, err := deleted.load(tx, entityID)
+			if err != nil {
+				return internalError(c, "查詢失敗", err)
+			}
+			if err := recordAudit(tx, userID, deleted.entity, entityID, before, nil, nil); err != nil {
+				return internalError(c, "刪除遊戲失敗", err)
+			}
+		}
+	}
+
+	for _, table := range []string{"deck_aliases", "deck_templates", "decks", "seasons", "ranks"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE game_id = ?", id); err != nil {
+			return internalError(c, "刪除遊戲失敗", err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM games WHERE id = ?", id); err != nil {
+		return internalError(c, "刪除遊戲失敗", err)
+	}
+	if err := recordAudit(tx, userID, auditGame, id, game, nil, nil); err != nil {
+		return internalError(c, "刪除遊戲失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "刪除遊戲失敗", err)
+	}
+
+	return c.JSON(fiber.Map{"message": "遊戲刪除成功"})
+}
+
+// idsForGame 資料表中屬於遊戲的資料列 ID
+func idsForGame(q database.Querier, table, gameID string) ([]string, error) {
+	rows, err := q.Query("SELECT id FROM "+table+" WHERE game_id = ? ORDER BY id", gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/importer"
+	"github.com/harvc/duellog/apps/api/models"
+)
+
+// ImportsHandler 處理 CSV/TSV 匯入
+type ImportsHandler struct {
+	db *database.DB
+}
+
+// NewImportsHandler 建立新的 imports handler
+func NewImportsHandler(db *database.DB) *ImportsHandler {
+	return &ImportsHandler{db: db}
+}
+
+// ImportRequest 匯入請求（multipart/form-data 上傳檔案，或 JSON 貼上文字）
+type ImportRequest struct {
+	Text      string                 `json:"text"`      // 貼上的 CSV/TSV 文字（沒有上傳 file 時使用）
+	GameKey   string                 `json:"gameKey"`   // 預設 "master_duel"
+	Delimiter string                 `json:"delimiter"` // "comma" | "tab" | ""（自動判斷）
+	NoHeader  bool                   `json:"noHeader"`  // 第一行就是資料（mapping 只能使用索引）
+	Mapping   importer.ColumnMapping `json:"mapping"`   // 欄位對應，e.g. {"date": "日期", "result": 4}；省略時使用 cmd/import 的欄位順序
+}
+
+// PreviewImport 解析並預覽匯入結果，不寫入資料庫 (POST /imports)
+func (h *ImportsHandler) PreviewImport(c *fiber.Ctx) error {
+	return h.runImport(c, true)
+}
+
+// CommitImport 寫入匯入資料 (POST /imports/commit)
+// 請求內容與 POST /imports 相同；已存在的對局會略過，重複送出不會產生重複資料。
+func (h *ImportsHandler) CommitImport(c *fiber.Ctx) error {
+	return h.runImport(c, false)
+}
+
+func (h *ImportsHandler) runImport(c *fiber.Ctx, dryRun bool) error {
+	req, input, err := parseImportRequest(c)
+	if err != nil {
+		return invalidBody(c, err)
+	}
+
+	var errs fieldErrors
+	delimiter, ok := map[string]rune{"": 0, "comma": ',', "tab": '\t'}[req.Delimiter]
+	if !ok {
+		errs.oneOf("delimiter", req.Delimiter, []string{"comma", "tab"})
+	}
+	if req.GameKey == "" {
+		req.GameKey = "master_duel"
+	}
+	if input == nil {
+		errs.add("file", FieldRequired, "請上傳檔案或貼上文字")
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	gameID, errResp := gameIDForBody(c, h.db, req.GameKey)
+	if gameID == "" {
+		return errResp
+	}
+
+	rows, rowErrors, err := importer.Parse(input, importer.ParseOptions{
+		Delimiter: delimiter,
+		NoHeader:  req.NoHeader,
+		Columns:   req.Mapping,
+	})
+	if err != nil {
+		var mappingErr *importer.MappingError
+		if errors.As(err, &mappingErr) {
+			return validationFailed(c, fieldErrors{{Field: "mapping", Code: FieldInvalid, Message: err.Error()}})
+		}
+		return validationFailed(c, fieldErrors{{Field: "file", Code: FieldInvalid, Message: err.Error()}})
+	}
+
+	// 與 POST /matches 相同的欄位驗證（長度限制等）
+	valid := rows[:0]
+	for _, row := range rows {
+		if errs := validateImportRow(row, req.GameKey); len(errs) > 0 {
+			rowErrors = append(rowErrors, importer.RowError{Line: row.Line, Reason: errs.String(), Raw: row.Raw})
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	userID := currentUserID(c)
+	report, err := importer.Import(h.db, valid, rowErrors, importer.Options{
+		UserID:  userID,
+		GameID:  gameID,
+		DryRun:  dryRun,
+		Preview: dryRun,
+		AfterInsert: func(q database.Querier, matchID string) error {
+			return auditMatchCreates(q, userID, []string{matchID})
+		},
+	})
+	if err != nil {
+		return internalError(c, "匯入失敗", err)
+	}
+	return c.JSON(report)
+}
+
+// parseImportRequest 讀取請求；input 為 nil 表示沒有上傳檔案也沒有貼上文字
+func parseImportRequest(c *fiber.Ctx) (ImportRequest, io.Reader, error) {
+	var req ImportRequest
+
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		if err := c.BodyParser(&req); err != nil {
+			return req, nil, err
+		}
+		if strings.TrimSpace(req.Text) == "" {
+			return req, nil, nil
+		}
+		return req, strings.NewReader(req.Text), nil
+	}
+
+	req.Text = c.FormValue("text")
+	req.GameKey = c.FormValue("gameKey")
+	req.Delimiter = c.FormValue("delimiter")
+	if v := c.FormValue("noHeader"); v != "" {
+		noHeader, err := strconv.ParseBool(v)
+		if err != nil {
+			return req, nil, fmt.Errorf("noHeader: %w", err)
+		}
+		req.NoHeader = noHeader
+	}
+	if v := c.FormValue("mapping"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Mapping); err != nil {
+			return req, nil, fmt.Errorf("mapping: %w", err)
+		}
+	}
+
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return req, nil, err
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return req, nil, err
+		}
+		return req, bytes.NewReader(data), nil
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return req, nil, nil
+	}
+	return req, strings.NewReader(req.Text), nil
+}
+
+// validateImportRow 以新增對局的規則驗證一筆匯入資料
+func validateImportRow(row importer.Row, gameKey string) fieldErrors {
+	req := models.CreateMatchRequest{
+		GameKey:    gameKey,
+		SeasonCode: row.SeasonCode,
+		Date:       row.Date,
+		Mode:       row.Mode,
+		Rank:       row.Rank,
+		MyDeck:     models.DeckForm{Main: row.MyMain, Sub: row.MySub},
+		OppDeck:    models.DeckForm{Main: row.OppMain, Sub: row.OppSub},
+		PlayOrder:  row.PlayOrder,
+		Result:     row.Result,
+		Note:       row.Note,
+
+		OutcomeReason: row.OutcomeReason,
+		CoinToss:      row.CoinToss,
+		Choice:        row.Choice,
+		Games:         row.Games,
+		Tags:          row.Tags,
+	}
+	return validateCreateMatch(&req)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/models"
+)
+
+// matchIDChunk 一次查詢的對局數（避免 IN 的參數過多）
+const matchIDChunk = 500
+
+// gamesResult 由各局推導對局結果（勝局多為 W、敗局多為 L、相同為 D）與先後攻（第 1 局）
+func gamesResult(games []models.MatchGameForm) (result, playOrder string) {
+	wins, losses := 0, 0
+	for _, g := range games {
+		switch g.Result {
+		case "W":
+			wins++
+		case "L":
+			losses++
+		}
+	}
+	switch {
+	case wins > losses:
+		result = "W"
+	case losses > wins:
+		result = "L"
+	default:
+		result = "D"
+	}
+	return result, games[0].PlayOrder
+}
+
+// replaceMatchGames 以 games 取代對局的所有局記錄（空陣列表示改回單局）
+func replaceMatchGames(q database.Querier, matchID string, games []models.MatchGameForm) error {
+	if _, err := q.Exec("DELETE FROM match_games WHERE match_id = ?", matchID); err != nil {
+		return err
+	}
+	for i, g := range games {
+		_, err := q.Exec(`
+			INSERT INTO match_games (id, match_id, game_no, play_order, result, side_in, side_out, note)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, uuid.New().String(), matchID, i+1, g.PlayOrder, g.Result, g.SideIn, g.SideOut, g.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countMatchGames 對局的局數（單局對局為 0）
+func countMatchGames(q database.Querier, matchID string) (int, error) {
+	var n int
+	err := q.QueryRow("SELECT COUNT(*) FROM match_games WHERE match_id = ?", matchID).Scan(&n)
+	return n, err
+}
+
+// loadMatchGames 讀取對局的各局記錄並填入 Games
+func loadMatchGames(q database.Querier, matches []models.MatchWithDetails) error {
+	index := make(map[string]int, len(matches))
+	for i := range matches {
+		index[matches[i].ID] = i
+	}
+
+	return forEachMatchChunk(matches, func(in string, args []interface{}) error {
+		rows, err := q.Query(`
+			SELECT match_id, game_no, play_order, result, side_in, side_out, note
+			FROM match_games
+			WHERE match_id IN (`+in+`)
+			ORDER BY match_id, game_no
+		`, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var matchID string
+			var g models.MatchGame
+			var sideIn, sideOut, note sql.NullString
+			if err := rows.Scan(&matchID, &g.GameNo, &g.PlayOrder, &g.Result, &sideIn, &sideOut, &note); err != nil {
+				return err
+			}
+			if sideIn.Valid {
+				g.SideIn = &sideIn.String
+			}
+			if sideOut.Valid {
+				g.SideOut = &sideOut.String
+			}
+			if note.Valid {
+				g.Note = &note.String
+			}
+			m := &matches[index[matchID]]
+			m.Games = append(m.Games, g)
+		}
+		return rows.Err()
+	})
+}
+
+// forEachMatchChunk 將對局 ID 分批（每批 matchIDChunk 筆）組成 IN 的佔位符與參數
+func forEachMatchChunk(matches []models.MatchWithDetails, fn func(in string, args []interface{}) error) error {
+	for start := 0; start < len(matches); start += matchIDChunk {
+		end := start + matchIDChunk
+		if end > len(matches) {
+			end = len(matches)
+		}
+		placeholders := make([]string, 0, end-start)
+		args := make([]interface{}, 0, end-start)
+		for _, m := range matches[start:end] {
+			placeholders = append(placeholders, "?")
+			args = append(args, m.ID)
+		}
+		if err := fn(strings.Join(placeholders, ", "), args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -8,40 +8,155 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
 	"github.com/harvc/duellog/apps/api/models"
+	"github.com/harvc/duellog/apps/api/store"
 )
 
 // MatchesHandler 處理 matches 相關請求
 type MatchesHandler struct {
-	db *sql.DB
+	db *database.DB
 }
 
 // NewMatchesHandler 建立新的 matches handler
-func NewMatchesHandler(db *sql.DB) *MatchesHandler {
+func NewMatchesHandler(db *database.DB) *MatchesHandler {
 	return &MatchesHandler{db: db}
 }
 
+// matchSortColumns GET /matches 可排序的欄位
+var matchSortColumns = map[string]string{
+	"date":          "m.date",
+	"mode":          "m.mode",
+	"rank":          "COALESCE(rk.ordinal, 0)",
+	"playOrder":     "m.play_order",
+	"result":        "m.result",
+	"outcomeReason": "m.outcome_reason",
+	"coinToss":      "m.coin_toss",
+	"seasonCode":    "s.code",
+	"myDeck":        "my_deck.main",
+	"oppDeck":       "opp_deck.main",
+	"createdAt":     "m.created_at",
+	"updatedAt":     "m.updated_at",
+}
+
+// matchFields GET /matches 的 fields 參數可選欄位（id 一律回傳）
+var matchFields = []string{
+	"date", "mode", "rank", "rankId", "rankOrdinal", "myDeck", "oppDeck", "playOrder",
+	"result", "outcomeReason", "coinToss", "choice", "note", "seasonCode", "games", "tags", "snippet", "createdAt", "updatedAt",
+}
+
 // GetMatches 查詢對局列表 (GET /matches)
+// query: 篩選條件見 buildMatchFilters，另外支援
+//   - limit/offset: 分頁（未指定 limit 時回傳全部）
+//   - sort: 排序欄位，逗號分隔，前綴 - 表示遞減（預設 "-date,-createdAt"）
+//   - fields: 只回傳指定欄位，逗號分隔
+//
+// 帶 q（備註搜尋）時每筆對局多了 snippet：備註中符合的片段，符合處以 <mark></mark> 標示
 func (h *MatchesHandler) GetMatches(c *fiber.Ctx) error {
-	// 取得查詢參數
-	seasonCode := c.Query("seasonCode")
-	mode := c.Query("mode")
-	myDeckMain := c.Query("myDeckMain")
-	oppDeckMain := c.Query("oppDeckMain")
-	result := c.Query("result")
-	playOrder := c.Query("playOrder")
-	dateFrom := c.Query("dateFrom")
-	dateTo := c.Query("dateTo")
+	page, err := parsePage(c)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	orderBy, err := parseSort(c, matchSortColumns, "m.date DESC, m.created_at DESC")
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	fields, err := parseFields(c, matchFields)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+
+	// 符合篩選條件的總筆數（不受分頁影響）
+	var total int
+	if err := h.db.QueryRow("SELECT COUNT(*)"+matchesFromClause+where, args...).Scan(&total); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
 
 	// 建立基礎 SQL 查詢（JOIN 取得完整資訊）
-	query := `
-		SELECT 
+	query := "SELECT" + matchSelectColumns + matchesFromClause + where
+
+	// 排序（預設最新在前；最後以 id 確保分頁順序穩定）
+	query += " ORDER BY " + orderBy + ", m.id ASC" + page.SQL()
+
+	// 執行查詢
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	// 解析結果
+	matches := []models.MatchWithDetails{}
+	for rows.Next() {
+		m, err := scanMatch(rows)
+		if err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		matches = append(matches, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	// 三戰兩勝的各局記錄與標籤
+	if fields == nil || contains(fields, "games") {
+		if err := loadMatchGames(h.db, matches); err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+	}
+	if fields == nil || contains(fields, "tags") {
+		if err := loadMatchTags(h.db, matches); err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+	}
+
+	// 備註搜尋的片段（q 已在 buildMatchFilters 驗證過）
+	if terms, _ := parseSearchQuery(c.Query("q")); len(terms) > 0 {
+		for i := range matches {
+			if matches[i].Note != nil {
+				snippet := noteSnippet(*matches[i].Note, terms)
+				matches[i].Snippet = &snippet
+			}
+		}
+	}
+
+	resp := fiber.Map{
+		"matches": matches,
+		"total":   total,
+		"limit":   page.Limit,
+		"offset":  page.Offset,
+		"hasMore": page.Offset+len(matches) < total,
+	}
+	if fields != nil {
+		picked, err := selectFields(matches, fields)
+		if err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		resp["matches"] = picked
+	}
+
+	return c.JSON(resp)
+}
+
+// matchSelectColumns 對局列表的 SELECT 欄位（對應 scanMatch）
+const matchSelectColumns = `
 			m.id,
 			m.date,
 			m.mode,
 			m.rank,
+			m.rank_id,
+			rk.ordinal,
 			m.play_order,
 			m.result,
+			m.outcome_reason,
+			m.coin_toss,
+			m.toss_choice,
 			m.note,
 			m.created_at,
 			m.updated_at,
@@ -52,182 +167,357 @@ func (h *MatchesHandler) GetMatches(c *fiber.Ctx) error {
 			opp_deck.id as opp_deck_id,
 			opp_deck.main as opp_deck_main,
 			opp_deck.sub as opp_deck_sub
+	`
+
+// scanMatch 讀取一筆 matchSelectColumns 查詢結果
+func scanMatch(rows *sql.Rows) (models.MatchWithDetails, error) {
+	var m models.MatchWithDetails
+	var myDeckSub, oppDeckSub, note, rankID, coinToss, choice sql.NullString
+	var rankOrdinal sql.NullInt64
+
+	err := rows.Scan(
+		&m.ID,
+		&m.Date,
+		&m.Mode,
+		&m.Rank,
+		&rankID,
+		&rankOrdinal,
+		&m.PlayOrder,
+		&m.Result,
+		&m.OutcomeReason,
+		&coinToss,
+		&choice,
+		&note,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+		&m.SeasonCode,
+		&m.MyDeck.ID,
+		&m.MyDeck.Main,
+		&myDeckSub,
+		&m.OppDeck.ID,
+		&m.OppDeck.Main,
+		&oppDeckSub,
+	)
+	if err != nil {
+		return m, err
+	}
+
+	// 處理 nullable 欄位
+	if myDeckSub.Valid {
+		m.MyDeck.Sub = &myDeckSub.String
+	}
+	if oppDeckSub.Valid {
+		m.OppDeck.Sub = &oppDeckSub.String
+	}
+	if note.Valid {
+		m.Note = &note.String
+	}
+	if coinToss.Valid {
+		m.CoinToss = &coinToss.String
+	}
+	if choice.Valid {
+		m.Choice = &choice.String
+	}
+	if rankID.Valid {
+		m.RankID = &rankID.String
+		ordinal := int(rankOrdinal.Int64)
+		m.RankOrdinal = &ordinal
+	}
+	return m, nil
+}
+
+// matchesFromClause 對局查詢共用的 FROM/JOIN 片段（GetMatches 與統計 API 共用）
+const matchesFromClause = `
 		FROM matches m
 		JOIN seasons s ON m.season_id = s.id
 		JOIN decks my_deck ON m.my_deck_id = my_deck.id
 		JOIN decks opp_deck ON m.opp_deck_id = opp_deck.id
+		LEFT JOIN ranks rk ON m.rank_id = rk.id
 		WHERE 1=1
 	`
 
-	// 動態加入篩選條件（SQLite 使用 ? 佔位符）
-	args := []interface{}{}
+// buildMatchFilters 依查詢參數組出 WHERE 條件（以 " AND ..." 開頭，搭配 matchesFromClause 使用）
+// rankMin/rankMax 以遊戲的天梯階級篩選範圍（含），e.g. rankMin=金 V&rankMax=鑽石 I
+// bestOf=3 只保留有各局記錄的三戰兩勝對局，bestOf=1 只保留單局對局
+// tags 以逗號分隔，tagMode=any（預設，有任一個）或 all（全部都有）；excludeTags 排除有任一個標籤的對局
+// q 搜尋備註（見 parseSearchQuery、noteSearchFilter），e.g. q="turn 2" 卡手
+func buildMatchFilters(c *fiber.Ctx, db *database.DB) (string, []interface{}, error) {
+	// 取得查詢參數
+	seasonCode := c.Query("seasonCode")
+	mode := c.Query("mode")
+	myDeckMain := c.Query("myDeckMain")
+	oppDeckMain := c.Query("oppDeckMain")
+	result := c.Query("result")
+	playOrder := c.Query("playOrder")
+	dateFrom := c.Query("dateFrom")
+	dateTo := c.Query("dateTo")
+	rankMin := c.Query("rankMin")
+	rankMax := c.Query("rankMax")
+	outcomes := splitList(c.Query("outcomeReason"))
+	excludeOutcomes := splitList(c.Query("excludeOutcomes"))
+	bestOf := c.Query("bestOf")
+	coinToss := c.Query("coinToss")
+	choice := c.Query("choice")
+	tags := splitList(c.Query("tags"))
+	tagMode := c.Query("tagMode", "any")
+	excludeTags := splitList(c.Query("excludeTags"))
+	terms, err := parseSearchQuery(c.Query("q"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 動態加入篩選條件（一律使用 ? 佔位符，PostgreSQL 由 database 套件轉換）
+	// 一律限定為目前使用者的對局
+	where := " AND m.user_id = ?"
+	args := []interface{}{currentUserID(c)}
+
+	// 限定為 gameKey 指定的遊戲（見 GamesHandler.RequireGameKey）
+	if gameID := currentGameID(c); gameID != "" {
+		where += " AND m.game_id = ?"
+		args = append(args, gameID)
+	}
 
 	if seasonCode != "" {
-		query += " AND s.code = ?"
+		where += " AND s.code = ?"
 		args = append(args, seasonCode)
 	}
 
 	if mode != "" {
-		query += " AND m.mode = ?"
+		where += " AND m.mode = ?"
 		args = append(args, mode)
 	}
 
 	if myDeckMain != "" {
-		query += " AND my_deck.main = ?"
+		where += " AND my_deck.main = ?"
 		args = append(args, myDeckMain)
 	}
 
 	if oppDeckMain != "" {
-		query += " AND opp_deck.main = ?"
+		where += " AND opp_deck.main = ?"
 		args = append(args, oppDeckMain)
 	}
 
 	if result != "" {
-		query += " AND m.result = ?"
+		where += " AND m.result = ?"
 		args = append(args, result)
 	}
 
 	if playOrder != "" {
-		query += " AND m.play_order = ?"
+		where += " AND m.play_order = ?"
 		args = append(args, playOrder)
 	}
 
+	// 擲硬幣：coinToss=won|lost，choice=先攻|後攻（猜中的一方的選擇）
+	if coinToss != "" {
+		if !contains(validCoinTosses, coinToss) {
+			return "", nil, fmt.Errorf("coinToss 必須為 %v 其中之一", validCoinTosses)
+		}
+		where += " AND m.coin_toss = ?"
+		args = append(args, coinToss)
+	}
+
+	if choice != "" {
+		if !contains(validPlayOrders, choice) {
+			return "", nil, fmt.Errorf("choice 必須為 %v 其中之一", validPlayOrders)
+		}
+		where += " AND m.toss_choice = ?"
+		args = append(args, choice)
+	}
+
 	if dateFrom != "" {
-		query += " AND m.date >= ?"
+		where += " AND m.date >= ?"
 		args = append(args, dateFrom)
 	}
 
 	if dateTo != "" {
-		query += " AND m.date <= ?"
+		where += " AND m.date <= ?"
 		args = append(args, dateTo)
 	}
 
-	// 按日期排序（最新在前）
-	query += " ORDER BY m.date DESC, m.created_at DESC"
-
-	// 執行查詢
-	rows, err := h.db.Query(query, args...)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "查詢失敗", "details": err.Error()})
+	// 勝負原因：outcomeReason 只保留列出的、excludeOutcomes 排除列出的（e.g. 排除對手投降的白勝與斷線）
+	for _, f := range []struct {
+		param, op string
+		values    []string
+	}{
+		{"outcomeReason", "IN", outcomes},
+		{"excludeOutcomes", "NOT IN", excludeOutcomes},
+	} {
+		if len(f.values) == 0 {
+			continue
+		}
+		placeholders := make([]string, len(f.values))
+		for i, v := range f.values {
+			if !contains(validOutcomes, v) {
+				return "", nil, fmt.Errorf("%s 必須為 %v 其中之一", f.param, validOutcomes)
+			}
+			placeholders[i] = "?"
+			args = append(args, v)
+		}
+		where += " AND m.outcome_reason " + f.op + " (" + joinStrings(placeholders, ", ") + ")"
 	}
-	defer rows.Close()
 
-	// 解析結果
-	matches := []models.MatchWithDetails{}
-	for rows.Next() {
-		var m models.MatchWithDetails
-		var myDeckSub, oppDeckSub, note sql.NullString
-
-		err := rows.Scan(
-			&m.ID,
-			&m.Date,
-			&m.Mode,
-			&m.Rank,
-			&m.PlayOrder,
-			&m.Result,
-			&note,
-			&m.CreatedAt,
-			&m.UpdatedAt,
-			&m.SeasonCode,
-			&m.MyDeck.ID,
-			&m.MyDeck.Main,
-			&myDeckSub,
-			&m.OppDeck.ID,
-			&m.OppDeck.Main,
-			&oppDeckSub,
-		)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "解析資料失敗", "details": err.Error()})
+	if tagMode != "any" && tagMode != "all" {
+		return "", nil, fmt.Errorf("tagMode 必須為 any 或 all")
+	}
+	for _, f := range []struct {
+		mode  string
+		names []string
+	}{
+		{tagMode, tags},
+		{"exclude", excludeTags},
+	} {
+		if len(f.names) == 0 {
+			continue
 		}
-
-		// 處理 nullable 欄位
-		if myDeckSub.Valid {
-			m.MyDeck.Sub = &myDeckSub.String
+		names := make([]string, 0, len(f.names))
+		for _, name := range f.names {
+			if name = store.NormalizeTagName(name); name != "" && !contains(names, name) {
+				names = append(names, name)
+			}
 		}
-		if oppDeckSub.Valid {
-			m.OppDeck.Sub = &oppDeckSub.String
+		cond, condArgs := tagFilter(f.mode, names)
+		where += cond
+		args = append(args, condArgs...)
+	}
+
+	if len(terms) > 0 {
+		cond, condArgs := noteSearchFilter(db, terms)
+		where += cond
+		args = append(args, condArgs...)
+	}
+
+	switch bestOf {
+	case "":
+	case "1":
+		where += " AND NOT EXISTS (SELECT 1 FROM match_games g WHERE g.match_id = m.id)"
+	case "3":
+		where += " AND EXISTS (SELECT 1 FROM match_games g WHERE g.match_id = m.id)"
+	default:
+		return "", nil, fmt.Errorf("bestOf 必須為 1 或 3")
+	}
+
+	for _, f := range []struct{ param, value, op string }{
+		{"rankMin", rankMin, ">="},
+		{"rankMax", rankMax, "<="},
+	} {
+		if f.value == "" {
+			continue
 		}
-		if note.Valid {
-			m.Note = &note.String
+		r, err := store.ResolveRank(db, currentGameID(c), "Ranked", f.value)
+		if errors.Is(err, store.ErrRankNotFound) || (err == nil && r == nil) {
+			return "", nil, fmt.Errorf("%s: 找不到階級：%s", f.param, f.value)
+		} else if err != nil {
+			return "", nil, err
 		}
-
-		matches = append(matches, m)
+		where += " AND rk.ordinal " + f.op + " ?"
+		args = append(args, r.Ordinal)
 	}
 
-	return c.JSON(fiber.Map{
-		"matches": matches,
-		"total":   len(matches),
-	})
+	return where, args, nil
 }
 
 // CreateMatch 新增對局 (POST /matches)
 func (h *MatchesHandler) CreateMatch(c *fiber.Ctx) error {
 	var req models.CreateMatchRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "請求格式錯誤", "details": err.Error()})
+		return invalidBody(c, err)
 	}
 
-	// 驗證必要欄位
-	if req.GameKey == "" || req.SeasonCode == "" || req.Date == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "缺少必要欄位"})
+	if errs := validateCreateMatch(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
-	if req.Mode == "" {
-		req.Mode = "Ranked"
-	}
-	if req.Mode != "Ranked" && req.Rank == "" {
-		req.Rank = "—"
+	// 取得 game_id
+	gameID, errResp := gameIDForBody(c, h.db, req.GameKey)
+	if gameID == "" {
+		return errResp
 	}
 
-	// 取得 game_id
-	var gameID string
-	err := h.db.QueryRow("SELECT id FROM games WHERE key = ?", req.GameKey).Scan(&gameID)
+	// 對局與各局記錄在同一個交易內新增
+	tx, err := h.db.Begin()
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "找不到遊戲", "gameKey": req.GameKey})
+		return internalError(c, "新增對局失敗", err)
 	}
+	defer tx.Rollback()
 
-	// 取得 season_id
-	seasonID, err := h.getOrCreateSeasonID(gameID, req.SeasonCode)
+	// 階級文字對應到遊戲的階梯（e.g. "金4" → "金 IV"）
+	rankID, err := resolveRank(tx, gameID, req.Mode, &req.Rank)
+	if errors.Is(err, store.ErrRankNotFound) {
+		return validationFailed(c, rankNotFound(req.Rank))
+	} else if err != nil {
+		return internalError(c, "處理階級失敗", err)
+	}
+
+	// 取得 season_id（未指定 seasonCode 時依對局日期推斷）
+	seasonID, errs, err := matchSeason(tx, gameID, req.SeasonCode, req.Date)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "處理賽季失敗", "details": err.Error()})
+		return internalError(c, "處理賽季失敗", err)
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// 牌組別名轉為正式名稱，不同寫法會對應到同一個牌組
+	if err := resolveDeckForm(tx, gameID, &req.MyDeck); err != nil {
+		return internalError(c, "處理我的牌組失敗", err)
+	}
+	if err := resolveDeckForm(tx, gameID, &req.OppDeck); err != nil {
+		return internalError(c, "處理對手牌組失敗", err)
 	}
 
 	// 取得或建立我的牌組
-	myDeckID, err := h.findOrCreateDeck(gameID, req.MyDeck.Main, req.MyDeck.Sub)
+	myDeckID, err := store.FindOrCreateDeck(tx, gameID, req.MyDeck.Main, req.MyDeck.Sub)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "處理我的牌組失敗", "details": err.Error()})
+		return internalError(c, "處理我的牌組失敗", err)
 	}
 
 	// 取得或建立對手牌組
-	oppDeckID, err := h.findOrCreateDeck(gameID, req.OppDeck.Main, req.OppDeck.Sub)
+	oppDeckID, err := store.FindOrCreateDeck(tx, gameID, req.OppDeck.Main, req.OppDeck.Sub)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "處理對手牌組失敗", "details": err.Error()})
+		return internalError(c, "處理對手牌組失敗", err)
 	}
 
 	// 生成新的 match ID
 	matchID := uuid.New().String()
 
-	// 取得預設 user_id（MVP 單人模式）
-	var userID string
-	err = h.db.QueryRow("SELECT id FROM users LIMIT 1").Scan(&userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "找不到使用者"})
-	}
+	// 對局一律屬於目前登入的使用者
+	userID := currentUserID(c)
 
 	// 插入對局記錄
-	_, err = h.db.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO matches (
-			id, user_id, game_id, season_id, date, mode, rank,
-			my_deck_id, opp_deck_id, play_order, result, note,
+			id, user_id, game_id, season_id, date, mode, rank, rank_id,
+			my_deck_id, opp_deck_id, play_order, result, outcome_reason,
+			coin_toss, toss_choice, note,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
-		matchID, userID, gameID, seasonID, req.Date, req.Mode, req.Rank,
-		myDeckID, oppDeckID, req.PlayOrder, req.Result, req.Note,
+		matchID, userID, gameID, seasonID, req.Date, req.Mode, req.Rank, rankID,
+		myDeckID, oppDeckID, req.PlayOrder, req.Result, req.OutcomeReason,
+		req.CoinToss, req.Choice, req.Note,
 		time.Now(), time.Now(),
 	)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "新增對局失敗", "details": err.Error()})
+		return internalError(c, "新增對局失敗", err)
+	}
+	if err := replaceMatchGames(tx, matchID, req.Games); err != nil {
+		return internalError(c, "新增對局失敗", err)
+	}
+	if err := store.SetMatchTags(tx, userID, matchID, req.Tags); err != nil {
+		return internalError(c, "新增對局失敗", err)
+	}
+
+	// 變更紀錄
+	after, err := loadMatchSnapshot(tx, userID, matchID)
+	if err != nil {
+		return internalError(c, "新增對局失敗", err)
+	}
+	if err := recordAudit(tx, userID, auditMatch, matchID, nil, after, nil); err != nil {
+		return internalError(c, "新增對局失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "新增對局失敗", err)
 	}
 
 	return c.Status(201).JSON(fiber.Map{
@@ -240,41 +530,153 @@ func (h *MatchesHandler) CreateMatch(c *fiber.Ctx) error {
 func (h *MatchesHandler) UpdateMatch(c *fiber.Ctx) error {
 	matchID := c.Params("id")
 	if matchID == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "缺少對局 ID"})
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少對局 ID")
 	}
 
 	var req models.UpdateMatchRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "請求格式錯誤", "details": err.Error()})
+		return invalidBody(c, err)
+	}
+
+	if errs := validateUpdateMatch(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// 檢查、牌組/賽季的建立、對局更新與變更紀錄在同一個交易內完成，
+	// 變更紀錄的 before 與檢查時讀到的都是寫入前同一份內容
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "更新失敗", err)
+	}
+	defer tx.Rollback()
+
+	// 檢查對局是否存在（同時取得 game_id，用於解析牌組與賽季；mode/rank 用於解析階級；
+	// result/outcome_reason、play_order/coin_toss 用於檢查一致性）
+	var gameID, curMode, curRank, curResult, curOutcome, curPlayOrder string
+	var curCoinToss sql.NullString
+	err = tx.QueryRow(
+		"SELECT game_id, mode, rank, result, outcome_reason, play_order, coin_toss FROM matches WHERE id = ? AND user_id = ?",
+		matchID, currentUserID(c),
+	).Scan(&gameID, &curMode, &curRank, &curResult, &curOutcome, &curPlayOrder, &curCoinToss)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到對局")
+	}
+	if err != nil {
+		return internalError(c, "查詢對局失敗", err)
 	}
 
-	// 檢查對局是否存在
-	var exists bool
-	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM matches WHERE id = ?)", matchID).Scan(&exists)
-	if err != nil || !exists {
-		return c.Status(404).JSON(fiber.Map{"error": "找不到對局"})
+	// 有各局記錄的對局，result/playOrder 由各局推導，需透過 games 修改
+	if req.Games == nil && (req.Result != nil || req.PlayOrder != nil) {
+		n, err := countMatchGames(tx, matchID)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		if n > 0 {
+			return validationFailed(c, fieldErrors{{
+				Field: "games", Code: FieldInvalid, Message: "三戰兩勝的對局由各局推導 result 與 playOrder，請改用 games 修改",
+			}})
+		}
+	}
+
+	if req.Result != nil || req.OutcomeReason != nil {
+		result, reason := curResult, curOutcome
+		if req.Result != nil {
+			result = *req.Result
+		}
+		if req.OutcomeReason != nil {
+			reason = *req.OutcomeReason
+		}
+		var errs fieldErrors
+		if errs.outcome(result, reason); len(errs) > 0 {
+			return validationFailed(c, errs)
+		}
+	}
+
+	// 擲硬幣或先後攻改變時重新推導 choice（猜中為自己的先後攻，猜錯為相反）
+	var tossUpdate []interface{}
+	if req.CoinToss != nil || req.Choice != nil || req.PlayOrder != nil {
+		coinToss, playOrder := curCoinToss.String, curPlayOrder
+		if req.CoinToss != nil {
+			coinToss = *req.CoinToss
+		}
+		if req.PlayOrder != nil {
+			playOrder = *req.PlayOrder
+		}
+		var errs fieldErrors
+		choice := errs.tossChoice(coinToss, derefString(req.Choice), playOrder)
+		if len(errs) > 0 {
+			return validationFailed(c, errs)
+		}
+		tossUpdate = []interface{}{optionalString(coinToss), optionalString(choice)}
+	}
+
+	before, err := loadMatchSnapshot(tx, currentUserID(c), matchID)
+	if err != nil {
+		return internalError(c, "更新失敗", err)
 	}
 
 	// 動態建立更新語句
 	updates := []string{}
 	args := []interface{}{}
 
+	if req.SeasonCode != nil {
+		seasonID, errs, err := matchSeason(tx, gameID, *req.SeasonCode, "")
+		if err != nil {
+			return internalError(c, "處理賽季失敗", err)
+		}
+		if len(errs) > 0 {
+			return validationFailed(c, errs)
+		}
+		updates = append(updates, "season_id = ?")
+		args = append(args, seasonID)
+	}
 	if req.Date != nil {
 		updates = append(updates, "date = ?")
 		args = append(args, *req.Date)
 	}
-	if req.Mode != nil {
-		updates = append(updates, "mode = ?")
-		args = append(args, *req.Mode)
-		// If switching away from Ranked and no explicit rank provided, set rank to '—' to satisfy NOT NULL.
-		if *req.Mode != "Ranked" && req.Rank == nil {
-			updates = append(updates, "rank = ?")
-			args = append(args, "—")
+	if req.Mode != nil || req.Rank != nil {
+		mode, rank := curMode, curRank
+		if req.Mode != nil {
+			mode = *req.Mode
+			updates = append(updates, "mode = ?")
+			args = append(args, mode)
+		}
+		if req.Rank != nil {
+			rank = *req.Rank
+		} else if req.Mode != nil && mode != "Ranked" {
+			// If switching away from Ranked and no explicit rank provided, set rank to '—' to satisfy NOT NULL.
+			rank = "—"
+		}
+		rankID, err := resolveRank(tx, gameID, mode, &rank)
+		if errors.Is(err, store.ErrRankNotFound) {
+			return validationFailed(c, rankNotFound(rank))
+		} else if err != nil {
+			return internalError(c, "處理階級失敗", err)
+		}
+		updates = append(updates, "rank = ?", "rank_id = ?")
+		args = append(args, rank, rankID)
+	}
+	if req.MyDeck != nil {
+		if err := resolveDeckForm(tx, gameID, req.MyDeck); err != nil {
+			return internalError(c, "處理我的牌組失敗", err)
+		}
+		myDeckID, err := store.FindOrCreateDeck(tx, gameID, req.MyDeck.Main, req.MyDeck.Sub)
+		if err != nil {
+			return internalError(c, "處理我的牌組失敗", err)
 		}
+		updates = append(updates, "my_deck_id = ?")
+		args = append(args, myDeckID)
 	}
-	if req.Rank != nil {
-		updates = append(updates, "rank = ?")
-		args = append(args, *req.Rank)
+	if req.OppDeck != nil {
+		if err := resolveDeckForm(tx, gameID, req.OppDeck); err != nil {
+			return internalError(c, "處理對手牌組失敗", err)
+		}
+		oppDeckID, err := store.FindOrCreateDeck(tx, gameID, req.OppDeck.Main, req.OppDeck.Sub)
+		if err != nil {
+			return internalError(c, "處理對手牌組失敗", err)
+		}
+		updates = append(updates, "opp_deck_id = ?")
+		args = append(args, oppDeckID)
 	}
 	if req.PlayOrder != nil {
 		updates = append(updates, "play_order = ?")
@@ -284,15 +686,33 @@ func (h *MatchesHandler) UpdateMatch(c *fiber.Ctx) error {
 		updates = append(updates, "result = ?")
 		args = append(args, *req.Result)
 	}
+	if req.OutcomeReason != nil {
+		updates = append(updates, "outcome_reason = ?")
+		args = append(args, *req.OutcomeReason)
+	}
+	if tossUpdate != nil {
+		updates = append(updates, "coin_toss = ?", "toss_choice = ?")
+		args = append(args, tossUpdate...)
+	}
 	if req.Note != nil {
 		updates = append(updates, "note = ?")
 		args = append(args, *req.Note)
 	}
 
-	// TODO: 處理 MyDeck 和 OppDeck 的更新（需要 findOrCreateDeck）
+	if req.Games != nil {
+		if err := replaceMatchGames(tx, matchID, *req.Games); err != nil {
+			return internalError(c, "更新失敗", err)
+		}
+	}
+
+	if req.Tags != nil {
+		if err := store.SetMatchTags(tx, currentUserID(c), matchID, *req.Tags); err != nil {
+			return internalError(c, "更新失敗", err)
+		}
+	}
 
-	if len(updates) == 0 {
-		return c.Status(400).JSON(fiber.Map{"error": "沒有要更新的欄位"})
+	if len(updates) == 0 && req.Games == nil && req.Tags == nil {
+		return apiError(c, fiber.StatusUnprocessableEntity, CodeValidationFailed, "沒有要更新的欄位")
 	}
 
 	// 加入 updated_at
@@ -300,13 +720,24 @@ func (h *MatchesHandler) UpdateMatch(c *fiber.Ctx) error {
 	args = append(args, time.Now())
 
 	// 加入 WHERE 條件
-	args = append(args, matchID)
+	args = append(args, matchID, currentUserID(c))
 
 	// 執行更新
-	query := fmt.Sprintf("UPDATE matches SET %s WHERE id = ?", joinStrings(updates, ", "))
-	_, err = h.db.Exec(query, args...)
+	query := fmt.Sprintf("UPDATE matches SET %s WHERE id = ? AND user_id = ?", joinStrings(updates, ", "))
+	if _, err := tx.Exec(query, args...); err != nil {
+		return internalError(c, "更新失敗", err)
+	}
+
+	after, err := loadMatchSnapshot(tx, currentUserID(c), matchID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "更新失敗", "details": err.Error()})
+		return internalError(c, "更新失敗", err)
+	}
+	if err := recordAudit(tx, currentUserID(c), auditMatch, matchID, before, after, nil); err != nil {
+		return internalError(c, "更新失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "更新失敗", err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -319,19 +750,34 @@ func (h *MatchesHandler) UpdateMatch(c *fiber.Ctx) error {
 func (h *MatchesHandler) DeleteMatch(c *fiber.Ctx) error {
 	matchID := c.Params("id")
 	if matchID == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "缺少對局 ID"})
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少對局 ID")
 	}
 
-	// 執行刪除
-	result, err := h.db.Exec("DELETE FROM matches WHERE id = ?", matchID)
+	tx, err := h.db.Begin()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "刪除失敗", "details": err.Error()})
+		return internalError(c, "刪除失敗", err)
 	}
+	defer tx.Rollback()
 
-	// 檢查是否有刪除任何記錄
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "找不到對局"})
+	// 刪除前的內容記在變更紀錄中，可用 POST /audit/:id/revert 還原
+	userID := currentUserID(c)
+	before, err := loadMatchSnapshot(tx, userID, matchID)
+	if err != nil {
+		return internalError(c, "刪除失敗", err)
+	}
+	if before == nil {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到對局")
+	}
+
+	// 執行刪除（一併刪除各局記錄與標籤）
+	if _, err := deleteMatchRows(tx, userID, matchID); err != nil {
+		return internalError(c, "刪除失敗", err)
+	}
+	if err := recordAudit(tx, userID, auditMatch, matchID, before, nil, nil); err != nil {
+		return internalError(c, "刪除失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "刪除失敗", err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -340,118 +786,68 @@ func (h *MatchesHandler) DeleteMatch(c *fiber.Ctx) error {
 	})
 }
 
-// findOrCreateDeck 尋找或建立牌組
-func (h *MatchesHandler) findOrCreateDeck(gameID, main string, sub *string) (string, error) {
-	var deckID string
-	var subValue sql.NullString
-
-	if sub != nil {
-		subValue.String = *sub
-		subValue.Valid = true
+// joinStrings 連接字串陣列（輔助函數）
+func joinStrings(strs []string, sep string) string {
+	if len(strs) == 0 {
+		return ""
 	}
-
-	// 先嘗試尋找
-	var query string
-	if sub == nil {
-		query = "SELECT id FROM decks WHERE game_id = ? AND main = ? AND sub IS NULL"
-		err := h.db.QueryRow(query, gameID, main).Scan(&deckID)
-		if err == nil {
-			return deckID, nil // 找到了
-		}
-	} else {
-		query = "SELECT id FROM decks WHERE game_id = ? AND main = ? AND sub = ?"
-		err := h.db.QueryRow(query, gameID, main, *sub).Scan(&deckID)
-		if err == nil {
-			return deckID, nil // 找到了
-		}
+	result := strs[0]
+	for i := 1; i < len(strs); i++ {
+		result += sep + strs[i]
 	}
+	return result
+}
 
-	// 沒找到，建立新的
-	deckID = uuid.New().String()
-	_, err := h.db.Exec(
-		"INSERT INTO decks (id, game_id, main, sub) VALUES (?, ?, ?, ?)",
-		deckID, gameID, main, subValue,
-	)
+// resolveDeckForm 將牌組表單中的別名轉為正式名稱
+func resolveDeckForm(q database.Querier, gameID string, d *models.DeckForm) error {
+	main, sub, err := store.ResolveDeck(q, gameID, d.Main, d.Sub)
 	if err != nil {
-		return "", err
+		return err
 	}
+	d.Main, d.Sub = main, sub
+	return nil
+}
 
-	// 同時確保 deck_templates 中有這個牌組（用於顏色顯示）
-	h.ensureDeckTemplate(gameID, main)
-	if sub != nil && *sub != "" && *sub != "無" {
-		h.ensureDeckTemplate(gameID, *sub)
+// resolveRank 將階級文字轉為階梯上的顯示名稱並回傳 rank_id；
+// 遊戲在該模式沒有階梯時保留原本的文字，rank_id 為 nil
+func resolveRank(q database.Querier, gameID, mode string, rank *string) (*string, error) {
+	r, err := store.ResolveRank(q, gameID, mode, *rank)
+	if err != nil || r == nil {
+		return nil, err
 	}
-
-	return deckID, nil
+	*rank = r.Name
+	return &r.ID, nil
 }
 
-// ensureDeckTemplate 確保牌組模板存在，不存在則建立（預設主題為「無」）
-func (h *MatchesHandler) ensureDeckTemplate(gameID, deckName string) {
-	// 檢查是否已存在
-	var exists bool
-	err := h.db.QueryRow(
-		"SELECT EXISTS(SELECT 1 FROM deck_templates WHERE game_id = ? AND main = ? AND deck_type = 'main')",
-		gameID,
-		deckName,
-	).Scan(&exists)
-	if err != nil || exists {
-		return // 已存在或查詢失敗，不需要建立
-	}
-
-	// 不存在，建立新的模板（預設主題為「無」= 灰色）
-	templateID := "tpl-auto-" + uuid.New().String()[:8]
-	_, _ = h.db.Exec(`
-		INSERT INTO deck_templates (id, game_id, main, theme, deck_type, created_at)
-		VALUES (?, ?, ?, '無', 'main', CURRENT_TIMESTAMP)
-	`, templateID, gameID, deckName)
+// rankNotFound 422：階級不在遊戲的階梯上
+func rankNotFound(rank string) fieldErrors {
+	return fieldErrors{{Field: "rank", Code: FieldNotFound, Message: "找不到階級：" + rank + "（可用 GET /ranks 查詢階梯）"}}
 }
 
-func (h *MatchesHandler) getOrCreateSeasonID(gameID, seasonCode string) (string, error) {
-	var seasonID string
-	err := h.db.QueryRow("SELECT id FROM seasons WHERE code = ? AND game_id = ?", seasonCode, gameID).Scan(&seasonID)
-	if err == nil {
-		return seasonID, nil
-	}
-	if !errors.Is(err, sql.ErrNoRows) {
-		return "", err
-	}
-
-	// Not found: auto-create so users can start recording immediately.
-	seasonID = uuid.New().String()
-
-	// If seasonCode looks like YYYY-MM, fill start/end dates; otherwise leave them NULL.
-	var startDate any = nil
-	var endDate any = nil
-	if t, parseErr := time.Parse("2006-01", seasonCode); parseErr == nil {
-		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
-		end := start.AddDate(0, 1, 0).AddDate(0, 0, -1)
-		startDate = start.Format("2006-01-02")
-		endDate = end.Format("2006-01-02")
-	}
-
-	_, err = h.db.Exec(
-		"INSERT INTO seasons (id, game_id, code, start_date, end_date) VALUES (?, ?, ?, ?, ?)",
-		seasonID, gameID, seasonCode, startDate, endDate,
-	)
-	if err != nil {
-		// If another request created it concurrently, just re-read.
-		if readErr := h.db.QueryRow("SELECT id FROM seasons WHERE code = ? AND game_id = ?", seasonCode, gameID).Scan(&seasonID); readErr == nil {
-			return seasonID, nil
+// matchSeason 取得對局的 season_id：有 seasonCode 時取得或建立該賽季，
+// 否則依 date 找出範圍包含該日期的賽季；已結束的賽季不能再新增對局
+func matchSeason(q database.Querier, gameID, seasonCode, date string) (string, fieldErrors, error) {
+	var season store.Season
+	if seasonCode == "" {
+		inferred, err := store.InferSeason(q, gameID, date)
+		if errors.Is(err, store.ErrSeasonNotInferred) {
+			return "", fieldErrors{{Field: "seasonCode", Code: FieldRequired, Message: "沒有賽季包含 " + date + "，請指定 seasonCode"}}, nil
+		} else if err != nil {
+			return "", nil, err
+		}
+		season = inferred
+	} else {
+		seasonID, err := store.GetOrCreateSeasonID(q, gameID, seasonCode)
+		if err != nil {
+			return "", nil, err
+		}
+		if season, err = store.GetSeason(q, seasonID); err != nil {
+			return "", nil, err
 		}
-		return "", err
 	}
 
-	return seasonID, nil
-}
-
-// joinStrings 連接字串陣列（輔助函數）
-func joinStrings(strs []string, sep string) string {
-	if len(strs) == 0 {
-		return ""
+	if season.Closed() {
+		return "", fieldErrors{{Field: "seasonCode", Code: FieldInvalid, Message: "賽季 " + season.Code + " 已結束"}}, nil
 	}
-	result := strs[0]
-	for i := 1; i < len(strs); i++ {
-		result += sep + strs[i]
-	}
-	return result
+	return season.ID, nil, nil
 }
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultMinGames 每格場數低於此值時標記為 lowSample
+const defaultMinGames = 10
+
+// wilsonZ 95% 信賴區間的 z 值
+const wilsonZ = 1.959963984540054
+
+// MatchupKey 矩陣的列/欄鍵（groupBy=main 時 Sub 為 null）
+type MatchupKey struct {
+	Main string  `json:"main"`
+	Sub  *string `json:"sub"`
+}
+
+// MatchupSplit 先攻/後攻拆分
+type MatchupSplit struct {
+	Games   int      `json:"games"`
+	Wins    int      `json:"wins"`
+	WinRate *float64 `json:"winRate"`
+}
+
+// Interval 勝率區間（0~1）
+type Interval struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// MatchupCell 我方牌組 × 對手牌組的單格統計
+type MatchupCell struct {
+	MyDeck    MatchupKey   `json:"myDeck"`
+	OppDeck   MatchupKey   `json:"oppDeck"`
+	Games     int          `json:"games"`
+	Wins      int          `json:"wins"`
+	Losses    int          `json:"losses"`
+	Draws     int          `json:"draws"`
+	WinRate   *float64     `json:"winRate"`
+	First     MatchupSplit `json:"first"`  // 先攻
+	Second    MatchupSplit `json:"second"` // 後攻
+	CI        Interval     `json:"ci"`     // Wilson score interval (95%)
+	LowSample bool         `json:"lowSample"`
+}
+
+// GetMatchups 對戰矩陣 (GET /stats/matchups)
+// query: 與 GET /matches 相同的篩選條件，外加
+//   - groupBy: "main"（預設）或 "main_sub"
+//   - minGames: lowSample 門檻（預設 10）
+func (h *StatsHandler) GetMatchups(c *fiber.Ctx) error {
+	groupBy := c.Query("groupBy", "main")
+	if groupBy != "main" && groupBy != "main_sub" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "groupBy 必須為 main 或 main_sub")
+	}
+
+	minGames := defaultMinGames
+	if v := c.Query("minGames"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "minGames 必須為非負整數")
+		}
+		minGames = n
+	}
+
+	// groupBy=main 時不區分小軸
+	selectCols := "my_deck.main, NULL, opp_deck.main, NULL"
+	groupCols := "my_deck.main, opp_deck.main"
+	if groupBy == "main_sub" {
+		selectCols = "my_deck.main, my_deck.sub, opp_deck.main, opp_deck.sub"
+		groupCols = selectCols
+	}
+
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := "SELECT " + selectCols + ", " + statsAggregateColumns +
+		matchesFromClause + where +
+		" GROUP BY " + groupCols +
+		" ORDER BY my_deck.main ASC, opp_deck.main ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	cells := []MatchupCell{}
+	myDecks := []MatchupKey{}
+	oppDecks := []MatchupKey{}
+	seenMy := map[string]bool{}
+	seenOpp := map[string]bool{}
+
+	for rows.Next() {
+		var cell MatchupCell
+		var s StatsSummary
+		var mySubVal, oppSubVal *string
+		if err := rows.Scan(
+			&cell.MyDeck.Main, &mySubVal, &cell.OppDeck.Main, &oppSubVal,
+			&s.Total, &s.Wins, &s.Draws, &s.First, &s.Second, &s.FirstWins, &s.SecondWins,
+		); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		cell.MyDeck.Sub = mySubVal
+		cell.OppDeck.Sub = oppSubVal
+
+		cell.Games = s.Total
+		cell.Wins = s.Wins
+		cell.Draws = s.Draws
+		cell.Losses = s.Total - s.Wins - s.Draws
+		cell.WinRate = ratio(s.Wins, s.Total)
+		cell.First = MatchupSplit{Games: s.First, Wins: s.FirstWins, WinRate: ratio(s.FirstWins, s.First)}
+		cell.Second = MatchupSplit{Games: s.Second, Wins: s.SecondWins, WinRate: ratio(s.SecondWins, s.Second)}
+		cell.CI = wilsonInterval(s.Wins, s.Total)
+		cell.LowSample = s.Total < minGames
+
+		if k := matchupKeyString(cell.MyDeck); !seenMy[k] {
+			seenMy[k] = true
+			myDecks = append(myDecks, cell.MyDeck)
+		}
+		if k := matchupKeyString(cell.OppDeck); !seenOpp[k] {
+			seenOpp[k] = true
+			oppDecks = append(oppDecks, cell.OppDeck)
+		}
+		cells = append(cells, cell)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"groupBy":  groupBy,
+		"minGames": minGames,
+		"myDecks":  myDecks,
+		"oppDecks": oppDecks,
+		"cells":    cells,
+	})
+}
+
+// wilsonInterval 計算 Wilson score interval；n 為 0 時回傳 [0, 1]
+func wilsonInterval(wins, n int) Interval {
+	if n == 0 {
+		return Interval{Lower: 0, Upper: 1}
+	}
+	nf := float64(n)
+	p := float64(wins) / nf
+	z2 := wilsonZ * wilsonZ
+	denom := 1 + z2/nf
+	center := (p + z2/(2*nf)) / denom
+	margin := wilsonZ * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf)) / denom
+	return Interval{
+		Lower: math.Max(0, center-margin),
+		Upper: math.Min(1, center+margin),
+	}
+}
+
+// matchupKeyString 產生去重用的鍵
+func matchupKeyString(k MatchupKey) string {
+	if k.Sub == nil {
+		return k.Main
+	}
+	return k.Main + "\x00" + *k.Sub
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		wins, n      int
+		lower, upper float64
+	}{
+		{0, 0, 0, 1},
+		{5, 10, 0.2366, 0.7634},
+		{0, 10, 0, 0.2775},
+		{10, 10, 0.7225, 1},
+		{1, 3, 0.0615, 0.7923},
+		{60, 100, 0.5020, 0.6906},
+	}
+	for _, tt := range tests {
+		got := wilsonInterval(tt.wins, tt.n)
+		if math.Abs(got.Lower-tt.lower) > 1e-4 || math.Abs(got.Upper-tt.upper) > 1e-4 {
+			t.Errorf("wilsonInterval(%d, %d) = [%.4f, %.4f], want [%.4f, %.4f]",
+				tt.wins, tt.n, got.Lower, got.Upper, tt.lower, tt.upper)
+		}
+		if got.Lower < 0 || got.Upper > 1 || got.Lower > got.Upper {
+			t.Errorf("wilsonInterval(%d, %d) = [%v, %v], want 0 ≤ lower ≤ upper ≤ 1", tt.wins, tt.n, got.Lower, got.Upper)
+		}
+	}
+}
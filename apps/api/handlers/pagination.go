@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxPageLimit 單頁最多筆數
+const maxPageLimit = 1000
+
+// Page 分頁參數；Limit 為 0 表示不分頁（回傳全部）
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// parsePage 解析 limit/offset 查詢參數
+func parsePage(c *fiber.Ctx) (Page, error) {
+	var p Page
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return p, fmt.Errorf("limit 必須介於 1 與 %d 之間", maxPageLimit)
+		}
+		p.Limit = n
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("offset 必須為非負整數")
+		}
+		p.Offset = n
+	}
+	return p, nil
+}
+
+// SQL 產生 LIMIT/OFFSET 片段
+func (p Page) SQL() string {
+	if p.Limit == 0 {
+		if p.Offset == 0 {
+			return ""
+		}
+		// SQLite 需要 LIMIT 才能使用 OFFSET（LIMIT -1 在 PostgreSQL 不合法，改用極大值）
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", int64(1)<<53, p.Offset)
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", p.Limit, p.Offset)
+}
+
+// parseSort 解析 sort 參數（逗號分隔，前綴 - 表示遞減，e.g. "-date,oppDeck"）
+// columns 為允許排序的欄位（API 名稱 → SQL 欄位）；未指定時使用 fallback。
+func parseSort(c *fiber.Ctx, columns map[string]string, fallback string) (string, error) {
+	raw := strings.TrimSpace(c.Query("sort"))
+	if raw == "" {
+		return fallback, nil
+	}
+
+	parts := []string{}
+	for _, key := range strings.Split(raw, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		dir := "ASC"
+		if strings.HasPrefix(key, "-") {
+			dir = "DESC"
+			key = key[1:]
+		} else if strings.HasPrefix(key, "+") {
+			key = key[1:]
+		}
+		col, ok := columns[key]
+		if !ok {
+			return "", fmt.Errorf("不支援的排序欄位: %s", key)
+		}
+		parts = append(parts, col+" "+dir)
+	}
+	if len(parts) == 0 {
+		return fallback, nil
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// parseFields 解析 fields 參數（逗號分隔）；回傳 nil 表示回傳全部欄位
+func parseFields(c *fiber.Ctx, allowed []string) ([]string, error) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	allowedSet := map[string]bool{}
+	for _, f := range allowed {
+		allowedSet[f] = true
+	}
+
+	fields := []string{"id"}
+	seen := map[string]bool{"id": true}
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || seen[f] {
+			continue
+		}
+		if !allowedSet[f] {
+			return nil, fmt.Errorf("不支援的欄位: %s", f)
+		}
+		seen[f] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// selectFields 只保留指定的 JSON 欄位（以 struct 的 json tag 為準）
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	out := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		picked := make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				picked[f] = v
+			}
+		}
+		out = append(out, picked)
+	}
+	return out, nil
+}
+
+// splitList 逗號分隔的查詢參數（去除空白與空值），e.g. "a, b,," → ["a", "b"]
+func splitList(raw string) []string {
+	var out []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// contains 字串是否在清單中
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
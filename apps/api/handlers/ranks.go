@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// RanksHandler 處理階級（天梯）相關請求
+type RanksHandler struct {
+	db *database.DB
+}
+
+// NewRanksHandler 建立新的 ranks handler
+func NewRanksHandler(db *database.DB) *RanksHandler {
+	return &RanksHandler{db: db}
+}
+
+// GetRanks 取得遊戲的階梯 (GET /ranks?gameKey=&mode=)
+// mode 預設 Ranked；由低到高排序，沒有階梯的遊戲/模式回傳空陣列
+func (h *RanksHandler) GetRanks(c *fiber.Ctx) error {
+	mode := c.Query("mode", "Ranked")
+	var errs fieldErrors
+	if errs.oneOf("mode", mode, validModes); len(errs) > 0 {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, "mode "+errs[0].Message)
+	}
+
+	ranks, err := store.ListRanks(h.db, currentGameID(c), mode)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"ranks": ranks,
+		"total": len(ranks),
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+const (
+	maxSearchLength = 200 // q 的最大長度
+	maxSearchTerms  = 10  // q 最多幾個詞
+	minTrigramTerm  = 3   // FTS5 trigram 至少要 3 個字，較短的詞改用 LIKE
+	snippetBefore   = 20  // 片段在第一個符合處之前保留的字數
+	snippetLength   = 80  // 片段的最大字數
+)
+
+// searchTerm 備註搜尋的一個詞（詞之間為 AND）
+type searchTerm struct {
+	text   string
+	phrase bool // "..." 片語
+	prefix bool // 結尾的 * 表示前綴查詢，e.g. misp*
+}
+
+// parseSearchQuery 解析備註搜尋 q：以空白分隔的詞、"..." 片語、結尾 * 的前綴查詢
+// e.g. `卡手 "turn 2" misp*`
+func parseSearchQuery(q string) ([]searchTerm, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(q) > maxSearchLength {
+		return nil, fmt.Errorf("q 不可超過 %d 字", maxSearchLength)
+	}
+
+	var terms []searchTerm
+	for rest := q; rest != ""; rest = strings.TrimLeftFunc(rest, unicode.IsSpace) {
+		var t searchTerm
+		if strings.HasPrefix(rest, `"`) {
+			// 片語：到下一個 " 為止（沒有結尾的 " 時到字串結尾）
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				t.text, rest = rest[1:], ""
+			} else {
+				t.text, rest = rest[1:end+1], rest[end+2:]
+			}
+			t.phrase = true
+			if strings.HasPrefix(rest, "*") {
+				t.prefix, rest = true, rest[1:]
+			}
+			t.text = strings.Join(strings.Fields(t.text), " ")
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			t.text, rest = rest[:end], rest[end:]
+			if trimmed := strings.TrimRight(t.text, "*"); trimmed != t.text {
+				t.text, t.prefix = trimmed, true
+			}
+		}
+		if t.text == "" {
+			continue
+		}
+		terms = append(terms, t)
+	}
+	if len(terms) > maxSearchTerms {
+		return nil, fmt.Errorf("q 最多 %d 個詞", maxSearchTerms)
+	}
+	return terms, nil
+}
+
+// noteSearchFilter 備註搜尋的 WHERE 條件（以 " AND ..." 開頭）
+//   - SQLite：FTS5（trigram）比對 3 個字以上的詞，較短的詞與沒有 FTS5 時用 LIKE
+//   - PostgreSQL：tsvector（'simple'），含中日韓文字的詞用 ILIKE（'simple' 不會切開連續的中日文）
+//
+// trigram 與 LIKE 都是子字串比對，前綴查詢在 SQLite 上等同一般的詞。
+// 使用 MATCH 前先確認索引與 triggers 還在（見 database.NoteIndexReady），否則（含檢查失敗）一律用 LIKE。
+func noteSearchFilter(db *database.DB, terms []searchTerm) (string, []interface{}) {
+	fullText, err := db.NoteIndexReady()
+	fullText = fullText && err == nil
+
+	where := ""
+	var args []interface{}
+	var match []string
+
+	for _, t := range terms {
+		switch {
+		case db.Dialect == database.Postgres && !hasCJK(t.text):
+			const doc = "to_tsvector('simple', COALESCE(m.note, ''))"
+			switch {
+			case t.prefix:
+				where += " AND " + doc + " @@ to_tsquery('simple', ?)"
+				args = append(args, "'"+strings.ReplaceAll(t.text, "'", "''")+"':*")
+			case t.phrase:
+				where += " AND " + doc + " @@ phraseto_tsquery('simple', ?)"
+				args = append(args, t.text)
+			default:
+				where += " AND " + doc + " @@ plainto_tsquery('simple', ?)"
+				args = append(args, t.text)
+			}
+		case db.Dialect == database.SQLite && fullText && utf8.RuneCountInString(t.text) >= minTrigramTerm:
+			expr := `"` + strings.ReplaceAll(t.text, `"`, `""`) + `"`
+			if t.prefix {
+				expr += "*"
+			}
+			match = append(match, expr)
+		default:
+			op := "LIKE"
+			if db.Dialect == database.Postgres {
+				op = "ILIKE"
+			}
+			where += " AND m.note " + op + ` ? ESCAPE '\'`
+			args = append(args, "%"+escapeLike(t.text)+"%")
+		}
+	}
+
+	if len(match) > 0 {
+		where += " AND m.id IN (SELECT match_id FROM matches_fts WHERE matches_fts MATCH ?)"
+		args = append(args, strings.Join(match, " AND "))
+	}
+	return where, args
+}
+
+// escapeLike 跳脫 LIKE 的萬用字元（搭配 ESCAPE '\'）
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// hasCJK 是否含有中日韓文字
+func hasCJK(s string) bool {
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+	return false
+}
+
+// noteSnippet 備註中符合搜尋詞的片段：以第一個符合處為中心最多 snippetLength 字，
+// 符合處以 <mark></mark> 標示，其餘文字經過 HTML 跳脫；截斷處加上 "…"
+func noteSnippet(note string, terms []searchTerm) string {
+	text := []rune(note)
+	lower := []rune(strings.Map(unicode.ToLower, note)) // 逐字轉換，索引與 text 相同
+
+	// 標記每個字是否在符合處內（大小寫不分）
+	marked := make([]bool, len(text))
+	first := -1
+	for _, t := range terms {
+		needle := []rune(strings.Map(unicode.ToLower, t.text))
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if string(lower[i:i+len(needle)]) != string(needle) {
+				continue
+			}
+			for j := i; j < i+len(needle); j++ {
+				marked[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start := max(first-snippetBefore, 0)
+	end := min(start+snippetLength, len(text))
+	start = max(min(start, end-snippetLength), 0) // 接近結尾時往前補滿
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			b.WriteString("<mark>" + html.EscapeString(string(text[i:j])) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(string(text[i:j])))
+		}
+		i = j
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
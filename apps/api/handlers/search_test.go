@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []searchTerm
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"卡手", []searchTerm{{text: "卡手"}}},
+		{` 卡手  "turn 2"  misp* `, []searchTerm{{text: "卡手"}, {text: "turn 2", phrase: true}, {text: "misp", prefix: true}}},
+		{`"  extra   spaces "`, []searchTerm{{text: "extra spaces", phrase: true}}},
+		{`"open phrase`, []searchTerm{{text: "open phrase", phrase: true}}},
+		{`"draw"* hand`, []searchTerm{{text: "draw", phrase: true, prefix: true}, {text: "hand"}}},
+		{`"" * **`, nil},
+		{`ab"cd`, []searchTerm{{text: `ab"cd`}}},
+	}
+	for _, tt := range tests {
+		got, err := parseSearchQuery(tt.q)
+		if err != nil {
+			t.Errorf("parseSearchQuery(%q) error: %v", tt.q, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSearchQuery(%q) = %+v, want %+v", tt.q, got, tt.want)
+		}
+	}
+
+	if _, err := parseSearchQuery(strings.Repeat("字", maxSearchLength+1)); err == nil {
+		t.Error("parseSearchQuery accepted a query over the length limit")
+	}
+	if _, err := parseSearchQuery(strings.Repeat("a ", maxSearchTerms+1)); err == nil {
+		t.Error("parseSearchQuery accepted too many terms")
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := escapeLike(`100%_a\b`), `100\%\_a\\b`; got != want {
+		t.Errorf("escapeLike = %q, want %q", got, want)
+	}
+}
+
+func TestHasCJK(t *testing.T) {
+	tests := map[string]bool{"misplay": false, "卡手": true, "ドロー": true, "패": true, "turn 2 後攻": true, "": false}
+	for s, want := range tests {
+		if got := hasCJK(s); got != want {
+			t.Errorf("hasCJK(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestNoteSearchFilter(t *testing.T) {
+	terms := []searchTerm{{text: "卡手"}, {text: "misp", prefix: true}, {text: "turn 2", phrase: true}, {text: "50%"}}
+
+	// 沒有 FTS5 的 SQLite：全部用 LIKE
+	where, args := noteSearchFilter(&database.DB{Dialect: database.SQLite}, terms)
+	if got := strings.Count(where, "m.note LIKE ? ESCAPE"); got != 4 || strings.Contains(where, "MATCH") {
+		t.Errorf("sqlite filter = %q, want 4 LIKE conditions", where)
+	}
+	wantArgs := []interface{}{"%卡手%", "%misp%", "%turn 2%", `%50\%%`}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("sqlite args = %v, want %v", args, wantArgs)
+	}
+
+	// PostgreSQL：中日韓文字用 ILIKE，其餘用 tsquery
+	where, args = noteSearchFilter(&database.DB{Dialect: database.Postgres}, terms)
+	for _, want := range []string{"m.note ILIKE ?", "to_tsquery('simple', ?)", "phraseto_tsquery('simple', ?)", "plainto_tsquery('simple', ?)"} {
+		if !strings.Contains(where, want) {
+			t.Errorf("postgres filter = %q, want it to contain %q", where, want)
+		}
+	}
+	wantArgs = []interface{}{"%卡手%", "'misp':*", "turn 2", "50%"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("postgres args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestNoteSnippet(t *testing.T) {
+	tests := []struct {
+		name  string
+		note  string
+		terms []searchTerm
+		want  string
+	}{
+		{"marks every match", "Misplay, then misplay again", []searchTerm{{text: "misplay"}},
+			"<mark>Misplay</mark>, then <mark>misplay</mark> again"},
+		{"escapes html", "<b>卡手</b> & 事故", []searchTerm{{text: "卡手"}},
+			"&lt;b&gt;<mark>卡手</mark>&lt;/b&gt; &amp; 事故"},
+		{"adjacent terms merge", "abcdef", []searchTerm{{text: "abc"}, {text: "cde"}},
+			"<mark>abcde</mark>f"},
+		{"no match keeps the start", "short note", []searchTerm{{text: "xyz"}}, "short note"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := noteSnippet(tt.note, tt.terms); got != tt.want {
+				t.Errorf("noteSnippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// 長備註：以第一個符合處為中心截斷，前後加上 "…"
+	note := strings.Repeat("前", 100) + "卡手" + strings.Repeat("後", 100)
+	got := noteSnippet(note, []searchTerm{{text: "卡手"}})
+	want := "…" + strings.Repeat("前", snippetBefore) + "<mark>卡手</mark>" +
+		strings.Repeat("後", snippetLength-snippetBefore-2) + "…"
+	if got != want {
+		t.Errorf("noteSnippet(long) = %q, want %q", got, want)
+	}
+
+	// 接近結尾時往前補滿 snippetLength 字
+	note = strings.Repeat("前", 100) + "卡手"
+	got = noteSnippet(note, []searchTerm{{text: "卡手"}})
+	want = "…" + strings.Repeat("前", snippetLength-2) + "<mark>卡手</mark>"
+	if got != want {
+		t.Errorf("noteSnippet(near end) = %q, want %q", got, want)
+	}
+}
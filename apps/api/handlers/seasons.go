@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// SeasonsHandler 處理賽季相關請求
+type SeasonsHandler struct {
+	db *database.DB
+}
+
+// NewSeasonsHandler 建立新的 seasons handler
+func NewSeasonsHandler(db *database.DB) *SeasonsHandler {
+	return &SeasonsHandler{db: db}
+}
+
+// SeasonInfo 賽季（含目前使用者在該賽季的對局數）
+type SeasonInfo struct {
+	store.Season
+	Matches int `json:"matches"`
+}
+
+// CreateSeasonRequest 新增賽季請求
+type CreateSeasonRequest struct {
+	GameKey   string `json:"gameKey"`
+	Code      string `json:"code"`      // e.g. "S49"、"2026-01"
+	StartDate string `json:"startDate"` // YYYY-MM-DD；代碼為 YYYY-MM 時可省略
+	EndDate   string `json:"endDate"`   // YYYY-MM-DD；省略表示尚未決定
+}
+
+// UpdateSeasonRequest 更新賽季請求（日期傳空字串表示清除）
+type UpdateSeasonRequest struct {
+	Code      *string `json:"code"`
+	StartDate *string `json:"startDate"`
+	EndDate   *string `json:"endDate"`
+	Closed    *bool   `json:"closed"` // false 重新開啟已結束的賽季
+}
+
+// CloseSeasonRequest 結束賽季請求
+type CloseSeasonRequest struct {
+	EndDate string `json:"endDate"` // 省略時為今天（原本的結束日期較早時沿用）
+}
+
+// GetSeasons 取得遊戲的所有賽季 (GET /seasons?gameKey=)
+// issues 列出日期範圍重疊、有空檔或沒有日期的賽季
+func (h *SeasonsHandler) GetSeasons(c *fiber.Ctx) error {
+	gameID := currentGameID(c)
+	seasons, err := store.ListSeasons(h.db, gameID)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	counts := map[string]int{}
+	rows, err := h.db.Query(`
+		SELECT season_id, COUNT(*) FROM matches
+		WHERE game_id = ? AND user_id = ?
+		GROUP BY season_id
+	`, gameID, currentUserID(c))
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	infos := make([]SeasonInfo, 0, len(seasons))
+	for _, s := range seasons {
+		infos = append(infos, SeasonInfo{Season: s, Matches: counts[s.ID]})
+	}
+
+	return c.JSON(fiber.Map{
+		"seasons": infos,
+		"issues":  store.SeasonIssues(seasons),
+		"total":   len(infos),
+	})
+}
+
+// CreateSeason 新增賽季 (POST /seasons)
+func (h *SeasonsHandler) CreateSeason(c *fiber.Ctx) error {
+	var req CreateSeasonRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateCreateSeason(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	gameID, errResp := gameIDForBody(c, h.db, req.GameKey)
+	if gameID == "" {
+		return errResp
+	}
+
+	existing, err := store.FindSeasonID(h.db, gameID, req.Code)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if existing != "" {
+		return apiError(c, fiber.StatusConflict, CodeConflict, "賽季已存在："+req.Code)
+	}
+
+	id := uuid.New().String()
+	err = h.audit(c, id, func(tx *database.Tx) error {
+		_, err := tx.Exec(
+			"INSERT INTO seasons (id, game_id, code, start_date, end_date) VALUES (?, ?, ?, ?, ?)",
+			id, gameID, req.Code, nullString(req.StartDate), nullString(req.EndDate),
+		)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "新增賽季失敗", err)
+	}
+
+	warnings, err := h.seasonWarnings(gameID, req.Code)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"id":       id,
+		"message":  "賽季新增成功",
+		"warnings": warnings,
+	})
+}
+
+// UpdateSeason 更新賽季 (PATCH /seasons/:id)
+func (h *SeasonsHandler) UpdateSeason(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req UpdateSeasonRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+
+	season, gameID, err := h.findSeason(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到賽季")
+	} else if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	if errs := validateUpdateSeason(&req, season); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	updates := []string{}
+	args := []interface{}{}
+
+	if req.Code != nil && *req.Code != season.Code {
+		existing, err := store.FindSeasonID(h.db, gameID, *req.Code)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		if existing != "" {
+			return apiError(c, fiber.StatusConflict, CodeConflict, "賽季已存在："+*req.Code)
+		}
+		updates = append(updates, "code = ?")
+		args = append(args, *req.Code)
+	}
+	if req.StartDate != nil {
+		updates = append(updates, "start_date = ?")
+		args = append(args, nullString(*req.StartDate))
+	}
+	if req.EndDate != nil {
+		updates = append(updates, "end_date = ?")
+		args = append(args, nullString(*req.EndDate))
+	}
+	if req.Closed != nil {
+		updates = append(updates, "closed_at = ?")
+		if *req.Closed {
+			args = append(args, closedAt(season))
+		} else {
+			args = append(args, nil)
+		}
+	}
+
+	if len(updates) == 0 {
+		return apiError(c, fiber.StatusUnprocessableEntity, CodeValidationFailed, "沒有要更新的欄位")
+	}
+
+	args = append(args, id)
+	err = h.audit(c, id, func(tx *database.Tx) error {
+		_, err := tx.Exec("UPDATE seasons SET "+joinStrings(updates, ", ")+" WHERE id = ?", args...)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "更新賽季失敗", err)
+	}
+
+	code := season.Code
+	if req.Code != nil {
+		code = *req.Code
+	}
+	warnings, err := h.seasonWarnings(gameID, code)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "賽季更新成功",
+		"warnings": warnings,
+	})
+}
+
+// CloseSeason 結束賽季 (POST /seasons/:id/close)
+// 結束後不能再新增對局到該賽季；結束日期省略時為今天
+func (h *SeasonsHandler) CloseSeason(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req CloseSeasonRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return invalidBody(c, err)
+		}
+	}
+
+	season, gameID, err := h.findSeason(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到賽季")
+	} else if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if season.Closed() {
+		return apiError(c, fiber.StatusConflict, CodeConflict, "賽季已結束："+season.Code)
+	}
+
+	if errs := validateCloseSeason(&req, season, time.Now()); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	err = h.audit(c, id, func(tx *database.Tx) error {
+		_, err := tx.Exec("UPDATE seasons SET end_date = ?, closed_at = ? WHERE id = ?", req.EndDate, time.Now(), id)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "結束賽季失敗", err)
+	}
+
+	warnings, err := h.seasonWarnings(gameID, season.Code)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "賽季已結束",
+		"endDate":  req.EndDate,
+		"warnings": warnings,
+	})
+}
+
+// audit 在交易中執行 fn，並以 fn 前後的內容寫入賽季的變更紀錄
+func (h *SeasonsHandler) audit(c *fiber.Ctx, id string, fn func(tx *database.Tx) error) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := loadSeasonSnapshot(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	after, err := loadSeasonSnapshot(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(tx, currentUserID(c), auditSeason, id, before, after, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// findSeason 取得賽季與所屬遊戲
+func (h *SeasonsHandler) findSeason(id string) (store.Season, string, error) {
+	var gameID string
+	if err := h.db.QueryRow("SELECT game_id FROM seasons WHERE id = ?", id).Scan(&gameID); err != nil {
+		return store.Season{}, "", err
+	}
+	season, err := store.GetSeason(h.db, id)
+	return season, gameID, err
+}
+
+// seasonWarnings 與指定賽季有關的日期範圍問題（新增/修改後提醒用）
+func (h *SeasonsHandler) seasonWarnings(gameID, code string) ([]store.SeasonIssue, error) {
+	seasons, err := store.ListSeasons(h.db, gameID)
+	if err != nil {
+		return nil, err
+	}
+	warnings := []store.SeasonIssue{}
+	for _, issue := range store.SeasonIssues(seasons) {
+		for _, c := range issue.Seasons {
+			if c == code {
+				warnings = append(warnings, issue)
+				break
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// closedAt 已結束的賽季沿用原本的結束時間
+func closedAt(s store.Season) time.Time {
+	if s.ClosedAt != nil {
+		return *s.ClosedAt
+	}
+	return time.Now()
+}
+
+// nullString 空字串存為 NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// StatsHandler 處理統計相關請求（口徑見 docs/spec.md 5.3）
+type StatsHandler struct {
+	db *database.DB
+}
+
+// NewStatsHandler 建立新的 stats handler
+func NewStatsHandler(db *database.DB) *StatsHandler {
+	return &StatsHandler{db: db}
+}
+
+// StatsSummary 統計摘要（KPI）
+// 比率皆為 0~1；分母為 0 時回傳 null
+type StatsSummary struct {
+	Total         int      `json:"total"`
+	Wins          int      `json:"wins"`
+	Draws         int      `json:"draws"`
+	First         int      `json:"first"`
+	Second        int      `json:"second"`
+	FirstWins     int      `json:"firstWins"`
+	SecondWins    int      `json:"secondWins"`
+	FirstRate     *float64 `json:"firstRate"`
+	WinRate       *float64 `json:"winRate"`
+	FirstWinRate  *float64 `json:"firstWinRate"`
+	SecondWinRate *float64 `json:"secondWinRate"`
+}
+
+// DailyStats 每日統計
+type DailyStats struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	StatsSummary
+}
+
+// OpponentStats 對手大軸分布
+type OpponentStats struct {
+	DeckMain string  `json:"deckMain"`
+	Count    int     `json:"count"`
+	Pct      float64 `json:"pct"` // 0~1
+}
+
+// statsAggregateColumns 依 5.3 口徑計算所需的計數欄位
+const statsAggregateColumns = `
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN m.result = 'W' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.result = 'D' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.play_order = '先攻' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.play_order = '後攻' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.play_order = '先攻' AND m.result = 'W' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.play_order = '後攻' AND m.result = 'W' THEN 1 ELSE 0 END), 0)
+`
+
+// GetSummary 統計摘要 (GET /stats/summary)
+func (h *StatsHandler) GetSummary(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := "SELECT " + statsAggregateColumns + matchesFromClause + where
+
+	var s StatsSummary
+	err = h.db.QueryRow(query, args...).Scan(&s.Total, &s.Wins, &s.Draws, &s.First, &s.Second, &s.FirstWins, &s.SecondWins)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	s.fillRates()
+
+	return c.JSON(s)
+}
+
+// GetDaily 每日統計 (GET /stats/daily)
+func (h *StatsHandler) GetDaily(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := "SELECT m.date, " + statsAggregateColumns + matchesFromClause + where +
+		" GROUP BY m.date ORDER BY m.date ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	daily := []DailyStats{}
+	for rows.Next() {
+		var d DailyStats
+		if err := rows.Scan(&d.Date, &d.Total, &d.Wins, &d.Draws, &d.First, &d.Second, &d.FirstWins, &d.SecondWins); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		d.Date = dateOnly(d.Date)
+		d.fillRates()
+		daily = append(daily, d)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"daily": daily,
+		"total": len(daily),
+	})
+}
+
+// GetOpponents 對手大軸分布 (GET /stats/opponents)
+func (h *StatsHandler) GetOpponents(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := "SELECT opp_deck.main, COUNT(*) AS cnt" + matchesFromClause + where +
+		" GROUP BY opp_deck.main ORDER BY cnt DESC, opp_deck.main ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	opponents := []OpponentStats{}
+	total := 0
+	for rows.Next() {
+		var o OpponentStats
+		if err := rows.Scan(&o.DeckMain, &o.Count); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		total += o.Count
+		opponents = append(opponents, o)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	// opp_dist[deck_main] = count(opp_deck.main=deck_main) / N
+	for i := range opponents {
+		opponents[i].Pct = float64(opponents[i].Count) / float64(total)
+	}
+
+	return c.JSON(fiber.Map{
+		"opponents": opponents,
+		"total":     total,
+	})
+}
+
+// fillRates 依計數欄位套用 5.3 統計口徑
+func (s *StatsSummary) fillRates() {
+	s.FirstRate = ratio(s.First, s.Total)
+	s.WinRate = ratio(s.Wins, s.Total)
+	s.FirstWinRate = ratio(s.FirstWins, s.First)
+	s.SecondWinRate = ratio(s.SecondWins, s.Second)
+}
+
+// ratio 計算比率；分母為 0 時回傳 nil（JSON 為 null）
+func ratio(n, d int) *float64 {
+	if d == 0 {
+		return nil
+	}
+	r := float64(n) / float64(d)
+	return &r
+}
+
+// dateOnly 將 driver 回傳的日期（可能為 RFC3339）正規化為 YYYY-MM-DD
+func dateOnly(s string) string {
+	if i := strings.IndexByte(s, 'T'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// gameAggregateColumns 同 statsAggregateColumns，但以各局（match_games g）為單位計算
+var gameAggregateColumns = strings.NewReplacer(
+	"m.result", "g.result",
+	"m.play_order", "g.play_order",
+).Replace(statsAggregateColumns)
+
+// GameNoStats 第 N 局的統計
+type GameNoStats struct {
+	GameNo int `json:"gameNo"`
+	StatsSummary
+}
+
+// GetSideStats 換 side 前後的勝率 (GET /stats/side)
+// 只統計有各局記錄的三戰兩勝對局（篩選條件同 GET /matches）：
+// preSide 為第 1 局、postSide 為第 2、3 局，games 為每一局的統計
+func (h *StatsHandler) GetSideStats(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := "SELECT g.game_no, " + gameAggregateColumns +
+		" FROM match_games g WHERE g.match_id IN (SELECT m.id" + matchesFromClause + where + ")" +
+		" GROUP BY g.game_no ORDER BY g.game_no ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	games := []GameNoStats{}
+	var pre, post StatsSummary
+	for rows.Next() {
+		var g GameNoStats
+		if err := rows.Scan(&g.GameNo, &g.Total, &g.Wins, &g.Draws, &g.First, &g.Second, &g.FirstWins, &g.SecondWins); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		if g.GameNo == 1 {
+			pre = g.StatsSummary
+		} else {
+			post.add(g.StatsSummary)
+		}
+		g.fillRates()
+		games = append(games, g)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	pre.fillRates()
+	post.fillRates()
+
+	return c.JSON(fiber.Map{
+		"matches":  pre.Total, // 每場三戰兩勝都有第 1 局
+		"preSide":  pre,
+		"postSide": post,
+		"games":    games,
+	})
+}
+
+// add 累加計數（比率需再呼叫 fillRates）
+func (s *StatsSummary) add(o StatsSummary) {
+	s.Total += o.Total
+	s.Wins += o.Wins
+	s.Draws += o.Draws
+	s.First += o.First
+	s.Second += o.Second
+	s.FirstWins += o.FirstWins
+	s.SecondWins += o.SecondWins
+}
+
+// TagStats 標籤的統計：有此標籤與沒有此標籤的對局比較
+type TagStats struct {
+	Tag        string   `json:"tag"`
+	Games      int      `json:"games"`
+	Wins       int      `json:"wins"`
+	Losses     int      `json:"losses"`
+	Draws      int      `json:"draws"`
+	WinRate    *float64 `json:"winRate"`
+	Pct        float64  `json:"pct"`        // 有此標籤的對局比例（0~1）
+	OthersRate *float64 `json:"othersRate"` // 沒有此標籤的對局勝率
+}
+
+// GetTagStats 各標籤的勝率 (GET /stats/tags)
+// 篩選條件同 GET /matches（e.g. excludeTags=disconnect 排除斷線的對局）；依對局數由多到少排列。
+// othersRate 為篩選後沒有此標籤的對局勝率，方便比較（e.g. 卡手時與其他對局）
+func (h *StatsHandler) GetTagStats(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+
+	var total StatsSummary
+	err = h.db.QueryRow("SELECT "+statsAggregateColumns+matchesFromClause+where, args...).
+		Scan(&total.Total, &total.Wins, &total.Draws, &total.First, &total.Second, &total.FirstWins, &total.SecondWins)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	total.fillRates()
+
+	query := `
+		SELECT t.name, COUNT(*),
+			COALESCE(SUM(CASE WHEN tm.result = 'W' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN tm.result = 'D' THEN 1 ELSE 0 END), 0)
+		FROM match_tags mt
+		JOIN tags t ON t.id = mt.tag_id
+		JOIN matches tm ON tm.id = mt.match_id
+		WHERE mt.match_id IN (SELECT m.id` + matchesFromClause + where + `)
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name ASC`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	tags := []TagStats{}
+	for rows.Next() {
+		var t TagStats
+		if err := rows.Scan(&t.Tag, &t.Games, &t.Wins, &t.Draws); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		t.Losses = t.Games - t.Wins - t.Draws
+		t.WinRate = ratio(t.Wins, t.Games)
+		if total.Total > 0 {
+			t.Pct = float64(t.Games) / float64(total.Total)
+		}
+		t.OthersRate = ratio(total.Wins-t.Wins, total.Total-t.Games)
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"tags":    tags,
+		"summary": total,
+	})
+}
+
+// OutcomeStats 勝負原因的統計
+type OutcomeStats struct {
+	Reason string  `json:"reason"` // "normal" | "opp_surrender" | "my_surrender" | "timeout" | "disconnect"
+	Count  int     `json:"count"`
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+	Draws  int     `json:"draws"`
+	Pct    float64 `json:"pct"` // 0~1
+}
+
+// GetOutcomes 勝負原因分布 (GET /stats/outcomes)
+// 篩選條件同 GET /matches；excluded 為排除對手投降與斷線（excludeOutcomes=opp_surrender,disconnect）後的摘要，
+// 用來比較白勝、斷線對勝率的影響
+func (h *StatsHandler) GetOutcomes(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := `SELECT m.outcome_reason, COUNT(*),
+			COALESCE(SUM(CASE WHEN m.result = 'W' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.result = 'L' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN m.result = 'D' THEN 1 ELSE 0 END), 0)` +
+		matchesFromClause + where + " GROUP BY m.outcome_reason"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	byReason := map[string]OutcomeStats{}
+	total := 0
+	for rows.Next() {
+		var o OutcomeStats
+		if err := rows.Scan(&o.Reason, &o.Count, &o.Wins, &o.Losses, &o.Draws); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		total += o.Count
+		byReason[o.Reason] = o
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	// 依固定順序列出所有原因（沒有對局的為 0）
+	outcomes := make([]OutcomeStats, 0, len(validOutcomes))
+	var all, excluded StatsSummary
+	for _, reason := range validOutcomes {
+		o := byReason[reason]
+		o.Reason = reason
+		if total > 0 {
+			o.Pct = float64(o.Count) / float64(total)
+		}
+		outcomes = append(outcomes, o)
+
+		all.Total += o.Count
+		all.Wins += o.Wins
+		all.Draws += o.Draws
+		if reason != "opp_surrender" && reason != "disconnect" {
+			excluded.Total += o.Count
+			excluded.Wins += o.Wins
+			excluded.Draws += o.Draws
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"outcomes": outcomes,
+		"total":    total,
+		"winRate":  ratio(all.Wins, all.Total),
+		"excluded": fiber.Map{
+			"reasons": []string{"opp_surrender", "disconnect"},
+			"total":   excluded.Total,
+			"wins":    excluded.Wins,
+			"draws":   excluded.Draws,
+			"winRate": ratio(excluded.Wins, excluded.Total),
+		},
+	})
+}
+
+// RankPoint 階級變化的一個點（一場天梯對局）
+type RankPoint struct {
+	ID         string `json:"id"`
+	Date       string `json:"date"`
+	SeasonCode string `json:"seasonCode"`
+	Rank       string `json:"rank"`
+	Ordinal    int    `json:"ordinal"`
+	Result     string `json:"result"`
+}
+
+// RankDay 每日的階級變化（開始、結束、最高、最低的 ordinal）
+type RankDay struct {
+	Date    string `json:"date"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	High    int    `json:"high"`
+	Low     int    `json:"low"`
+	Matches int    `json:"matches"`
+}
+
+// GetRankProgression 天梯階級變化 (GET /stats/rank-progression)
+// 篩選條件同 GET /matches，只計入有對應階級的天梯對局，依時間先後排序；
+// ranks 為階梯（圖表的 Y 軸），peak 為期間內最高的一場
+func (h *StatsHandler) GetRankProgression(c *fiber.Ctx) error {
+	where, args, err := buildMatchFilters(c, h.db)
+	if err != nil {
+		return invalidQuery(c, err)
+	}
+	query := "SELECT m.id, m.date, s.code, rk.name, rk.ordinal, m.result" + matchesFromClause + where +
+		" AND m.mode = 'Ranked' AND rk.id IS NOT NULL ORDER BY m.date ASC, m.created_at ASC, m.id ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	points := []RankPoint{}
+	daily := []RankDay{}
+	peak := -1 // points 中最高的一場
+	for rows.Next() {
+		var p RankPoint
+		if err := rows.Scan(&p.ID, &p.Date, &p.SeasonCode, &p.Rank, &p.Ordinal, &p.Result); err != nil {
+			return internalError(c, "解析資料失敗", err)
+		}
+		p.Date = dateOnly(p.Date)
+		points = append(points, p)
+
+		if peak < 0 || p.Ordinal > points[peak].Ordinal {
+			peak = len(points) - 1
+		}
+
+		if n := len(daily); n > 0 && daily[n-1].Date == p.Date {
+			d := &daily[n-1]
+			d.End = p.Ordinal
+			d.High = max(d.High, p.Ordinal)
+			d.Low = min(d.Low, p.Ordinal)
+			d.Matches++
+		} else {
+			daily = append(daily, RankDay{Date: p.Date, Start: p.Ordinal, End: p.Ordinal, High: p.Ordinal, Low: p.Ordinal, Matches: 1})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	ranks, err := store.ListRanks(h.db, currentGameID(c), "Ranked")
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	var peakPoint *RankPoint
+	if peak >= 0 {
+		peakPoint = &points[peak]
+	}
+
+	return c.JSON(fiber.Map{
+		"ranks":  ranks,
+		"points": points,
+		"daily":  daily,
+		"peak":   peakPoint,
+		"total":  len(points),
+	})
+}
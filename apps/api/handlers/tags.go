@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/models"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// TagsHandler 處理對局標籤
+type TagsHandler struct {
+	db *database.DB
+}
+
+// NewTagsHandler 建立新的 tags handler
+func NewTagsHandler(db *database.DB) *TagsHandler {
+	return &TagsHandler{db: db}
+}
+
+// Tag 標籤（含使用次數）
+type Tag struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Matches   int       `json:"matches"` // 有此標籤的對局數
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TagRequest 新增/改名標籤請求
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+// MergeTagsRequest 標籤合併請求（e.g. 把 "mis-play" 合併到 "misplay"）
+type MergeTagsRequest struct {
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"` // 不存在時由第一個來源標籤改名
+	DryRun  bool     `json:"dryRun"` // 只預覽，不寫入
+}
+
+// GetTags 取得目前使用者的標籤 (GET /tags)
+func (h *TagsHandler) GetTags(c *fiber.Ctx) error {
+	rows, err := h.db.Query(`
+		SELECT t.id, t.name, COUNT(mt.id), t.created_at
+		FROM tags t
+		LEFT JOIN match_tags mt ON mt.tag_id = t.id
+		WHERE t.user_id = ?
+		GROUP BY t.id, t.name, t.created_at
+		ORDER BY t.name ASC
+	`, currentUserID(c))
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var t Tag
+		var createdAt sql.NullTime
+		if err := rows.Scan(&t.ID, &t.Name, &t.Matches, &createdAt); err != nil {
+			return internalError(c, "讀取資料失敗", err)
+		}
+		if createdAt.Valid {
+			t.CreatedAt = createdAt.Time
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return internalError(c, "讀取資料失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"tags":  tags,
+		"total": len(tags),
+	})
+}
+
+// CreateTag 新增標籤 (POST /tags)
+func (h *TagsHandler) CreateTag(c *fiber.Ctx) error {
+	var req TagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateTag(&req.Name); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	userID := currentUserID(c)
+	existing, err := store.FindTagID(h.db, userID, req.Name)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if existing != "" {
+		return apiError(c, fiber.StatusConflict, CodeConflict, "標籤已存在："+req.Name)
+	}
+
+	id := uuid.New().String()
+	if _, err := h.db.Exec("INSERT INTO tags (id, user_id, name) VALUES (?, ?, ?)", id, userID, req.Name); err != nil {
+		return internalError(c, "新增標籤失敗", err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"id":      id,
+		"name":    req.Name,
+		"message": "標籤新增成功",
+	})
+}
+
+// RenameTag 標籤改名 (PATCH /tags/:id)
+// 新名稱已被其他標籤使用時回傳 409，請改用 POST /tags/merge
+func (h *TagsHandler) RenameTag(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少標籤 ID")
+	}
+
+	var req TagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateTag(&req.Name); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	userID := currentUserID(c)
+	var name string
+	err := h.db.QueryRow("SELECT name FROM tags WHERE id = ? AND user_id = ?", id, userID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到標籤")
+	} else if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	existing, err := store.FindTagID(h.db, userID, req.Name)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if existing != "" && existing != id {
+		return apiError(c, fiber.StatusConflict, CodeConflict, "標籤已存在："+req.Name+"（合併請使用 POST /tags/merge）")
+	}
+
+	// 對局的內容包含標籤名稱，有此標籤的對局都記一筆變更
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	matchIDs, err := store.TagMatchIDs(tx, []string{id})
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	err = auditMatchUpdates(tx, userID, matchIDs, func() error {
+		_, err := tx.Exec("UPDATE tags SET name = ? WHERE id = ?", req.Name, id)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "更新標籤失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "更新標籤失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "標籤更新成功",
+		"from":    name,
+		"to":      req.Name,
+	})
+}
+
+// DeleteTag 刪除標籤 (DELETE /tags/:id)
+// 對局本身不受影響，只移除此標籤
+func (h *TagsHandler) DeleteTag(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apiError(c, fiber.StatusBadRequest, CodeInvalidParam, "缺少標籤 ID")
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	userID := currentUserID(c)
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM tags WHERE id = ? AND user_id = ?", id, userID).Scan(&exists); err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	if exists == 0 {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到標籤")
+	}
+
+	matchIDs, err := store.TagMatchIDs(tx, []string{id})
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+	err = auditMatchUpdates(tx, userID, matchIDs, func() error {
+		if _, err := tx.Exec("DELETE FROM tags WHERE id = ?", id); err != nil {
+			return err
+		}
+		// SQLite 不會執行 ON DELETE CASCADE，一併移除對局上的標籤
+		_, err := tx.Exec("DELETE FROM match_tags WHERE tag_id = ?", id)
+		return err
+	})
+	if err != nil {
+		return internalError(c, "刪除標籤失敗", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(c, "刪除標籤失敗", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "標籤刪除成功",
+		"matches": len(matchIDs),
+	})
+}
+
+// MergeTags 將多個標籤合併為一個 (POST /tags/merge)
+// 有來源標籤的對局改標為 target，來源標籤刪除；全部在同一個交易中完成。
+func (h *TagsHandler) MergeTags(c *fiber.Ctx) error {
+	var req MergeTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return invalidBody(c, err)
+	}
+	if errs := validateMergeTags(&req); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return internalError(c, "開始交易失敗", err)
+	}
+	defer tx.Rollback()
+
+	userID := currentUserID(c)
+	sourceIDs := make([]string, 0, len(req.Sources))
+	for _, name := range req.Sources {
+		id, err := store.FindTagID(tx, userID, name)
+		if err != nil {
+			return internalError(c, "查詢失敗", err)
+		}
+		if id != "" {
+			sourceIDs = append(sourceIDs, id)
+		}
+	}
+	matchIDs, err := store.TagMatchIDs(tx, sourceIDs)
+	if err != nil {
+		return internalError(c, "查詢失敗", err)
+	}
+
+	var summary *store.TagMergeSummary
+	err = auditMatchUpdates(tx, userID, matchIDs, func() (err error) {
+		summary, err = store.MergeTags(tx, userID, req.Sources, req.Target)
+		return err
+	})
+	if errors.Is(err, store.ErrTagNotFound) {
+		return apiError(c, fiber.StatusNotFound, CodeNotFound, "找不到標籤")
+	}
+	if err != nil {
+		return internalError(c, "合併標籤失敗", err)
+	}
+
+	if !req.DryRun {
+		if err := tx.Commit(); err != nil {
+			return internalError(c, "合併標籤失敗", err)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"dryRun":  req.DryRun,
+		"summary": summary,
+	})
+}
+
+// loadMatchTags 讀取對局的標籤並填入 Tags（依名稱排序）
+func loadMatchTags(q database.Querier, matches []models.MatchWithDetails) error {
+	index := make(map[string]int, len(matches))
+	for i := range matches {
+		index[matches[i].ID] = i
+	}
+
+	return forEachMatchChunk(matches, func(in string, args []interface{}) error {
+		rows, err := q.Query(`
+			SELECT mt.match_id, t.name
+			FROM match_tags mt
+			JOIN tags t ON t.id = mt.tag_id
+			WHERE mt.match_id IN (`+in+`)
+			ORDER BY mt.match_id, t.name
+		`, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var matchID, name string
+			if err := rows.Scan(&matchID, &name); err != nil {
+				return err
+			}
+			m := &matches[index[matchID]]
+			m.Tags = append(m.Tags, name)
+		}
+		return rows.Err()
+	})
+}
+
+// tagFilter 標籤篩選條件：any 為有任一個標籤、all 為全部標籤都有、exclude 為沒有任何一個標籤
+func tagFilter(mode string, names []string) (string, []interface{}) {
+	placeholders := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		placeholders[i] = "?"
+		args[i] = name
+	}
+	sub := "FROM match_tags mt JOIN tags t ON t.id = mt.tag_id WHERE mt.match_id = m.id AND t.name IN (" +
+		joinStrings(placeholders, ", ") + ")"
+
+	switch mode {
+	case "all":
+		args = append(args, len(names))
+		return " AND (SELECT COUNT(DISTINCT t.name) " + sub + ") = ?", args
+	case "exclude":
+		return " AND NOT EXISTS (SELECT 1 " + sub + ")", args
+	default:
+		return " AND EXISTS (SELECT 1 " + sub + ")", args
+	}
+}
@@ -0,0 +1,683 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/models"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// 錯誤代碼（回應中的 code 欄位，供程式判斷）
+const (
+	CodeInvalidBody      = "INVALID_BODY"      // 400 請求內容無法解析
+	CodeInvalidQuery     = "INVALID_QUERY"     // 400 查詢參數錯誤
+	CodeInvalidParam     = "INVALID_PARAM"     // 400 路徑參數錯誤
+	CodeValidationFailed = "VALIDATION_FAILED" // 422 欄位驗證失敗（見 fields）
+	CodeUnauthorized     = "UNAUTHORIZED"      // 401 未登入或 token 失效
+	CodeForbidden        = "FORBIDDEN"         // 403 沒有權限（e.g. 修改共用資料需要管理員）
+	CodeNotFound         = "NOT_FOUND"         // 404 找不到資源
+	CodeConflict         = "CONFLICT"          // 409 資源已存在
+	CodeInternal         = "INTERNAL_ERROR"    // 500 伺服器錯誤
+)
+
+// 欄位錯誤代碼（fields[].code）
+const (
+	FieldRequired    = "required"
+	FieldInvalidEnum = "invalid_enum"
+	FieldInvalidDate = "invalid_date"
+	FieldTooLong     = "too_long"
+	FieldTooShort    = "too_short"
+	FieldInvalid     = "invalid"
+	FieldNotFound    = "not_found"
+)
+
+// 欄位長度上限（以字元計）
+const (
+	maxDeckNameLength = 50
+	maxRankLength     = 20
+	maxCodeLength     = 50
+	maxNoteLength     = 2000
+	maxGameNameLength = 100
+	maxSideLength     = 500
+	maxTagLength      = 30
+)
+
+// minMatchGames、maxMatchGames 三戰兩勝至少 2 局、最多 3 局
+const (
+	minMatchGames = 2
+	maxMatchGames = 3
+)
+
+// maxMatchTags 每場對局最多的標籤數
+const maxMatchTags = 20
+
+var (
+	validModes      = []string{"Ranked", "Rating", "DC"}
+	validPlayOrders = []string{"先攻", "後攻"}
+	validResults    = []string{"W", "L", "D"}
+	validOutcomes   = []string{"normal", "opp_surrender", "my_surrender", "timeout", "disconnect"}
+	validCoinTosses = []string{"won", "lost"}
+	validDeckTypes  = []string{"main", "sub"}
+	validThemes     = []string{"融合", "超量", "連結", "同步", "陷阱", "魔法", "輔助", "儀式", "鐘擺", "無"}
+)
+
+// FieldError 單一欄位的驗證錯誤
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// fieldErrors 收集驗證錯誤
+type fieldErrors []FieldError
+
+// String 合併為單行說明（e.g. "rank: 必填; result: 必須為 [W L] 其中之一"）
+func (e fieldErrors) String() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *fieldErrors) add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// required 必填字串
+func (e *fieldErrors) required(field, value string) bool {
+	if value == "" {
+		e.add(field, FieldRequired, "必填")
+		return false
+	}
+	return true
+}
+
+// maxLength 字串長度上限
+func (e *fieldErrors) maxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		e.add(field, FieldTooLong, fmt.Sprintf("長度不可超過 %d 個字元", max))
+	}
+}
+
+// oneOf 列舉值
+func (e *fieldErrors) oneOf(field, value string, allowed []string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	e.add(field, FieldInvalidEnum, fmt.Sprintf("必須為 %v 其中之一", allowed))
+}
+
+// date YYYY-MM-DD 日期
+func (e *fieldErrors) date(field, value string) {
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		e.add(field, FieldInvalidDate, "日期格式必須為 YYYY-MM-DD")
+	}
+}
+
+// deck 牌組表單（大軸必填）
+func (e *fieldErrors) deck(field string, d models.DeckForm) {
+	if e.required(field+".main", d.Main) {
+		e.maxLength(field+".main", d.Main, maxDeckNameLength)
+	}
+	if d.Sub != nil {
+		e.maxLength(field+".sub", *d.Sub, maxDeckNameLength)
+	}
+}
+
+// outcome 勝負原因需與結果一致（對手投降只能是勝、自己投降只能是敗）
+func (e *fieldErrors) outcome(result, reason string) {
+	switch {
+	case reason == "opp_surrender" && result != "W":
+		e.add("outcomeReason", FieldInvalid, "對手投降時 result 必須為 W")
+	case reason == "my_surrender" && result != "L":
+		e.add("outcomeReason", FieldInvalid, "自己投降時 result 必須為 L")
+	}
+}
+
+// opposite 相反的先後攻
+func opposite(playOrder string) string {
+	if playOrder == "先攻" {
+		return "後攻"
+	}
+	return "先攻"
+}
+
+// tossChoice 檢查擲硬幣記錄並回傳猜中的一方的選擇：
+// 猜中（won）時為自己的先後攻，猜錯（lost）時為對手的選擇（與自己的先後攻相反）。
+// 沒有記錄擲硬幣時回傳空字串
+func (e *fieldErrors) tossChoice(coinToss, choice, playOrder string) string {
+	if coinToss == "" {
+		if choice != "" {
+			e.add("choice", FieldInvalid, "需要同時記錄 coinToss")
+		}
+		return ""
+	}
+	expected := playOrder
+	if coinToss == "lost" {
+		expected = opposite(playOrder)
+	}
+	if choice != "" && choice != expected {
+		e.add("choice", FieldInvalid, "與 coinToss、playOrder 不一致（應為 "+expected+"）")
+	}
+	return expected
+}
+
+// tag 標籤名稱（需已正規化）；名稱不可包含逗號（篩選參數以逗號分隔）
+func (e *fieldErrors) tag(field, name string) bool {
+	if !e.required(field, name) {
+		return false
+	}
+	if utf8.RuneCountInString(name) > maxTagLength {
+		e.maxLength(field, name, maxTagLength)
+		return false
+	}
+	if strings.Contains(name, ",") {
+		e.add(field, FieldInvalid, "不可包含逗號")
+		return false
+	}
+	return true
+}
+
+// tagList 正規化對局的標籤並去除重複
+func (e *fieldErrors) tagList(names []string) []string {
+	tags := make([]string, 0, len(names))
+	for i, name := range names {
+		name = store.NormalizeTagName(name)
+		if !e.tag(fmt.Sprintf("tags[%d]", i), name) || contains(tags, name) {
+			continue
+		}
+		tags = append(tags, name)
+	}
+	if len(tags) > maxMatchTags {
+		e.add("tags", FieldInvalid, fmt.Sprintf("最多 %d 個標籤", maxMatchTags))
+	}
+	return tags
+}
+
+// games 三戰兩勝的各局（依序為第 1~3 局）：至少 2 局，第 1 局不能換 side，
+// 勝負分出後不能再有下一局，未分出勝負時必須打滿 3 局
+func (e *fieldErrors) games(games []models.MatchGameForm) {
+	if len(games) < minMatchGames {
+		e.add("games", FieldTooShort, fmt.Sprintf("至少 %d 局（單局對局請省略 games）", minMatchGames))
+		return
+	}
+	if len(games) > maxMatchGames {
+		e.add("games", FieldInvalid, fmt.Sprintf("最多 %d 局", maxMatchGames))
+		return
+	}
+	wins, losses := 0, 0
+	for i, g := range games {
+		field := fmt.Sprintf("games[%d]", i)
+		if wins == 2 || losses == 2 {
+			e.add(field, FieldInvalid, fmt.Sprintf("勝負已分出，不能有第 %d 局", i+1))
+			return
+		}
+		e.oneOf(field+".playOrder", g.PlayOrder, validPlayOrders)
+		e.oneOf(field+".result", g.Result, validResults)
+		for _, side := range []struct {
+			name  string
+			value *string
+		}{{"sideIn", g.SideIn}, {"sideOut", g.SideOut}} {
+			if side.value == nil || *side.value == "" {
+				continue
+			}
+			if i == 0 {
+				e.add(field+"."+side.name, FieldInvalid, "第 1 局為換 side 前，不能記錄換 side")
+			} else {
+				e.maxLength(field+"."+side.name, *side.value, maxSideLength)
+			}
+		}
+		if g.Note != nil {
+			e.maxLength(field+".note", *g.Note, maxNoteLength)
+		}
+		switch g.Result {
+		case "W":
+			wins++
+		case "L":
+			losses++
+		}
+	}
+	if wins < 2 && losses < 2 && len(games) < maxMatchGames {
+		e.add("games", FieldInvalid, fmt.Sprintf("勝負未分出，需要第 %d 局", len(games)+1))
+	}
+}
+
+// derivedFromGames 有各局記錄時，檢查 result/playOrder 與各局推導的結果一致（省略時補上）
+func (e *fieldErrors) derivedFromGames(games []models.MatchGameForm, result, playOrder *string) {
+	derived, order := gamesResult(games)
+	if *result == "" {
+		*result = derived
+	} else if *result != derived {
+		e.add("result", FieldInvalid, "與各局結果不一致（各局推導為 "+derived+"）")
+	}
+	if *playOrder == "" {
+		*playOrder = order
+	} else if *playOrder != order {
+		e.add("playOrder", FieldInvalid, "必須與第 1 局的先後攻相同（"+order+"）")
+	}
+}
+
+// validateCreateMatch 驗證新增對局請求（並補上預設值）
+func validateCreateMatch(req *models.CreateMatchRequest) fieldErrors {
+	var errs fieldErrors
+
+	if req.Mode == "" {
+		req.Mode = "Ranked"
+	}
+	if req.Mode != "Ranked" && req.Rank == "" {
+		req.Rank = "—"
+	}
+	if req.OutcomeReason == "" {
+		req.OutcomeReason = "normal"
+	}
+
+	errs.required("gameKey", req.GameKey)
+	// seasonCode 可省略，由 date 推斷
+	errs.maxLength("seasonCode", req.SeasonCode, maxCodeLength)
+	if errs.required("date", req.Date) {
+		errs.date("date", req.Date)
+	}
+	errs.oneOf("mode", req.Mode, validModes)
+	if errs.required("rank", req.Rank) {
+		errs.maxLength("rank", req.Rank, maxRankLength)
+	}
+	errs.deck("myDeck", req.MyDeck)
+	errs.deck("oppDeck", req.OppDeck)
+	if len(req.Games) > 0 {
+		var gameErrs fieldErrors
+		if gameErrs.games(req.Games); len(gameErrs) == 0 {
+			gameErrs.derivedFromGames(req.Games, &req.Result, &req.PlayOrder)
+		}
+		errs = append(errs, gameErrs...)
+	}
+	coinToss, choice := derefString(req.CoinToss), derefString(req.Choice)
+	if coinToss != "" {
+		errs.oneOf("coinToss", coinToss, validCoinTosses)
+	}
+	if choice != "" {
+		errs.oneOf("choice", choice, validPlayOrders)
+		// 省略 playOrder 時由擲硬幣與選擇推導
+		if req.PlayOrder == "" && contains(validCoinTosses, coinToss) && contains(validPlayOrders, choice) {
+			req.PlayOrder = choice
+			if coinToss == "lost" {
+				req.PlayOrder = opposite(choice)
+			}
+		}
+	}
+	// 各局有誤時無法推導 result/playOrder，只回報各局的錯誤
+	if len(errs) == 0 || len(req.Games) == 0 {
+		errs.oneOf("playOrder", req.PlayOrder, validPlayOrders)
+		errs.oneOf("result", req.Result, validResults)
+	}
+	errs.oneOf("outcomeReason", req.OutcomeReason, validOutcomes)
+	if len(errs) == 0 {
+		errs.outcome(req.Result, req.OutcomeReason)
+		choice = errs.tossChoice(coinToss, choice, req.PlayOrder)
+		req.CoinToss, req.Choice = optionalString(coinToss), optionalString(choice)
+	}
+	if req.Note != nil {
+		errs.maxLength("note", *req.Note, maxNoteLength)
+	}
+	req.Tags = errs.tagList(req.Tags)
+
+	return errs
+}
+
+// validateUpdateMatch 驗證更新對局請求（只檢查有提供的欄位）
+func validateUpdateMatch(req *models.UpdateMatchRequest) fieldErrors {
+	var errs fieldErrors
+
+	if req.SeasonCode != nil && errs.required("seasonCode", *req.SeasonCode) {
+		errs.maxLength("seasonCode", *req.SeasonCode, maxCodeLength)
+	}
+	if req.Date != nil && errs.required("date", *req.Date) {
+		errs.date("date", *req.Date)
+	}
+	if req.Mode != nil {
+		errs.oneOf("mode", *req.Mode, validModes)
+	}
+	if req.Rank != nil && errs.required("rank", *req.Rank) {
+		errs.maxLength("rank", *req.Rank, maxRankLength)
+	}
+	if req.MyDeck != nil {
+		errs.deck("myDeck", *req.MyDeck)
+	}
+	if req.OppDeck != nil {
+		errs.deck("oppDeck", *req.OppDeck)
+	}
+	if req.PlayOrder != nil {
+		errs.oneOf("playOrder", *req.PlayOrder, validPlayOrders)
+	}
+	if req.Result != nil {
+		errs.oneOf("result", *req.Result, validResults)
+	}
+	if req.OutcomeReason != nil {
+		errs.oneOf("outcomeReason", *req.OutcomeReason, validOutcomes)
+	}
+	// coinToss 空字串表示清除
+	if req.CoinToss != nil && *req.CoinToss != "" {
+		errs.oneOf("coinToss", *req.CoinToss, validCoinTosses)
+	}
+	if req.Choice != nil && *req.Choice != "" {
+		errs.oneOf("choice", *req.Choice, validPlayOrders)
+	}
+	if req.Note != nil {
+		errs.maxLength("note", *req.Note, maxNoteLength)
+	}
+	if req.Tags != nil {
+		tags := errs.tagList(*req.Tags)
+		req.Tags = &tags
+	}
+	if req.Games != nil && len(*req.Games) > 0 {
+		var gameErrs fieldErrors
+		if gameErrs.games(*req.Games); len(gameErrs) == 0 {
+			// 以各局推導的結果更新對局的 result/playOrder
+			var result, playOrder string
+			if req.Result != nil {
+				result = *req.Result
+			}
+			if req.PlayOrder != nil {
+				playOrder = *req.PlayOrder
+			}
+			gameErrs.derivedFromGames(*req.Games, &result, &playOrder)
+			req.Result, req.PlayOrder = &result, &playOrder
+		}
+		errs = append(errs, gameErrs...)
+	}
+
+	return errs
+}
+
+// validateTag 驗證標籤名稱（並正規化）
+func validateTag(name *string) fieldErrors {
+	var errs fieldErrors
+	*name = store.NormalizeTagName(*name)
+	errs.tag("name", *name)
+	return errs
+}
+
+// validateMergeTags 驗證標籤合併請求（並正規化名稱）
+func validateMergeTags(req *MergeTagsRequest) fieldErrors {
+	var errs fieldErrors
+
+	req.Target = store.NormalizeTagName(req.Target)
+	if len(req.Sources) == 0 {
+		errs.add("sources", FieldRequired, "必填")
+	}
+	for i := range req.Sources {
+		req.Sources[i] = store.NormalizeTagName(req.Sources[i])
+		if errs.tag("sources", req.Sources[i]) && req.Sources[i] == req.Target {
+			errs.add("sources", FieldInvalid, "不可包含合併目標 "+req.Target)
+		}
+	}
+	errs.tag("target", req.Target)
+
+	return errs
+}
+
+// derefString nil 視為空字串
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// optionalString 空字串視為 nil
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// validateCreateDeckTemplate 驗證新增牌組模板請求（並補上預設值）
+func validateCreateDeckTemplate(req *CreateDeckTemplateRequest) fieldErrors {
+	var errs fieldErrors
+
+	if req.Theme == "" {
+		// 屬性只對 Master Duel 有意義，其他遊戲預設為「無」
+		req.Theme = "無"
+		if req.GameKey == "master_duel" {
+			req.Theme = "連結"
+		}
+	}
+	if req.DeckType == "" {
+		req.DeckType = "main"
+	}
+	req.Name = store.NormalizeDeckName(req.Name)
+
+	errs.required("gameKey", req.GameKey)
+	if errs.required("name", req.Name) {
+		errs.maxLength("name", req.Name, maxDeckNameLength)
+	}
+	errs.oneOf("theme", req.Theme, validThemes)
+	errs.oneOf("deckType", req.DeckType, validDeckTypes)
+
+	return errs
+}
+
+// validateUpdateDeckTemplate 驗證更新牌組模板請求
+func validateUpdateDeckTemplate(req *UpdateDeckTemplateRequest) fieldErrors {
+	var errs fieldErrors
+
+	req.Name = store.NormalizeDeckName(req.Name)
+	if req.Name != "" {
+		errs.maxLength("name", req.Name, maxDeckNameLength)
+	}
+	if req.Theme != "" {
+		errs.oneOf("theme", req.Theme, validThemes)
+	}
+
+	return errs
+}
+
+// validateCreateDeckAlias 驗證新增牌組別名請求（並補上預設值）
+func validateCreateDeckAlias(req *CreateDeckAliasRequest) fieldErrors {
+	var errs fieldErrors
+
+	req.Alias = store.NormalizeDeckName(req.Alias)
+	if req.GameKey == "" {
+		req.GameKey = "master_duel"
+	}
+
+	if errs.required("alias", req.Alias) {
+		errs.maxLength("alias", req.Alias, maxDeckNameLength)
+	}
+	errs.required("templateId", req.TemplateID)
+
+	return errs
+}
+
+// validateUpdateDeckAlias 驗證更新牌組別名請求
+func validateUpdateDeckAlias(req *UpdateDeckAliasRequest) fieldErrors {
+	var errs fieldErrors
+
+	req.Alias = store.NormalizeDeckName(req.Alias)
+	if req.Alias != "" {
+		errs.maxLength("alias", req.Alias, maxDeckNameLength)
+	}
+
+	return errs
+}
+
+// gameKeyPattern 遊戲 key：小寫英數與底線，英文字母開頭（e.g. "master_duel"、"ptcg"）
+var gameKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// gameKey 遊戲 key 格式
+func (e *fieldErrors) gameKey(field, value string) {
+	if utf8.RuneCountInString(value) > maxCodeLength {
+		e.maxLength(field, value, maxCodeLength)
+		return
+	}
+	if !gameKeyPattern.MatchString(value) {
+		e.add(field, FieldInvalid, "只能使用小寫英文、數字與底線，且以英文字母開頭")
+	}
+}
+
+// validateCreateGame 驗證新增遊戲請求
+func validateCreateGame(req *CreateGameRequest) fieldErrors {
+	var errs fieldErrors
+
+	req.Key = strings.TrimSpace(req.Key)
+	req.Name = strings.TrimSpace(req.Name)
+
+	if errs.required("key", req.Key) {
+		errs.gameKey("key", req.Key)
+	}
+	if errs.required("name", req.Name) {
+		errs.maxLength("name", req.Name, maxGameNameLength)
+	}
+
+	return errs
+}
+
+// validateUpdateGame 驗證更新遊戲請求
+func validateUpdateGame(req *UpdateGameRequest) fieldErrors {
+	var errs fieldErrors
+
+	req.Key = strings.TrimSpace(req.Key)
+	req.Name = strings.TrimSpace(req.Name)
+
+	if req.Key != "" {
+		errs.gameKey("key", req.Key)
+	}
+	if req.Name != "" {
+		errs.maxLength("name", req.Name, maxGameNameLength)
+	}
+
+	return errs
+}
+
+// dateRange 開始日期不可晚於結束日期（任一為空時不檢查）
+func (e *fieldErrors) dateRange(start, end string) {
+	if start != "" && end != "" && start > end {
+		e.add("endDate", FieldInvalid, "結束日期不可早於開始日期")
+	}
+}
+
+// validateCreateSeason 驗證新增賽季請求（代碼為 YYYY-MM 且沒有日期時補上該月份）
+func validateCreateSeason(req *CreateSeasonRequest) fieldErrors {
+	var errs fieldErrors
+
+	req.Code = strings.TrimSpace(req.Code)
+	if req.StartDate == "" && req.EndDate == "" {
+		if start, end, ok := store.MonthRange(req.Code); ok {
+			req.StartDate, req.EndDate = start, end
+		}
+	}
+
+	errs.required("gameKey", req.GameKey)
+	if errs.required("code", req.Code) {
+		errs.maxLength("code", req.Code, maxCodeLength)
+	}
+	if req.StartDate != "" {
+		errs.date("startDate", req.StartDate)
+	}
+	if req.EndDate != "" {
+		errs.date("endDate", req.EndDate)
+	}
+	if len(errs) == 0 {
+		errs.dateRange(req.StartDate, req.EndDate)
+	}
+
+	return errs
+}
+
+// validateUpdateSeason 驗證更新賽季請求（日期與既有的值合併後檢查範圍）
+func validateUpdateSeason(req *UpdateSeasonRequest, season store.Season) fieldErrors {
+	var errs fieldErrors
+
+	if req.Code != nil {
+		*req.Code = strings.TrimSpace(*req.Code)
+		if errs.required("code", *req.Code) {
+			errs.maxLength("code", *req.Code, maxCodeLength)
+		}
+	}
+	start, end := "", ""
+	if season.StartDate != nil {
+		start = *season.StartDate
+	}
+	if season.EndDate != nil {
+		end = *season.EndDate
+	}
+	if req.StartDate != nil {
+		if start = *req.StartDate; start != "" {
+			errs.date("startDate", start)
+		}
+	}
+	if req.EndDate != nil {
+		if end = *req.EndDate; end != "" {
+			errs.date("endDate", end)
+		}
+	}
+	if len(errs) == 0 {
+		errs.dateRange(start, end)
+	}
+
+	return errs
+}
+
+// validateCloseSeason 驗證結束賽季請求（並補上結束日期）
+func validateCloseSeason(req *CloseSeasonRequest, season store.Season, now time.Time) fieldErrors {
+	var errs fieldErrors
+
+	if req.EndDate == "" {
+		req.EndDate = now.Format("2006-01-02")
+		if season.EndDate != nil && *season.EndDate < req.EndDate {
+			req.EndDate = *season.EndDate
+		}
+	}
+
+	errs.date("endDate", req.EndDate)
+	if len(errs) == 0 && season.StartDate != nil {
+		errs.dateRange(*season.StartDate, req.EndDate)
+	}
+
+	return errs
+}
+
+// apiError 統一的錯誤回應：{ "error": 說明, "code": 錯誤代碼 }
+func apiError(c *fiber.Ctx, status int, code, message string) error {
+	return c.Status(status).JSON(fiber.Map{"error": message, "code": code})
+}
+
+// invalidBody 400：請求內容無法解析
+func invalidBody(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":   "請求格式錯誤",
+		"code":    CodeInvalidBody,
+		"details": err.Error(),
+	})
+}
+
+// invalidQuery 400：查詢參數錯誤
+func invalidQuery(c *fiber.Ctx, err error) error {
+	return apiError(c, fiber.StatusBadRequest, CodeInvalidQuery, err.Error())
+}
+
+// validationFailed 422：欄位驗證失敗，fields 列出每個欄位的錯誤
+func validationFailed(c *fiber.Ctx, errs fieldErrors) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":  "欄位驗證失敗",
+		"code":   CodeValidationFailed,
+		"fields": errs,
+	})
+}
+
+// internalError 500：伺服器錯誤。原始錯誤只寫入伺服器 log（可能包含 SQL 與資料庫結構），回應只有 message 與 code
+func internalError(c *fiber.Ctx, message string, err error) error {
+	if err != nil {
+		log.Printf("%s %s: %s: %v", c.Method(), c.Path(), message, err)
+	}
+	return apiError(c, fiber.StatusInternalServerError, CodeInternal, message)
+}
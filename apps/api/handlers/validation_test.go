@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/harvc/duellog/apps/api/models"
+)
+
+func TestValidateGames(t *testing.T) {
+	game := func(playOrder, result string) models.MatchGameForm {
+		return models.MatchGameForm{PlayOrder: playOrder, Result: result}
+	}
+	sided := game("先攻", "W")
+	side := "增殖的G"
+	sided.SideIn = &side
+
+	tests := []struct {
+		name  string
+		games []models.MatchGameForm
+		want  []string // 有錯誤的欄位
+	}{
+		{"two wins", []models.MatchGameForm{game("先攻", "W"), game("後攻", "W")}, nil},
+		{"decided in three", []models.MatchGameForm{game("先攻", "W"), game("後攻", "L"), game("先攻", "L")}, nil},
+		{"draw in game three", []models.MatchGameForm{game("先攻", "W"), game("後攻", "L"), game("先攻", "D")}, nil},
+		{"side in later game", []models.MatchGameForm{game("先攻", "W"), game("後攻", "L"), sided}, nil},
+		{"single game", []models.MatchGameForm{game("先攻", "W")}, []string{"games"}},
+		{"four games", []models.MatchGameForm{game("先攻", "W"), game("後攻", "L"), game("先攻", "D"), game("後攻", "W")}, []string{"games"}},
+		{"undecided", []models.MatchGameForm{game("先攻", "W"), game("後攻", "L")}, []string{"games"}},
+		{"draws undecided", []models.MatchGameForm{game("先攻", "D"), game("後攻", "D")}, []string{"games"}},
+		{"game after decided", []models.MatchGameForm{game("先攻", "W"), game("後攻", "W"), game("先攻", "L")}, []string{"games[2]"}},
+		{"side in game one", []models.MatchGameForm{sided, game("後攻", "W")}, []string{"games[0].sideIn"}},
+		{"invalid values", []models.MatchGameForm{game("先", "W"), game("後攻", "O"), game("先攻", "W")}, []string{"games[0].playOrder", "games[1].result"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs fieldErrors
+			errs.games(tt.games)
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("games() errors = %v, want fields %v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestDerivedFromGames(t *testing.T) {
+	games := []models.MatchGameForm{{PlayOrder: "後攻", Result: "L"}, {PlayOrder: "先攻", Result: "W"}, {PlayOrder: "後攻", Result: "W"}}
+
+	var errs fieldErrors
+	result, playOrder := "", ""
+	errs.derivedFromGames(games, &result, &playOrder)
+	if len(errs) != 0 || result != "W" || playOrder != "後攻" {
+		t.Errorf("derivedFromGames filled %q, %q with errors %v; want W, 後攻", result, playOrder, errs)
+	}
+
+	errs = nil
+	result, playOrder = "L", "先攻"
+	errs.derivedFromGames(games, &result, &playOrder)
+	if len(errs) != 2 {
+		t.Errorf("derivedFromGames errors = %v, want result and playOrder", errs)
+	}
+}
+
+func TestInternalErrorHidesDetails(t *testing.T) {
+	var logs bytes.Buffer
+	w := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(w) })
+
+	app := fiber.New()
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return internalError(c, "查詢失敗", errors.New("no such column: m.secret_column"))
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/fail", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /fail: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != fiber.StatusInternalServerError || string(body) != `{"code":"INTERNAL_ERROR","error":"查詢失敗"}` {
+		t.Errorf("response = %d %s, want only code and message", resp.StatusCode, body)
+	}
+	if !strings.Contains(logs.String(), "GET /fail: 查詢失敗: no such column: m.secret_column") {
+		t.Errorf("log = %q, want the raw error", logs.String())
+	}
+}
@@ -0,0 +1,289 @@
+// Package importer 將試算表匯出的 CSV 對局紀錄匯入資料庫（附加模式，會略過已存在的對局）。
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/harvc/duellog/apps/api/models"
+)
+
+// Row CSV 中一筆已正規化的對局
+type Row struct {
+	Line       int // CSV 行號（標題列為第 1 行）
+	Date       string
+	SeasonCode string
+	Mode       string
+	Rank       string
+	RankMapped bool // false 表示階級不在 RankMapping 中；匯入時改為是否在遊戲的階梯上（見 resolver）
+	MyMain     string
+	MySub      *string
+	OppMain    string
+	OppSub     *string
+	PlayOrder  string
+	Result     string
+	Note       *string
+	// 以下為可選欄位（舊格式的試算表沒有）
+	OutcomeReason string                 // 勝負原因（預設 "normal"）
+	CoinToss      *string                // 擲硬幣 "won" | "lost"
+	Choice        *string                // 猜中的一方選擇的先後攻
+	Tags          []string               // 標籤（已正規化）
+	Games         []models.MatchGameForm // 三戰兩勝的各局（單局對局為 nil）
+	Raw           []string               // 原始欄位（錯誤報告用）
+}
+
+// RowError 無法匯入的資料列
+type RowError struct {
+	Line   int      `json:"line"`
+	Reason string   `json:"reason"`
+	Raw    []string `json:"raw"`
+}
+
+// MappingError 欄位對應設定錯誤
+type MappingError struct {
+	Err error
+}
+
+func (e *MappingError) Error() string { return e.Err.Error() }
+
+func (e *MappingError) Unwrap() error { return e.Err }
+
+// ParseOptions 解析設定
+type ParseOptions struct {
+	Delimiter rune          // 0 表示自動判斷（第一行有 tab 時視為 TSV，e.g. 從 Excel 貼上）
+	NoHeader  bool          // 第一行就是資料
+	Columns   ColumnMapping // nil 表示使用 DefaultMapping
+}
+
+// ParseCSV 以 cmd/import 的固定欄位順序讀取 CSV（第一列為標題列）
+func ParseCSV(r io.Reader) ([]Row, []RowError, error) {
+	return Parse(r, ParseOptions{Delimiter: ','})
+}
+
+// Parse 讀取並正規化 CSV/TSV；格式錯誤的資料列放在 RowError，不中斷整體讀取
+func Parse(r io.Reader, opts ParseOptions) ([]Row, []RowError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("無法讀取檔案: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel 匯出的 UTF-8 BOM
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comma = opts.Delimiter
+	if reader.Comma == 0 {
+		reader.Comma = detectDelimiter(data)
+	}
+	if reader.Comma == '\t' {
+		// 從 Excel 貼上的文字不會正確跳脫引號；tab 也算空白，TrimLeadingSpace 會把空欄位併入下一欄
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = false
+	}
+
+	var header []string
+	if !opts.NoHeader {
+		header, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("檔案沒有資料")
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("無法讀取標題列: %w", err)
+		}
+	}
+
+	mapping := DefaultMapping()
+	if opts.Columns != nil {
+		if mapping, err = opts.Columns.Resolve(header); err != nil {
+			return nil, nil, &MappingError{Err: err}
+		}
+	}
+
+	rows := []Row{}
+	rowErrors := []RowError{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, RowError{Line: parseErr.StartLine, Reason: parseErr.Err.Error(), Raw: record})
+				continue
+			}
+			return nil, nil, fmt.Errorf("無法讀取檔案: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+		row, err := parseRecord(record, mapping)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Line: line, Reason: err.Error(), Raw: record})
+			continue
+		}
+		row.Line = line
+		row.Raw = record
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+// parseRecord 正規化單一資料列
+func parseRecord(record []string, mapping Mapping) (Row, error) {
+	var row Row
+	if min := mapping.minColumns(); len(record) < min {
+		return row, fmt.Errorf("欄位不足（需要至少 %d 欄，實際 %d 欄）", min, len(record))
+	}
+	field := func(name string) (string, bool) {
+		idx, ok := mapping[name]
+		if !ok || idx >= len(record) {
+			return "", false
+		}
+		return strings.TrimSpace(record[idx]), true
+	}
+	value := func(name string) string {
+		v, _ := field(name)
+		return v
+	}
+
+	var err error
+	rankRaw := value(FieldRank)
+	if modeRaw, ok := field(FieldMode); ok {
+		if row.Mode, err = NormalizeMode(modeRaw); err != nil {
+			return row, err
+		}
+	} else {
+		// 舊格式沒有 Mode 欄：Rank 欄可直接寫 Rating / DC
+		row.Mode = "Ranked"
+		if mode, modeErr := NormalizeMode(rankRaw); modeErr == nil && mode != "Ranked" {
+			row.Mode = mode
+			rankRaw = ""
+		}
+	}
+
+	switch {
+	case rankRaw != "":
+		row.Rank, row.RankMapped = NormalizeRank(rankRaw)
+	case row.Mode == "Ranked":
+		return row, fmt.Errorf("缺少階級")
+	default:
+		// rank 為必填欄位，非天梯模式沒有填時使用佔位符
+		row.Rank, row.RankMapped = "—", true
+	}
+
+	if row.Result, err = NormalizeResult(value(FieldResult)); err != nil {
+		return row, err
+	}
+	if row.PlayOrder, err = NormalizePlayOrder(value(FieldPlayOrder)); err != nil {
+		return row, err
+	}
+	if row.Date, err = NormalizeDate(value(FieldDate)); err != nil {
+		return row, err
+	}
+
+	row.SeasonCode = value(FieldSeason)
+	if row.SeasonCode == "" {
+		return row, fmt.Errorf("缺少賽季")
+	}
+	row.MyMain = value(FieldMyMain)
+	row.OppMain = value(FieldOppMain)
+	if row.MyMain == "" || row.OppMain == "" {
+		return row, fmt.Errorf("缺少牌組大軸")
+	}
+	row.MySub = NormalizeSub(value(FieldMySub))
+	row.OppSub = NormalizeSub(value(FieldOppSub))
+
+	if note := value(FieldNote); note != "" {
+		row.Note = &note
+	}
+
+	if row.OutcomeReason, err = NormalizeOutcome(value(FieldOutcome)); err != nil {
+		return row, err
+	}
+	if row.Games, err = ParseGames(value(FieldGames)); err != nil {
+		return row, err
+	}
+	if row.Games != nil {
+		// 與 POST /matches 相同，result 與先後攻必須與各局推導的相同
+		result, playOrder := gamesResult(row.Games)
+		if row.Result != result {
+			return row, fmt.Errorf("勝負與各局結果不一致（各局推導為 %s）", result)
+		}
+		if row.PlayOrder != playOrder {
+			return row, fmt.Errorf("先後攻必須與第 1 局相同（%s）", playOrder)
+		}
+	}
+	switch {
+	case row.OutcomeReason == "opp_surrender" && row.Result != "W":
+		return row, fmt.Errorf("對手投降時勝負必須為 W")
+	case row.OutcomeReason == "my_surrender" && row.Result != "L":
+		return row, fmt.Errorf("自己投降時勝負必須為 L")
+	}
+
+	if row.CoinToss, err = NormalizeCoinToss(value(FieldCoinToss)); err != nil {
+		return row, err
+	}
+	if row.Choice, err = tossChoice(row.CoinToss, value(FieldChoice), row.PlayOrder); err != nil {
+		return row, err
+	}
+	if row.Tags, err = NormalizeTags(value(FieldTags)); err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+// tossChoice 猜中硬幣的一方的選擇：猜中時為自己的先後攻，猜錯時相反（與 POST /matches 相同）；
+// 省略時由擲硬幣與先後攻推導，沒有記錄擲硬幣時不能有選擇
+func tossChoice(coinToss *string, raw, playOrder string) (*string, error) {
+	if coinToss == nil {
+		if strings.TrimSpace(raw) != "" {
+			return nil, fmt.Errorf("記錄選擇時需要同時記錄擲硬幣")
+		}
+		return nil, nil
+	}
+	expected := playOrder
+	if *coinToss == "lost" {
+		expected = "先攻"
+		if playOrder == "先攻" {
+			expected = "後攻"
+		}
+	}
+	if strings.TrimSpace(raw) != "" {
+		choice, err := NormalizePlayOrder(raw)
+		if err != nil {
+			return nil, err
+		}
+		if choice != expected {
+			return nil, fmt.Errorf("選擇與擲硬幣、先後攻不一致（應為 %s）", expected)
+		}
+	}
+	return &expected, nil
+}
+
+// detectDelimiter 第一行含 tab 時視為 TSV，否則為 CSV
+func detectDelimiter(data []byte) rune {
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	if bytes.IndexByte(firstLine, '\t') >= 0 {
+		return '\t'
+	}
+	return ','
+}
+
+func isBlank(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
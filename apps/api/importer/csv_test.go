@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testHeader = "Rank,Account,本家,小軸,勝負,先後攻,本家,小軸,備註,Date,Season\n"
+
+func TestParseCSV(t *testing.T) {
+	input := "\xef\xbb\xbf" + testHeader +
+		"金4,main,閃刀姬,無,O,先,天盃龍,,卡手,2025/1/2,S40\n" +
+		"金4,main,閃刀姬,,?,先,天盃龍,,,2025/1/2,S40\n" +
+		",,,,,,,,,,\n" +
+		"Rating,main,閃刀姬,,X,後,天盃龍,烙印,,2025-01-03,S40\n" +
+		",main,閃刀姬,,X,後,天盃龍,,,2025-01-03,S40\n" +
+		"金4,main,閃刀姬\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("ParseCSV returned %d rows, want 2", len(rows))
+	}
+
+	first := rows[0]
+	if first.Line != 2 || first.Rank != "金 IV" || !first.RankMapped || first.Mode != "Ranked" ||
+		first.MyMain != "閃刀姬" || first.MySub != nil || first.OppMain != "天盃龍" ||
+		first.Result != "W" || first.PlayOrder != "先攻" || first.Date != "2025-01-02" || first.SeasonCode != "S40" {
+		t.Errorf("first row = %+v", first)
+	}
+	if first.Note == nil || *first.Note != "卡手" {
+		t.Errorf("first row note = %v, want %q", first.Note, "卡手")
+	}
+	if first.OutcomeReason != "normal" || first.CoinToss != nil || first.Games != nil {
+		t.Errorf("first row optional fields = %q, %v, %v; want defaults", first.OutcomeReason, first.CoinToss, first.Games)
+	}
+
+	// 舊格式沒有 Mode 欄時，Rank 欄可直接寫模式
+	legacy := rows[1]
+	if legacy.Line != 5 || legacy.Mode != "Rating" || legacy.Rank != "—" || legacy.OppSub == nil || *legacy.OppSub != "烙印" {
+		t.Errorf("legacy row = %+v", legacy)
+	}
+
+	wantErrors := []struct {
+		line   int
+		reason string
+	}{
+		{3, "無法辨識的勝負"},
+		{6, "缺少階級"},
+		{7, "欄位不足"},
+	}
+	if len(rowErrors) != len(wantErrors) {
+		t.Fatalf("ParseCSV returned %d row errors, want %d: %+v", len(rowErrors), len(wantErrors), rowErrors)
+	}
+	for i, want := range wantErrors {
+		got := rowErrors[i]
+		if got.Line != want.line || !strings.Contains(got.Reason, want.reason) || len(got.Raw) == 0 {
+			t.Errorf("row error %d = %+v, want line %d containing %q", i, got, want.line, want.reason)
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	if _, _, err := ParseCSV(strings.NewReader("")); err == nil {
+		t.Error("ParseCSV(empty) succeeded, want an error")
+	}
+}
+
+func TestWriteErrorReport(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteErrorReport(&buf, []RowError{{Line: 3, Reason: "缺少賽季", Raw: []string{"金4", "a,b"}}})
+	if err != nil {
+		t.Fatalf("WriteErrorReport: %v", err)
+	}
+	want := "line,reason,raw\n3,缺少賽季,金4,\"a,b\"\n"
+	if buf.String() != want {
+		t.Errorf("WriteErrorReport = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestParseOptionalColumns(t *testing.T) {
+	header := strings.TrimSuffix(testHeader, "\n") + ",Mode,OutcomeReason,CoinToss,Choice,Tags,Games\n"
+	games := `"[{""playOrder"":""後攻"",""result"":""L""},{""playOrder"":""先攻"",""result"":""W""},{""playOrder"":""後攻"",""result"":""W""}]"`
+	input := header +
+		"金4,main,蛇眼,,W,後攻,天盃龍,,,2025/1/2,S40,Ranked,opp_surrender,猜中,,\"卡手, 失誤\"," + games + "\n" +
+		"金4,main,蛇眼,,L,後攻,天盃龍,,,2025/1/2,S40,Ranked,,,,," + games + "\n" +
+		"金4,main,蛇眼,,L,先攻,天盃龍,,,2025/1/2,S40,Ranked,opp_surrender,,,,\n" +
+		"金4,main,蛇眼,,W,先攻,天盃龍,,,2025/1/2,S40,Ranked,,lost,先攻,,\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("ParseCSV returned %d rows and errors %+v, want 1 row", len(rows), rowErrors)
+	}
+	r := rows[0]
+	if r.OutcomeReason != "opp_surrender" || r.CoinToss == nil || *r.CoinToss != "won" ||
+		r.Choice == nil || *r.Choice != "後攻" || len(r.Tags) != 2 || len(r.Games) != 3 {
+		t.Errorf("row = %+v", r)
+	}
+
+	wantErrors := []string{"勝負與各局結果不一致", "對手投降時勝負必須為 W", "選擇與擲硬幣、先後攻不一致"}
+	if len(rowErrors) != len(wantErrors) {
+		t.Fatalf("ParseCSV returned row errors %+v, want %d", rowErrors, len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		if !strings.Contains(rowErrors[i].Reason, want) {
+			t.Errorf("row error %d = %q, want one containing %q", i, rowErrors[i].Reason, want)
+		}
+	}
+}
+
+func TestTossChoice(t *testing.T) {
+	won, lost := "won", "lost"
+	tests := []struct {
+		name      string
+		coinToss  *string
+		raw       string
+		playOrder string
+		want      string // "" 表示 nil
+		wantErr   bool
+	}{
+		{"no toss", nil, "", "先攻", "", false},
+		{"choice without toss", nil, "先攻", "先攻", "", true},
+		{"won derived", &won, "", "後攻", "後攻", false},
+		{"lost derived", &lost, "", "後攻", "先攻", false},
+		{"won matching", &won, "先", "先攻", "先攻", false},
+		{"lost mismatching", &lost, "後攻", "後攻", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tossChoice(tt.coinToss, tt.raw, tt.playOrder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tossChoice error = %v, want error: %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == "") || (got != nil && *got != tt.want) {
+				t.Errorf("tossChoice = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
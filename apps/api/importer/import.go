@@ -0,0 +1,513 @@
+package importer
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/models"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// Options 匯入設定
+type Options struct {
+	UserID  string // 對局所屬的使用者
+	GameID  string // e.g. "game-md"
+	DryRun  bool   // 只產生報告，不寫入資料庫
+	Preview bool   // 在報告中附上每一筆的解析結果（Report.Preview）
+	// AfterInsert 每新增一筆對局（含各局記錄與標籤）後在同一個交易中呼叫（e.g. 寫入變更紀錄），可為 nil
+	AfterInsert func(q database.Querier, matchID string) error
+}
+
+// Report 匯入結果
+type Report struct {
+	DryRun        bool           `json:"dryRun"`
+	Rows          int            `json:"rows"`          // 資料列總數（不含標題列與空白列）
+	Imported      int            `json:"imported"`      // 新增（dry-run 時為將新增）的對局數
+	Duplicates    int            `json:"duplicates"`    // 資料庫中已存在而略過的對局數
+	Failed        int            `json:"failed"`        // 無法匯入的資料列數（見 Errors）
+	NewSeasons    []string       `json:"newSeasons"`    // 將建立的賽季
+	NewDecks      []string       `json:"newDecks"`      // 將建立的牌組（大軸 / 小軸）
+	UnmappedRanks map[string]int `json:"unmappedRanks"` // 不在遊戲階梯上的階級字串 → 出現次數（這些資料列不會匯入，見 Errors）
+	Errors        []RowError     `json:"errors"`
+	Preview       []PreviewRow   `json:"preview,omitempty"`
+}
+
+// 預覽狀態
+const (
+	StatusNew       = "new"
+	StatusDuplicate = "duplicate"
+)
+
+// PreviewDeck 預覽中的牌組
+type PreviewDeck struct {
+	Main string  `json:"main"`
+	Sub  *string `json:"sub"`
+}
+
+// PreviewRow 單筆解析結果（無法匯入的資料列在 Report.Errors）
+type PreviewRow struct {
+	Line       int         `json:"line"`
+	Status     string      `json:"status"` // "new" | "duplicate"
+	Date       string      `json:"date"`
+	SeasonCode string      `json:"seasonCode"`
+	Mode       string      `json:"mode"`
+	Rank       string      `json:"rank"`
+	RankMapped bool        `json:"rankMapped"` // 階級在遊戲的階梯上（遊戲沒有階梯時一律為 true）
+	MyDeck     PreviewDeck `json:"myDeck"`
+	OppDeck    PreviewDeck `json:"oppDeck"`
+	PlayOrder  string      `json:"playOrder"`
+	Result     string      `json:"result"`
+	Note       *string     `json:"note"`
+	// 可選欄位（見 Row）
+	OutcomeReason string                 `json:"outcomeReason"`
+	CoinToss      *string                `json:"coinToss"`
+	Choice        *string                `json:"choice"`
+	Tags          []string               `json:"tags,omitempty"`
+	Games         []models.MatchGameForm `json:"games,omitempty"`
+}
+
+// Run 讀取 CSV 並匯入
+func Run(db *database.DB, r io.Reader, opts Options) (*Report, error) {
+	rows, rowErrors, err := ParseCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	return Import(db, rows, rowErrors, opts)
+}
+
+// Import 在單一交易內匯入已解析的資料列（附加模式）
+//
+// 與資料庫中同一使用者、同一遊戲的對局比對所有欄位，已存在的視為重複而略過
+// （牌組別名與階級的不同寫法先轉為正式名稱再比對，見 resolver）。
+// 以次數比對：檔案中第 n 筆相同內容的對局，只有在資料庫已有至少 n 筆時才略過，
+// 因此同一天打出完全相同結果的多場對局不會被誤判為重複。
+// DryRun 時同樣執行所有寫入以取得準確的報告，最後再 rollback。
+func Import(db *database.DB, rows []Row, rowErrors []RowError, opts Options) (*Report, error) {
+	report := &Report{
+		DryRun:        opts.DryRun,
+		Rows:          len(rows) + len(rowErrors),
+		NewSeasons:    []string{},
+		NewDecks:      []string{},
+		UnmappedRanks: map[string]int{},
+		Errors:        append([]RowError{}, rowErrors...),
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	names := newResolver(tx, opts.GameID)
+	existing, err := existingMatchCounts(tx, names, opts.UserID, opts.GameID)
+	if err != nil {
+		return nil, fmt.Errorf("讀取現有對局失敗: %w", err)
+	}
+
+	imp := &rowImporter{
+		tx:         tx,
+		opts:       opts,
+		report:     report,
+		seasonIDs:  map[string]string{},
+		newSeasons: map[string]*dateRange{},
+	}
+	seen := map[string]int{}
+	for _, row := range rows {
+		row, err := names.resolve(row)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", row.Line, err)
+		}
+		if !row.RankMapped {
+			report.UnmappedRanks[row.Rank]++
+		}
+		key := row.key()
+		seen[key]++
+		if seen[key] <= existing[key] {
+			report.Duplicates++
+			imp.preview(row, StatusDuplicate)
+			continue
+		}
+
+		if err := imp.importRow(row); err != nil {
+			report.Errors = append(report.Errors, RowError{Line: row.Line, Reason: err.Error(), Raw: row.Raw})
+			continue
+		}
+		report.Imported++
+		imp.preview(row, StatusNew)
+	}
+
+	if err := imp.fillSeasonDates(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+	report.Failed = len(report.Errors)
+
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// dateRange 新賽季中對局的日期範圍（用於填入 start_date/end_date）
+type dateRange struct {
+	from, to string
+}
+
+type rowImporter struct {
+	tx         *database.Tx
+	opts       Options
+	report     *Report
+	seasonIDs  map[string]string
+	newSeasons map[string]*dateRange
+}
+
+// preview 記錄單筆解析結果（Options.Preview 時）
+func (imp *rowImporter) preview(row Row, status string) {
+	if !imp.opts.Preview {
+		return
+	}
+	imp.report.Preview = append(imp.report.Preview, PreviewRow{
+		Line:       row.Line,
+		Status:     status,
+		Date:       row.Date,
+		SeasonCode: row.SeasonCode,
+		Mode:       row.Mode,
+		Rank:       row.Rank,
+		RankMapped: row.RankMapped,
+		MyDeck:     PreviewDeck{Main: row.MyMain, Sub: row.MySub},
+		OppDeck:    PreviewDeck{Main: row.OppMain, Sub: row.OppSub},
+		PlayOrder:  row.PlayOrder,
+		Result:     row.Result,
+		Note:       row.Note,
+
+		OutcomeReason: row.OutcomeReason,
+		CoinToss:      row.CoinToss,
+		Choice:        row.Choice,
+		Tags:          row.Tags,
+		Games:         row.Games,
+	})
+}
+
+// importRow 以 savepoint 包住單筆寫入，失敗時只還原這一筆（PostgreSQL 交易中的錯誤會讓整個交易失效）
+func (imp *rowImporter) importRow(row Row) error {
+	if _, err := imp.tx.Exec("SAVEPOINT import_row"); err != nil {
+		return err
+	}
+	seasonCount, deckCount := len(imp.report.NewSeasons), len(imp.report.NewDecks)
+	if err := imp.insertMatch(row); err != nil {
+		_, _ = imp.tx.Exec("ROLLBACK TO SAVEPOINT import_row")
+		_, _ = imp.tx.Exec("RELEASE SAVEPOINT import_row")
+		// 這一筆建立的賽季/牌組也一併還原
+		for _, code := range imp.report.NewSeasons[seasonCount:] {
+			delete(imp.seasonIDs, code)
+			delete(imp.newSeasons, code)
+		}
+		imp.report.NewSeasons = imp.report.NewSeasons[:seasonCount]
+		imp.report.NewDecks = imp.report.NewDecks[:deckCount]
+		return err
+	}
+	_, err := imp.tx.Exec("RELEASE SAVEPOINT import_row")
+	return err
+}
+
+func (imp *rowImporter) insertMatch(row Row) error {
+	seasonID, err := imp.seasonID(row)
+	if err != nil {
+		return fmt.Errorf("處理賽季失敗: %w", err)
+	}
+	myDeckID, err := imp.deckID(row.MyMain, row.MySub)
+	if err != nil {
+		return fmt.Errorf("處理我方牌組失敗: %w", err)
+	}
+	oppDeckID, err := imp.deckID(row.OppMain, row.OppSub)
+	if err != nil {
+		return fmt.Errorf("處理對手牌組失敗: %w", err)
+	}
+
+	// 對應到遊戲的階梯；與 POST /matches 相同，有階梯但無法對應的階級（已列在 UnmappedRanks）不能匯入
+	rank, rankID := row.Rank, (*string)(nil)
+	r, err := store.ResolveRank(imp.tx, imp.opts.GameID, row.Mode, row.Rank)
+	if errors.Is(err, store.ErrRankNotFound) {
+		return fmt.Errorf("找不到階級：%s", row.Rank)
+	}
+	if err != nil {
+		return fmt.Errorf("處理階級失敗: %w", err)
+	}
+	if r != nil {
+		rank, rankID = r.Name, &r.ID
+	}
+
+	matchID := uuid.New().String()
+	_, err = imp.tx.Exec(`
+		INSERT INTO matches (
+			id, user_id, game_id, season_id, date, mode, rank, rank_id,
+			my_deck_id, opp_deck_id, play_order, result, outcome_reason, coin_toss, toss_choice, note,
+			created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		matchID, imp.opts.UserID, imp.opts.GameID, seasonID, row.Date, row.Mode, rank, rankID,
+		myDeckID, oppDeckID, row.PlayOrder, row.Result, row.OutcomeReason, row.CoinToss, row.Choice, row.Note,
+		time.Now(), time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("新增對局失敗: %w", err)
+	}
+
+	for i, g := range row.Games {
+		_, err := imp.tx.Exec(`
+			INSERT INTO match_games (id, match_id, game_no, play_order, result, side_in, side_out, note)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, uuid.New().String(), matchID, i+1, g.PlayOrder, g.Result, g.SideIn, g.SideOut, g.Note)
+		if err != nil {
+			return fmt.Errorf("新增第 %d 局失敗: %w", i+1, err)
+		}
+	}
+	if err := store.SetMatchTags(imp.tx, imp.opts.UserID, matchID, row.Tags); err != nil {
+		return fmt.Errorf("處理標籤失敗: %w", err)
+	}
+	if imp.opts.AfterInsert != nil {
+		return imp.opts.AfterInsert(imp.tx, matchID)
+	}
+	return nil
+}
+
+// seasonID 取得或建立賽季，並記錄新賽季的日期範圍
+func (imp *rowImporter) seasonID(row Row) (string, error) {
+	if r, ok := imp.newSeasons[row.SeasonCode]; ok {
+		if row.Date < r.from {
+			r.from = row.Date
+		}
+		if row.Date > r.to {
+			r.to = row.Date
+		}
+	}
+	if id, ok := imp.seasonIDs[row.SeasonCode]; ok {
+		return id, nil
+	}
+
+	id, err := store.FindSeasonID(imp.tx, imp.opts.GameID, row.SeasonCode)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		if id, err = store.GetOrCreateSeasonID(imp.tx, imp.opts.GameID, row.SeasonCode); err != nil {
+			return "", err
+		}
+		imp.newSeasons[row.SeasonCode] = &dateRange{from: row.Date, to: row.Date}
+		imp.report.NewSeasons = append(imp.report.NewSeasons, row.SeasonCode)
+	}
+	imp.seasonIDs[row.SeasonCode] = id
+	return id, nil
+}
+
+// deckID 取得或建立牌組，並記錄新牌組
+func (imp *rowImporter) deckID(main string, sub *string) (string, error) {
+	// 牌組別名轉為正式名稱，與網頁新增對局相同
+	main, sub, err := store.ResolveDeck(imp.tx, imp.opts.GameID, main, sub)
+	if err != nil {
+		return "", err
+	}
+	id, err := store.FindDeckID(imp.tx, imp.opts.GameID, main, sub)
+	if err != nil || id != "" {
+		return id, err
+	}
+	if id, err = store.FindOrCreateDeck(imp.tx, imp.opts.GameID, main, sub); err != nil {
+		return "", err
+	}
+	label := main
+	if sub != nil {
+		label += " / " + *sub
+	}
+	imp.report.NewDecks = append(imp.report.NewDecks, label)
+	return id, nil
+}
+
+// fillSeasonDates 新賽季沒有日期時，以匯入對局的最早/最晚日期填入
+func (imp *rowImporter) fillSeasonDates() error {
+	for code, r := range imp.newSeasons {
+		_, err := imp.tx.Exec(`
+			UPDATE seasons SET start_date = ?, end_date = ?
+			WHERE game_id = ? AND code = ? AND start_date IS NULL AND end_date IS NULL
+		`, r.from, r.to, imp.opts.GameID, code)
+		if err != nil {
+			return fmt.Errorf("更新賽季 %s 日期失敗: %w", code, err)
+		}
+	}
+	return nil
+}
+
+// existingMatchCounts 資料庫中各對局內容（見 Row.key）的筆數
+func existingMatchCounts(q database.Querier, names *resolver, userID, gameID string) (map[string]int, error) {
+	rows, err := q.Query(`
+		SELECT s.code, m.date, m.mode, m.rank,
+			my_deck.main, my_deck.sub, opp_deck.main, opp_deck.sub,
+			m.play_order, m.result, m.note
+		FROM matches m
+		JOIN seasons s ON m.season_id = s.id
+		JOIN decks my_deck ON m.my_deck_id = my_deck.id
+		JOIN decks opp_deck ON m.opp_deck_id = opp_deck.id
+		WHERE m.user_id = ? AND m.game_id = ?
+	`, userID, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []Row
+	for rows.Next() {
+		var row Row
+		var date string
+		var mySub, oppSub, note sql.NullString
+		if err := rows.Scan(
+			&row.SeasonCode, &date, &row.Mode, &row.Rank,
+			&row.MyMain, &mySub, &row.OppMain, &oppSub,
+			&row.PlayOrder, &row.Result, &note,
+		); err != nil {
+			return nil, err
+		}
+		// SQLite driver 會把 DATE 欄位轉成 RFC3339（2026-01-13T00:00:00Z）
+		if len(date) > len("2006-01-02") {
+			date = date[:len("2006-01-02")]
+		}
+		row.Date = date
+		row.MySub = NormalizeSub(mySub.String)
+		row.OppSub = NormalizeSub(oppSub.String)
+		if n := strings.TrimSpace(note.String); n != "" {
+			row.Note = &n
+		}
+		found = append(found, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// 別名在對局建立之後才新增時，資料庫中可能還是舊的名稱
+	counts := map[string]int{}
+	for _, row := range found {
+		row, err := names.resolve(row)
+		if err != nil {
+			return nil, err
+		}
+		counts[row.key()]++
+	}
+	return counts, nil
+}
+
+// resolver 將牌組別名、階級的不同寫法轉為正式名稱（與新增對局時相同，見 store.ResolveDeck、store.ResolveRank），
+// 比對重複前在 CSV 與資料庫兩邊都先轉換；結果會快取
+type resolver struct {
+	q      database.Querier
+	gameID string
+	decks  map[string]string
+	ranks  map[[2]string]resolvedRank // [模式, 階級] → 正式名稱、是否在階梯上
+}
+
+type resolvedRank struct {
+	name   string
+	mapped bool
+}
+
+func newResolver(q database.Querier, gameID string) *resolver {
+	return &resolver{q: q, gameID: gameID, decks: map[string]string{}, ranks: map[[2]string]resolvedRank{}}
+}
+
+// resolve 回傳牌組與階級轉為正式名稱後的資料列，RankMapped 改為階級是否在遊戲的階梯上
+func (r *resolver) resolve(row Row) (Row, error) {
+	var err error
+	if row.MyMain, err = r.deck(row.MyMain); err != nil {
+		return row, err
+	}
+	if row.OppMain, err = r.deck(row.OppMain); err != nil {
+		return row, err
+	}
+	for _, sub := range []**string{&row.MySub, &row.OppSub} {
+		if *sub == nil {
+			continue
+		}
+		name, err := r.deck(**sub)
+		if err != nil {
+			return row, err
+		}
+		*sub = NormalizeSub(name)
+	}
+	row.Rank, row.RankMapped, err = r.rank(row.Mode, row.Rank)
+	return row, err
+}
+
+func (r *resolver) deck(name string) (string, error) {
+	if v, ok := r.decks[name]; ok {
+		return v, nil
+	}
+	v, err := store.ResolveDeckName(r.q, r.gameID, name)
+	if err != nil {
+		return "", err
+	}
+	r.decks[name] = v
+	return v, nil
+}
+
+// rank 無法對應的階級沿用原始文字（資料庫中升級前留下的對局仍可比對重複），mapped 為 false
+func (r *resolver) rank(mode, name string) (string, bool, error) {
+	key := [2]string{mode, name}
+	if v, ok := r.ranks[key]; ok {
+		return v.name, v.mapped, nil
+	}
+	v := resolvedRank{name: name, mapped: true}
+	rank, err := store.ResolveRank(r.q, r.gameID, mode, name)
+	if errors.Is(err, store.ErrRankNotFound) {
+		v.mapped = false
+	} else if err != nil {
+		return "", false, err
+	}
+	if rank != nil {
+		v.name = rank.Name
+	}
+	r.ranks[key] = v
+	return v.name, v.mapped, nil
+}
+
+// key 比對重複用的鍵（小軸「無」與空白視為相同）
+func (r Row) key() string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return strings.Join([]string{
+		r.SeasonCode, r.Date, r.Mode, r.Rank,
+		r.MyMain, deref(NormalizeSub(deref(r.MySub))),
+		r.OppMain, deref(NormalizeSub(deref(r.OppSub))),
+		r.PlayOrder, r.Result, strings.TrimSpace(deref(r.Note)),
+	}, "\x1f")
+}
+
+// WriteErrorReport 將無法匯入的資料列寫成 CSV（line, reason, 原始欄位...）
+func WriteErrorReport(w io.Writer, errs []RowError) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"line", "reason", "raw"}); err != nil {
+		return err
+	}
+	for _, e := range errs {
+		record := append([]string{strconv.Itoa(e.Line), e.Reason}, e.Raw...)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
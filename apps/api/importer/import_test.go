@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+const (
+	testUserID = "user-test"
+	testGameID = testdb.GameID
+)
+
+// importCSV 以 DefaultMapping 的欄位順序匯入資料列（不含標題列）
+func importCSV(t *testing.T, db *database.DB, dryRun bool, lines ...string) *Report {
+	t.Helper()
+	rows, rowErrors, err := ParseCSV(strings.NewReader(testHeader + strings.Join(lines, "\n") + "\n"))
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	report, err := Import(db, rows, rowErrors, Options{UserID: testUserID, GameID: testGameID, DryRun: dryRun})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	return report
+}
+
+func countMatches(t *testing.T, db *database.DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM matches WHERE user_id = ?", testUserID).Scan(&n); err != nil {
+		t.Fatalf("count matches: %v", err)
+	}
+	return n
+}
+
+func TestRowKey(t *testing.T) {
+	none, spaced, empty := "無", "  卡手 ", "卡手"
+	a := Row{SeasonCode: "S40", Date: "2025-01-02", Mode: "Ranked", Rank: "金 IV", MyMain: "蛇眼", MySub: &none,
+		OppMain: "天盃龍", PlayOrder: "先攻", Result: "W", Note: &spaced, Line: 2}
+	b := a
+	b.MySub, b.Note, b.Line = nil, &empty, 9
+	if a.key() != b.key() {
+		t.Errorf("key() differs for rows that only differ in 小軸 無/empty, note spacing and line")
+	}
+
+	c := b
+	c.OppSub = &empty
+	if c.key() == b.key() {
+		t.Errorf("key() is equal for rows with different opponent 小軸")
+	}
+}
+
+func TestImportDedupe(t *testing.T) {
+	db := testdb.Open(t)
+	row := "金4,main,蛇眼,無,O,先,天盃龍,,卡手,2025/1/2,S40"
+	other := "金4,main,蛇眼,,X,後,天盃龍,,,2025/1/2,S40"
+
+	report := importCSV(t, db, true, row, other)
+	if report.Imported != 2 || countMatches(t, db) != 0 {
+		t.Fatalf("dry run: imported %d, %d matches in database; want 2 and 0", report.Imported, countMatches(t, db))
+	}
+	if len(report.NewSeasons) != 1 || report.NewSeasons[0] != "S40" {
+		t.Errorf("dry run new seasons = %v, want [S40]", report.NewSeasons)
+	}
+
+	report = importCSV(t, db, false, row, row, other)
+	if report.Imported != 3 || report.Duplicates != 0 || countMatches(t, db) != 3 {
+		t.Fatalf("first import: imported %d, duplicates %d; want 3 and 0", report.Imported, report.Duplicates)
+	}
+
+	// 相同的檔案再匯入一次：全部略過
+	report = importCSV(t, db, false, row, row, other)
+	if report.Imported != 0 || report.Duplicates != 3 {
+		t.Errorf("re-import: imported %d, duplicates %d; want 0 and 3", report.Imported, report.Duplicates)
+	}
+
+	// 以次數比對：檔案中第 3 筆相同的對局是新的；小軸寫成空白、備註前後空白與「無」視為相同
+	report = importCSV(t, db, false, row, "金4,main,蛇眼,, O ,先,天盃龍,, 卡手 ,2025-01-02,S40", row)
+	if report.Imported != 1 || report.Duplicates != 2 {
+		t.Errorf("third identical row: imported %d, duplicates %d; want 1 and 2", report.Imported, report.Duplicates)
+	}
+	if n := countMatches(t, db); n != 4 {
+		t.Errorf("%d matches in database, want 4", n)
+	}
+}
+
+func TestImportRowErrorsDoNotAbort(t *testing.T) {
+	db := testdb.Open(t)
+	report := importCSV(t, db, false,
+		"金4,main,蛇眼,,O,先,天盃龍,,,2025/1/2,S40",
+		"金4,main,蛇眼,,O,先,天盃龍,,,2025/13/2,S40",
+		"金4,main,蛇眼,,X,先,天盃龍,,,2025/1/3,S40",
+	)
+	if report.Rows != 3 || report.Imported != 2 || report.Failed != 1 || report.Errors[0].Line != 3 {
+		t.Errorf("report = %+v; want 2 imported and line 3 failed", report)
+	}
+}
+
+func TestImportDedupeResolvesAliases(t *testing.T) {
+	db := testdb.Open(t)
+
+	// 別名建立之前匯入的對局仍是舊的名稱
+	report := importCSV(t, db, false, "金4,main,スネークアイ,,O,先,天盃龍,,,2025/1/2,S40")
+	if report.Imported != 1 {
+		t.Fatalf("imported %d, want 1", report.Imported)
+	}
+	_, err := db.Exec("INSERT INTO deck_aliases (id, game_id, alias, template_id) VALUES ('alias-1', ?, 'スネークアイ', 'tpl-main-001')", testGameID)
+	if err != nil {
+		t.Fatalf("insert alias: %v", err)
+	}
+
+	// 資料庫與檔案兩邊都先轉為正式名稱（蛇眼）再比對；階級的不同寫法也視為相同
+	report = importCSV(t, db, false,
+		"金 IV,main,蛇眼,,O,先,天盃龍,,,2025/1/2,S40",
+		"金4,main,スネークアイ,,O,先,天盃龍,,,2025/1/2,S40",
+	)
+	if report.Imported != 1 || report.Duplicates != 1 {
+		t.Errorf("imported %d, duplicates %d; want 1 and 1", report.Imported, report.Duplicates)
+	}
+
+	// 新匯入的對局使用模板名稱
+	var main string
+	err = db.QueryRow(`
+		SELECT d.main FROM matches m JOIN decks d ON d.id = m.my_deck_id
+		WHERE m.user_id = ? ORDER BY m.created_at DESC LIMIT 1
+	`, testUserID).Scan(&main)
+	if err != nil || main != "蛇眼" {
+		t.Errorf("imported deck = %q, %v; want 蛇眼", main, err)
+	}
+	if len(report.NewDecks) != 1 || report.NewDecks[0] != "蛇眼" {
+		t.Errorf("new decks = %v, want [蛇眼]", report.NewDecks)
+	}
+}
+
+func TestImportRejectsUnmappedRanks(t *testing.T) {
+	db := testdb.Open(t)
+
+	// 與 POST /matches 相同：有階梯但無法對應的階級不能匯入；沒有階梯的模式沿用原始文字
+	report := importCSV(t, db, false,
+		"金9,main,蛇眼,,O,先,天盃龍,,,2025/1/2,S40",
+		"金IV,main,蛇眼,,X,先,天盃龍,,,2025/1/2,S40",
+		"Rating,main,蛇眼,,O,後,天盃龍,,,2025/1/3,S40",
+	)
+	if report.Imported != 2 || report.Failed != 1 || report.Errors[0].Line != 2 || !strings.Contains(report.Errors[0].Reason, "找不到階級：金9") {
+		t.Fatalf("report = %+v; want line 2 rejected for its rank", report)
+	}
+	if len(report.UnmappedRanks) != 1 || report.UnmappedRanks["金9"] != 1 {
+		t.Errorf("unmapped ranks = %v, want only 金9", report.UnmappedRanks)
+	}
+	var unmapped int
+	if err := db.QueryRow("SELECT COUNT(*) FROM matches WHERE user_id = ? AND mode = 'Ranked' AND rank_id IS NULL", testUserID).Scan(&unmapped); err != nil || unmapped != 0 {
+		t.Errorf("%d ranked matches without rank_id, %v; want 0", unmapped, err)
+	}
+
+	// 升級前留下、階級無法對應的對局仍可比對為重複
+	testdb.MustExec(t, db, `
+		INSERT INTO matches (id, user_id, game_id, season_id, date, mode, rank, my_deck_id, opp_deck_id, play_order, result)
+		SELECT 'legacy', user_id, game_id, season_id, '2025-01-04', mode, '金9', my_deck_id, opp_deck_id, play_order, result
+		FROM matches WHERE user_id = ? AND rank = '金 IV'
+	`, testUserID)
+	report = importCSV(t, db, false, "金9,main,蛇眼,,X,先,天盃龍,,,2025/1/4,S40")
+	if report.Duplicates != 1 || report.Failed != 0 {
+		t.Errorf("legacy match: duplicates %d, failed %d; want 1 and 0", report.Duplicates, report.Failed)
+	}
+}
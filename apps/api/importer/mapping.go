@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 可對應的欄位名稱
+const (
+	FieldRank      = "rank"
+	FieldAccount   = "account"
+	FieldMyMain    = "myMain"
+	FieldMySub     = "mySub"
+	FieldResult    = "result"
+	FieldPlayOrder = "playOrder"
+	FieldOppMain   = "oppMain"
+	FieldOppSub    = "oppSub"
+	FieldNote      = "note"
+	FieldDate      = "date"
+	FieldSeason    = "season"
+	FieldMode      = "mode"
+	FieldOutcome   = "outcomeReason"
+	FieldCoinToss  = "coinToss"
+	FieldChoice    = "choice"
+	FieldTags      = "tags"
+	FieldGames     = "games"
+)
+
+// Fields 所有可對應的欄位（依 DefaultMapping 的欄位順序）
+var Fields = []string{
+	FieldRank, FieldAccount, FieldMyMain, FieldMySub, FieldResult, FieldPlayOrder,
+	FieldOppMain, FieldOppSub, FieldNote, FieldDate, FieldSeason, FieldMode,
+	FieldOutcome, FieldCoinToss, FieldChoice, FieldTags, FieldGames,
+}
+
+// requiredFields 必須對應到某一欄的欄位
+var requiredFields = []string{FieldMyMain, FieldOppMain, FieldResult, FieldPlayOrder, FieldDate, FieldSeason}
+
+// Mapping 欄位 → 資料列中的欄位索引（0 起算）
+type Mapping map[string]int
+
+// DefaultMapping cmd/import 使用的固定欄位順序：
+// Rank, Account, 本家(我方), 小軸(我方), 勝負, 先後攻, 本家(敵方), 小軸(敵方), 備註, Date, Season,
+// 以及可選的 Mode, OutcomeReason, CoinToss, Choice, Tags, Games（GET /exports/matches 匯出的欄位）
+func DefaultMapping() Mapping {
+	m := Mapping{}
+	for i, f := range Fields {
+		m[f] = i
+	}
+	return m
+}
+
+// minColumns 資料列至少要有的欄位數（涵蓋所有必要欄位）
+func (m Mapping) minColumns() int {
+	n := 0
+	for _, f := range requiredFields {
+		if idx := m[f]; idx+1 > n {
+			n = idx + 1
+		}
+	}
+	return n
+}
+
+// ColumnRef 欄位對應設定：可以是欄位索引（0 起算）或標題列中的欄位名稱
+type ColumnRef struct {
+	Index int
+	Name  string
+}
+
+// UnmarshalJSON 接受數字（索引）或字串（標題名稱）
+func (c *ColumnRef) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*c = ColumnRef{Index: -1, Name: name}
+		return nil
+	}
+	var idx int
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return fmt.Errorf("欄位對應必須是索引或標題名稱")
+	}
+	*c = ColumnRef{Index: idx}
+	return nil
+}
+
+// ColumnMapping 使用者指定的欄位對應（e.g. {"date": "日期", "result": 4}）
+type ColumnMapping map[string]ColumnRef
+
+// Resolve 依標題列轉換為 Mapping；header 為 nil 表示沒有標題列（只能使用索引）
+func (cm ColumnMapping) Resolve(header []string) (Mapping, error) {
+	known := map[string]bool{}
+	for _, f := range Fields {
+		known[f] = true
+	}
+
+	m := Mapping{}
+	for field, ref := range cm {
+		if !known[field] {
+			return nil, fmt.Errorf("未知的欄位: %s（可用欄位: %s）", field, strings.Join(Fields, ", "))
+		}
+		if ref.Name == "" {
+			if ref.Index < 0 {
+				return nil, fmt.Errorf("%s: 欄位索引不可為負數", field)
+			}
+			m[field] = ref.Index
+			continue
+		}
+		if header == nil {
+			return nil, fmt.Errorf("%s: 沒有標題列時只能使用欄位索引", field)
+		}
+		idx := -1
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(ref.Name)) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("%s: 標題列中找不到欄位 %q", field, ref.Name)
+		}
+		m[field] = idx
+	}
+
+	missing := []string{}
+	for _, f := range requiredFields {
+		if _, ok := m[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("缺少必要欄位對應: %s", strings.Join(missing, ", "))
+	}
+	return m, nil
+}
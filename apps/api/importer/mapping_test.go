@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestColumnRefUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ColumnRef
+		wantErr bool
+	}{
+		{`3`, ColumnRef{Index: 3}, false},
+		{`"日期"`, ColumnRef{Index: -1, Name: "日期"}, false},
+		{`""`, ColumnRef{Index: -1}, false},
+		{`1.5`, ColumnRef{}, true},
+		{`true`, ColumnRef{}, true},
+	}
+	for _, tt := range tests {
+		var got ColumnRef
+		err := json.Unmarshal([]byte(tt.input), &got)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("Unmarshal(%s) = %+v, %v; want %+v (error: %v)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestColumnMappingResolve(t *testing.T) {
+	header := []string{"日期", " Result ", "先後攻", "我方", "對方", "賽季", "備註"}
+	required := func() ColumnMapping {
+		return ColumnMapping{
+			FieldDate:      {Index: -1, Name: "日期"},
+			FieldResult:    {Index: -1, Name: "result"},
+			FieldPlayOrder: {Index: 2},
+			FieldMyMain:    {Index: 3},
+			FieldOppMain:   {Index: 4},
+			FieldSeason:    {Index: -1, Name: "賽季"},
+		}
+	}
+
+	m, err := required().Resolve(header)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	want := Mapping{FieldDate: 0, FieldResult: 1, FieldPlayOrder: 2, FieldMyMain: 3, FieldOppMain: 4, FieldSeason: 5}
+	if len(m) != len(want) {
+		t.Errorf("Resolve = %v, want %v", m, want)
+	}
+	for f, idx := range want {
+		if m[f] != idx {
+			t.Errorf("Resolve()[%s] = %d, want %d", f, m[f], idx)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(ColumnMapping)
+		header []string
+		reason string
+	}{
+		{"unknown field", func(cm ColumnMapping) { cm["deck"] = ColumnRef{Index: 7} }, header, "未知的欄位"},
+		{"negative index", func(cm ColumnMapping) { cm[FieldNote] = ColumnRef{Index: -1} }, header, "不可為負數"},
+		{"name without header", func(cm ColumnMapping) {}, nil, "沒有標題列"},
+		{"name not in header", func(cm ColumnMapping) { cm[FieldNote] = ColumnRef{Index: -1, Name: "memo"} }, header, "找不到欄位"},
+		{"missing required", func(cm ColumnMapping) { delete(cm, FieldDate); delete(cm, FieldSeason) }, header, "date, season"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := required()
+			tt.modify(cm)
+			_, err := cm.Resolve(tt.header)
+			if err == nil || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("Resolve error = %v, want one containing %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestParseWithColumnMapping(t *testing.T) {
+	input := "日期,結果,先後,我方,對方,賽季,階級\n2025/1/2,W,後攻,閃刀姬,天盃龍,S40,金4\n"
+	columns := ColumnMapping{
+		FieldDate:      {Index: -1, Name: "日期"},
+		FieldResult:    {Index: -1, Name: "結果"},
+		FieldPlayOrder: {Index: 2},
+		FieldMyMain:    {Index: -1, Name: "我方"},
+		FieldOppMain:   {Index: -1, Name: "對方"},
+		FieldSeason:    {Index: -1, Name: "賽季"},
+		FieldRank:      {Index: -1, Name: "階級"},
+	}
+
+	rows, rowErrors, err := Parse(strings.NewReader(input), ParseOptions{Columns: columns})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(rows) != 1 || len(rowErrors) != 0 {
+		t.Fatalf("Parse returned %d rows and errors %+v, want 1 row", len(rows), rowErrors)
+	}
+	if r := rows[0]; r.Date != "2025-01-02" || r.Result != "W" || r.PlayOrder != "後攻" ||
+		r.MyMain != "閃刀姬" || r.OppMain != "天盃龍" || r.SeasonCode != "S40" || r.Rank != "金 IV" {
+		t.Errorf("row = %+v", r)
+	}
+
+	delete(columns, FieldSeason)
+	_, _, err = Parse(strings.NewReader(input), ParseOptions{Columns: columns})
+	var mappingErr *MappingError
+	if !errors.As(err, &mappingErr) {
+		t.Errorf("Parse without a season mapping = %v, want a MappingError", err)
+	}
+}
+
+func TestParseNoHeader(t *testing.T) {
+	input := "2025/1/2,W,後攻,閃刀姬,天盃龍,S40,Rating\n"
+	columns := ColumnMapping{
+		FieldDate: {Index: 0}, FieldResult: {Index: 1}, FieldPlayOrder: {Index: 2},
+		FieldMyMain: {Index: 3}, FieldOppMain: {Index: 4}, FieldSeason: {Index: 5}, FieldMode: {Index: 6},
+	}
+
+	rows, rowErrors, err := Parse(strings.NewReader(input), ParseOptions{NoHeader: true, Columns: columns})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(rows) != 1 || len(rowErrors) != 0 {
+		t.Fatalf("Parse returned %d rows and errors %+v, want 1 row", len(rows), rowErrors)
+	}
+	if r := rows[0]; r.Line != 1 || r.Mode != "Rating" || r.Rank != "—" {
+		t.Errorf("row = %+v", r)
+	}
+}
+
+func TestParseDetectsTSV(t *testing.T) {
+	// 從 Excel 貼上：tab 分隔、空白的小軸、沒有跳脫的引號
+	input := strings.ReplaceAll(testHeader, ",", "\t") +
+		"金4\tmain\t閃刀姬\t\tO\t先\t天盃龍\t\t他說 \"好\"\t2025/1/2\tS40\n"
+
+	rows, rowErrors, err := Parse(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(rows) != 1 || len(rowErrors) != 0 {
+		t.Fatalf("Parse returned %d rows and errors %+v, want 1 row", len(rows), rowErrors)
+	}
+	r := rows[0]
+	if r.MySub != nil || r.OppMain != "天盃龍" || r.SeasonCode != "S40" {
+		t.Errorf("row = %+v", r)
+	}
+	if r.Note == nil || *r.Note != `他說 "好"` {
+		t.Errorf("note = %v, want unescaped quotes", r.Note)
+	}
+}
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		data string
+		want rune
+	}{
+		{"a,b,c\n1,2,3", ','},
+		{"a\tb\tc\n1,2,3", '\t'},
+		{"a,b\n1\t2", ','},
+		{"", ','},
+	}
+	for _, tt := range tests {
+		if got := detectDelimiter([]byte(tt.data)); got != tt.want {
+			t.Errorf("detectDelimiter(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/harvc/duellog/apps/api/models"
+	"github.com/harvc/duellog/apps/api/store"
+)
+
+// RankMapping 試算表的階級寫法 → 系統格式
+var RankMapping = map[string]string{
+	"銅5": "銅 V", "銅4": "銅 IV", "銅3": "銅 III", "銅2": "銅 II", "銅1": "銅 I",
+	"銀5": "銀 V", "銀4": "銀 IV", "銀3": "銀 III", "銀2": "銀 II", "銀1": "銀 I",
+	"金5": "金 V", "金4": "金 IV", "金3": "金 III", "金2": "金 II", "金1": "金 I",
+	"白金5": "白金 V", "白金4": "白金 IV", "白金3": "白金 III", "白金2": "白金 II", "白金1": "白金 I",
+	"鑽5": "鑽石 V", "鑽4": "鑽石 IV", "鑽3": "鑽石 III", "鑽2": "鑽石 II", "鑽1": "鑽石 I",
+	"大師5": "大師 V", "大師4": "大師 IV", "大師3": "大師 III", "大師2": "大師 II", "大師1": "大師 I",
+}
+
+// knownRanks 已是系統格式的階級（RankMapping 的值）
+var knownRanks = func() map[string]bool {
+	m := map[string]bool{}
+	for _, v := range RankMapping {
+		m[v] = true
+	}
+	return m
+}()
+
+// NormalizeRank 轉換階級；ok 為 false 表示無法對應，回傳原始值
+func NormalizeRank(raw string) (rank string, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if v, found := RankMapping[raw]; found {
+		return v, true
+	}
+	if knownRanks[raw] || raw == "—" {
+		return raw, true
+	}
+	return raw, false
+}
+
+// NormalizeMode 轉換模式（Ranked / Rating / DC）
+func NormalizeMode(raw string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "", "RANKED", "LADDER":
+		return "Ranked", nil
+	case "RATING":
+		return "Rating", nil
+	case "DC", "DUELIST CUP", "DUELISTCUP":
+		return "DC", nil
+	}
+	return "", fmt.Errorf("無法辨識的模式: %s", raw)
+}
+
+// NormalizeResult 轉換勝負（O/勝/W → W，X/敗/L → L，△/和/D → D）
+func NormalizeResult(raw string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "O", "勝", "W", "WIN":
+		return "W", nil
+	case "X", "敗", "L", "LOSE", "LOSS":
+		return "L", nil
+	case "△", "和", "平", "D", "DRAW":
+		return "D", nil
+	}
+	return "", fmt.Errorf("無法辨識的勝負: %s", raw)
+}
+
+// NormalizePlayOrder 轉換先後攻
+func NormalizePlayOrder(raw string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "先攻", "先", "FIRST", "1ST":
+		return "先攻", nil
+	case "後攻", "後", "SECOND", "2ND":
+		return "後攻", nil
+	}
+	return "", fmt.Errorf("無法辨識的先後攻: %s", raw)
+}
+
+// NormalizeDate 轉換日期（2025/12/31、2025-1-2 → YYYY-MM-DD）
+func NormalizeDate(raw string) (string, error) {
+	date := strings.ReplaceAll(strings.TrimSpace(raw), "/", "-")
+	for _, layout := range []string{"2006-01-02", "2006-1-2"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("日期格式錯誤: %s", raw)
+}
+
+// NormalizeSub 小軸：空白或「無」視為沒有小軸（與網頁新增時相同，存 NULL）
+func NormalizeSub(raw string) *string {
+	sub := strings.TrimSpace(raw)
+	if sub == "" || sub == "無" {
+		return nil
+	}
+	return &sub
+}
+
+// outcomeReasons 可用的勝負原因（與 POST /matches 相同）
+var outcomeReasons = []string{"normal", "opp_surrender", "my_surrender", "timeout", "disconnect"}
+
+// NormalizeOutcome 轉換勝負原因（空白為 normal）
+func NormalizeOutcome(raw string) (string, error) {
+	reason := strings.ToLower(strings.TrimSpace(raw))
+	if reason == "" {
+		return "normal", nil
+	}
+	for _, r := range outcomeReasons {
+		if reason == r {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("無法辨識的勝負原因: %s", raw)
+}
+
+// NormalizeCoinToss 轉換擲硬幣（猜中/won → won，猜錯/lost → lost）；空白表示沒有記錄
+func NormalizeCoinToss(raw string) (*string, error) {
+	var toss string
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "":
+		return nil, nil
+	case "won", "win", "猜中":
+		toss = "won"
+	case "lost", "lose", "猜錯":
+		toss = "lost"
+	default:
+		return nil, fmt.Errorf("無法辨識的擲硬幣: %s", raw)
+	}
+	return &toss, nil
+}
+
+// maxTagLength 標籤名稱的最大長度（與 API 相同）
+const maxTagLength = 30
+
+// NormalizeTags 以逗號分隔的標籤（正規化名稱並去除重複）
+func NormalizeTags(raw string) ([]string, error) {
+	var tags []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = store.NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if utf8.RuneCountInString(name) > maxTagLength {
+			return nil, fmt.Errorf("標籤不可超過 %d 字: %s", maxTagLength, name)
+		}
+		tags = append(tags, name)
+	}
+	return tags, nil
+}
+
+// FormatTags 標籤寫成 NormalizeTags 讀得回來的格式
+func FormatTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+// ParseGames 讀取三戰兩勝的各局（FormatGames 寫出的 JSON 陣列）；空白表示單局對局。
+// 與 POST /matches 相同：2~3 局，第 1 局不能換 side，勝負分出後不能再有下一局，未分出時必須打滿 3 局
+func ParseGames(raw string) ([]models.MatchGameForm, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	var games []models.MatchGameForm
+	if err := json.Unmarshal([]byte(raw), &games); err != nil {
+		return nil, fmt.Errorf("各局格式錯誤: %w", err)
+	}
+	if len(games) < 2 || len(games) > 3 {
+		return nil, fmt.Errorf("各局必須為 2~3 局（實際 %d 局）", len(games))
+	}
+
+	wins, losses := 0, 0
+	for i := range games {
+		g := &games[i]
+		if wins == 2 || losses == 2 {
+			return nil, fmt.Errorf("勝負已分出，不能有第 %d 局", i+1)
+		}
+		var err error
+		if g.PlayOrder, err = NormalizePlayOrder(g.PlayOrder); err != nil {
+			return nil, fmt.Errorf("第 %d 局: %w", i+1, err)
+		}
+		if g.Result, err = NormalizeResult(g.Result); err != nil {
+			return nil, fmt.Errorf("第 %d 局: %w", i+1, err)
+		}
+		g.SideIn, g.SideOut, g.Note = optional(g.SideIn), optional(g.SideOut), optional(g.Note)
+		if i == 0 && (g.SideIn != nil || g.SideOut != nil) {
+			return nil, fmt.Errorf("第 1 局為換 side 前，不能記錄換 side")
+		}
+		switch g.Result {
+		case "W":
+			wins++
+		case "L":
+			losses++
+		}
+	}
+	if wins < 2 && losses < 2 && len(games) < 3 {
+		return nil, fmt.Errorf("勝負未分出，需要第 3 局")
+	}
+	return games, nil
+}
+
+// FormatGames 各局寫成 ParseGames 讀得回來的 JSON 陣列；單局對局為空字串
+func FormatGames(games []models.MatchGame) (string, error) {
+	if len(games) == 0 {
+		return "", nil
+	}
+	type game struct {
+		PlayOrder string  `json:"playOrder"`
+		Result    string  `json:"result"`
+		SideIn    *string `json:"sideIn,omitempty"`
+		SideOut   *string `json:"sideOut,omitempty"`
+		Note      *string `json:"note,omitempty"`
+	}
+	out := make([]game, len(games))
+	for i, g := range games {
+		out[i] = game{PlayOrder: g.PlayOrder, Result: g.Result, SideIn: g.SideIn, SideOut: g.SideOut, Note: g.Note}
+	}
+	b, err := json.Marshal(out)
+	return string(b), err
+}
+
+// gamesResult 由各局推導的對局結果（勝局多為 W、敗局多為 L、相同為 D）與先後攻（第 1 局的）
+func gamesResult(games []models.MatchGameForm) (result, playOrder string) {
+	wins, losses := 0, 0
+	for _, g := range games {
+		switch g.Result {
+		case "W":
+			wins++
+		case "L":
+			losses++
+		}
+	}
+	switch {
+	case wins > losses:
+		result = "W"
+	case losses > wins:
+		result = "L"
+	default:
+		result = "D"
+	}
+	return result, games[0].PlayOrder
+}
+
+// optional 去除前後空白，空字串視為沒有值
+func optional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/models"
+)
+
+func TestNormalizeRank(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"金4", "金 IV", true},
+		{" 鑽1 ", "鑽石 I", true},
+		{"大師 V", "大師 V", true},
+		{"—", "—", true},
+		{"傳說", "傳說", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeRank(tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeRank(%q) = (%q, %v), want (%q, %v)", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeMode(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"", "Ranked", false},
+		{"ladder", "Ranked", false},
+		{"rating", "Rating", false},
+		{" Duelist Cup ", "DC", false},
+		{"casual", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeMode(tt.raw)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeMode(%q) = (%q, %v), want %q (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeResult(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"O", "W", false},
+		{"勝", "W", false},
+		{"win", "W", false},
+		{"X", "L", false},
+		{" loss ", "L", false},
+		{"△", "D", false},
+		{"和", "D", false},
+		{"", "", true},
+		{"?", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeResult(tt.raw)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeResult(%q) = (%q, %v), want %q (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizePlayOrder(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"先", "先攻", false},
+		{"First", "先攻", false},
+		{"後攻", "後攻", false},
+		{"2nd", "後攻", false},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePlayOrder(tt.raw)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("NormalizePlayOrder(%q) = (%q, %v), want %q (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeDate(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"2025/12/31", "2025-12-31", false},
+		{"2025-1-2", "2025-01-02", false},
+		{" 2025-01-02 ", "2025-01-02", false},
+		{"2025/13/01", "", true},
+		{"12/31/2025", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeDate(tt.raw)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeDate(%q) = (%q, %v), want %q (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeSub(t *testing.T) {
+	for _, raw := range []string{"", "  ", "無"} {
+		if got := NormalizeSub(raw); got != nil {
+			t.Errorf("NormalizeSub(%q) = %q, want nil", raw, *got)
+		}
+	}
+	if got := NormalizeSub(" 閃刀 "); got == nil || *got != "閃刀" {
+		t.Errorf("NormalizeSub(%q) = %v, want %q", " 閃刀 ", got, "閃刀")
+	}
+}
+
+func TestNormalizeOutcome(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"", "normal", false},
+		{" Opp_Surrender ", "opp_surrender", false},
+		{"timeout", "timeout", false},
+		{"rage quit", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeOutcome(tt.raw)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeOutcome(%q) = (%q, %v), want %q (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeCoinToss(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string // "" 表示 nil
+		wantErr bool
+	}{
+		{"", "", false},
+		{"猜中", "won", false},
+		{"WIN", "won", false},
+		{"lost", "lost", false},
+		{"猜錯", "lost", false},
+		{"maybe", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeCoinToss(tt.raw)
+		if (err != nil) != tt.wantErr || (got == nil) != (tt.want == "") || (got != nil && *got != tt.want) {
+			t.Errorf("NormalizeCoinToss(%q) = (%v, %v), want %q (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := NormalizeTags(" 卡手 ,失誤,,卡手 ")
+	if err != nil || FormatTags(got) != "卡手,失誤" {
+		t.Errorf("NormalizeTags = %q, %v; want [卡手 失誤]", got, err)
+	}
+	if got, err := NormalizeTags(""); err != nil || got != nil {
+		t.Errorf("NormalizeTags(empty) = %q, %v; want nil", got, err)
+	}
+	if _, err := NormalizeTags(strings.Repeat("字", maxTagLength+1)); err == nil {
+		t.Error("NormalizeTags accepted a tag over the length limit")
+	}
+}
+
+func TestParseGames(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		games   int
+		wantErr string
+	}{
+		{"single game match", "", 0, ""},
+		{"two games", `[{"playOrder":"先","result":"O"},{"playOrder":"後攻","result":"W","sideIn":" 增殖的G "}]`, 2, ""},
+		{"three games", `[{"playOrder":"先攻","result":"W"},{"playOrder":"後攻","result":"L"},{"playOrder":"先攻","result":"D"}]`, 3, ""},
+		{"not json", `先攻 W`, 0, "各局格式錯誤"},
+		{"one game", `[{"playOrder":"先攻","result":"W"}]`, 0, "2~3 局"},
+		{"undecided", `[{"playOrder":"先攻","result":"W"},{"playOrder":"後攻","result":"L"}]`, 0, "需要第 3 局"},
+		{"after decided", `[{"playOrder":"先攻","result":"W"},{"playOrder":"後攻","result":"W"},{"playOrder":"先攻","result":"W"}]`, 0, "勝負已分出"},
+		{"side in game 1", `[{"playOrder":"先攻","result":"W","sideOut":"x"},{"playOrder":"後攻","result":"W"}]`, 0, "第 1 局"},
+		{"bad result", `[{"playOrder":"先攻","result":"?"},{"playOrder":"後攻","result":"W"}]`, 0, "第 1 局"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, err := ParseGames(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseGames error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || len(games) != tt.games {
+				t.Fatalf("ParseGames = %d games, %v; want %d", len(games), err, tt.games)
+			}
+		})
+	}
+
+	games, _ := ParseGames(`[{"playOrder":"先","result":"O","note":" "},{"playOrder":"後攻","result":"W","sideIn":" 增殖的G "}]`)
+	if games[0].PlayOrder != "先攻" || games[0].Result != "W" || games[0].Note != nil || games[1].SideIn == nil || *games[1].SideIn != "增殖的G" {
+		t.Errorf("ParseGames did not normalize the games: %+v", games)
+	}
+}
+
+func TestFormatGamesRoundTrip(t *testing.T) {
+	if s, err := FormatGames(nil); err != nil || s != "" {
+		t.Errorf("FormatGames(nil) = %q, %v; want empty", s, err)
+	}
+
+	sideIn, note := "增殖的G", "對手換了 side"
+	games := []models.MatchGame{
+		{GameNo: 1, PlayOrder: "後攻", Result: "L"},
+		{GameNo: 2, PlayOrder: "先攻", Result: "W", SideIn: &sideIn},
+		{GameNo: 3, PlayOrder: "後攻", Result: "W", Note: &note},
+	}
+	s, err := FormatGames(games)
+	if err != nil {
+		t.Fatalf("FormatGames: %v", err)
+	}
+	if strings.Contains(s, "gameNo") || strings.Contains(s, "sideOut") {
+		t.Errorf("FormatGames = %s, want no game numbers or empty fields", s)
+	}
+	parsed, err := ParseGames(s)
+	if err != nil || len(parsed) != len(games) {
+		t.Fatalf("ParseGames(FormatGames) = %+v, %v", parsed, err)
+	}
+	for i, g := range games {
+		p := parsed[i]
+		if p.PlayOrder != g.PlayOrder || p.Result != g.Result ||
+			!reflect.DeepEqual(p.SideIn, g.SideIn) || !reflect.DeepEqual(p.SideOut, g.SideOut) || !reflect.DeepEqual(p.Note, g.Note) {
+			t.Errorf("game %d round trip = %+v, want %+v", i+1, p, g)
+		}
+	}
+	if result, playOrder := gamesResult(parsed); result != "W" || playOrder != "後攻" {
+		t.Errorf("gamesResult = %s, %s; want W, 後攻", result, playOrder)
+	}
+}
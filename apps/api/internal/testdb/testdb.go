@@ -0,0 +1,58 @@
+// Package testdb 測試用的 SQLite 資料庫（套用所有 migrations），供各套件的 _test.go 使用
+package testdb
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// Master Duel：migrations 內建的牌組模板與階級都屬於這個遊戲（遊戲本身由 main.go 啟動時建立）
+const (
+	GameID  = "game-md"
+	GameKey = "master_duel"
+)
+
+// apiDir apps/api 的路徑（LoadMigrations 從目前的工作目錄尋找 migrations）
+func apiDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
+
+// Open 在暫存目錄建立套用所有 migrations 的 SQLite 資料庫，並建立 Master Duel；
+// 測試期間工作目錄會切換到 apps/api（t.Chdir，測試結束後還原）。
+// 使用檔案而不是 in-memory 資料庫：in-memory 資料庫每個連線各自獨立，不適合 database/sql 的連線池。
+func Open(t testing.TB) *database.DB {
+	t.Helper()
+	t.Chdir(apiDir())
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// migrations 的進度訊息對測試沒有用
+	w := log.Writer()
+	log.SetOutput(io.Discard)
+	err = database.EnsureSchema(db)
+	log.SetOutput(w)
+	if err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+
+	MustExec(t, db, "INSERT INTO games (id, key, name) VALUES (?, ?, 'Yu-Gi-Oh! Master Duel')", GameID, GameKey)
+	return db
+}
+
+// MustExec 執行 SQL，失敗時結束測試
+func MustExec(t testing.TB, db database.Querier, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
@@ -1,22 +1,25 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/harvc/duellog/apps/api/backup"
+	"github.com/harvc/duellog/apps/api/database"
 	"github.com/harvc/duellog/apps/api/handlers"
 	"github.com/joho/godotenv"
-	_ "github.com/mattn/go-sqlite3"
 )
 
-var db *sql.DB
+var db *database.DB
 
 func main() {
 	// 載入 .env 檔案
@@ -24,10 +27,11 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	// 初始化 SQLite 資料庫
+	// 初始化資料庫：有 DATABASE_URL 時使用 PostgreSQL，否則使用本機 SQLite 檔案
 	var err error
+	databaseURL := getEnv("DATABASE_URL", "")
 	dbPath := getEnv("DB_PATH", "./duellog.db")
-	db, err = sql.Open("sqlite3", dbPath)
+	db, err = database.Open(databaseURL, dbPath)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -37,10 +41,10 @@ func main() {
 	if err := db.Ping(); err != nil {
 		log.Fatal("Failed to ping database:", err)
 	}
-	log.Println("✓ Database connected successfully")
+	log.Printf("✓ Database connected successfully: %s", database.Describe(databaseURL, dbPath))
 
-	// Ensure schema is up-to-date for local SQLite files.
-	if err := ensureSchema(db); err != nil {
+	// Ensure schema is up-to-date.
+	if err := database.EnsureSchema(db); err != nil {
 		log.Fatal("Failed to ensure schema:", err)
 	}
 
@@ -57,6 +61,15 @@ func main() {
 		}
 	}
 
+	// 排程快照（僅 SQLite；BACKUP_INTERVAL=0 停用）
+	if db.Dialect == database.SQLite {
+		cfg := backupConfig(dbPath)
+		backup.Start(context.Background(), db, cfg)
+		if cfg.Interval > 0 {
+			log.Printf("✓ Backup every %s to %s (keep %d daily, %d weekly)", cfg.Interval, cfg.Dir, cfg.Retention.Daily, cfg.Retention.Weekly)
+		}
+	}
+
 	// 建立 Fiber app
 	app := fiber.New(fiber.Config{
 		AppName: "DuelLog API v1.0",
@@ -66,25 +79,113 @@ func main() {
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: getEnv("CORS_ORIGINS", "http://localhost:5173"),
-		AllowHeaders: "Origin, Content-Type, Accept",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
 	}))
 
 	// Routes
 	app.Get("/health", healthHandler)
 
+	// Auth API
+	// 預設（AUTH_REQUIRED=true）未登入的請求回傳 401；
+	// 本機單人使用時可設為 false，未帶 token 的請求沿用本機單人帳號（start-backend.bat 會這樣設定）。
+	// ADMIN_EMAILS（逗號分隔）為可以修改遊戲、賽季等共用資料的帳號。
+	authHandler := handlers.NewAuthHandler(db, sessionTTL(), !isTruthy(getEnv("AUTH_REQUIRED", "true")), strings.Split(getEnv("ADMIN_EMAILS", ""), ","))
+	app.Post("/auth/register", authHandler.Register)
+	app.Post("/auth/login", authHandler.Login)
+	app.Post("/auth/logout", authHandler.Logout)
+
+	// 以下路由都需要使用者身分
+	app.Use(authHandler.RequireUser)
+	app.Get("/auth/me", authHandler.Me)
+
+	// Games API（對局、統計、牌組模板的查詢都需要 gameKey）
+	gamesHandler := handlers.NewGamesHandler(db)
+	requireGame := gamesHandler.RequireGameKey
+	app.Get("/games", gamesHandler.GetGames)
+	requireAdmin := authHandler.RequireAdmin
+	app.Post("/games", requireAdmin, gamesHandler.CreateGame)
+	app.Patch("/games/:id", requireAdmin, gamesHandler.UpdateGame)
+	app.Delete("/games/:id", requireAdmin, gamesHandler.DeleteGame)
+
+	// Seasons API
+	seasonsHandler := handlers.NewSeasonsHandler(db)
+	app.Get("/seasons", requireGame, seasonsHandler.GetSeasons)
+	app.Post("/seasons", requireAdmin, seasonsHandler.CreateSeason)
+	app.Patch("/seasons/:id", requireAdmin, seasonsHandler.UpdateSeason)
+	app.Post("/seasons/:id/close", requireAdmin, seasonsHandler.CloseSeason)
+
 	// Matches API
 	matchesHandler := handlers.NewMatchesHandler(db)
-	app.Get("/matches", matchesHandler.GetMatches)
+	app.Get("/matches", requireGame, matchesHandler.GetMatches)
 	app.Post("/matches", matchesHandler.CreateMatch)
 	app.Patch("/matches/:id", matchesHandler.UpdateMatch)
 	app.Delete("/matches/:id", matchesHandler.DeleteMatch)
 
+	// Audit API（對局、牌組模板、賽季與遊戲的變更紀錄；對局與牌組模板可還原）
+	auditHandler := handlers.NewAuditHandler(db)
+	app.Get("/matches/:id/history", auditHandler.GetMatchHistory)
+	app.Get("/deck-templates/:id/history", auditHandler.GetDeckTemplateHistory)
+	app.Get("/seasons/:id/history", auditHandler.GetSeasonHistory)
+	app.Get("/games/:id/history", auditHandler.GetGameHistory)
+	app.Post("/audit/:id/revert", auditHandler.RevertAudit)
+
+	// Decks API（牌組改名/合併）
+	decksHandler := handlers.NewDecksHandler(db)
+	app.Post("/decks/rename", decksHandler.RenameDeck)
+	app.Post("/decks/merge", decksHandler.MergeDecks)
+
+	// Imports API（CSV/TSV 匯入：先預覽再寫入）
+	importsHandler := handlers.NewImportsHandler(db)
+	app.Post("/imports", importsHandler.PreviewImport)
+	app.Post("/imports/commit", importsHandler.CommitImport)
+
+	// Exports API
+	exportsHandler := handlers.NewExportsHandler(db)
+	app.Get("/exports/matches", requireGame, exportsHandler.ExportMatches)
+
+	// Archive API（封存檔備份/還原）
+	archiveHandler := handlers.NewArchiveHandler(db)
+	app.Get("/archive", archiveHandler.ExportArchive)
+	app.Post("/archive/restore", archiveHandler.RestoreArchive)
+
+	// Stats API
+	statsHandler := handlers.NewStatsHandler(db)
+	app.Get("/stats/summary", requireGame, statsHandler.GetSummary)
+	app.Get("/stats/daily", requireGame, statsHandler.GetDaily)
+	app.Get("/stats/opponents", requireGame, statsHandler.GetOpponents)
+	app.Get("/stats/matchups", requireGame, statsHandler.GetMatchups)
+	app.Get("/stats/rank-progression", requireGame, statsHandler.GetRankProgression)
+	app.Get("/stats/outcomes", requireGame, statsHandler.GetOutcomes)
+	app.Get("/stats/side", requireGame, statsHandler.GetSideStats)
+	app.Get("/stats/coin-toss", requireGame, statsHandler.GetCoinToss)
+	app.Get("/stats/tags", requireGame, statsHandler.GetTagStats)
+
+	// Ranks API（遊戲的天梯階級）
+	ranksHandler := handlers.NewRanksHandler(db)
+	app.Get("/ranks", requireGame, ranksHandler.GetRanks)
+
 	// Deck Templates API
-	app.Get("/deck-templates", func(c *fiber.Ctx) error { return handlers.GetDeckTemplates(c, db) })
+	app.Get("/deck-templates", requireGame, func(c *fiber.Ctx) error { return handlers.GetDeckTemplates(c, db) })
+	app.Get("/deck-templates/suggest", requireGame, func(c *fiber.Ctx) error { return handlers.SuggestDeckTemplates(c, db) })
 	app.Post("/deck-templates", func(c *fiber.Ctx) error { return handlers.CreateDeckTemplate(c, db) })
 	app.Patch("/deck-templates/:id", func(c *fiber.Ctx) error { return handlers.UpdateDeckTemplate(c, db) })
 	app.Delete("/deck-templates/:id", func(c *fiber.Ctx) error { return handlers.DeleteDeckTemplate(c, db) })
 
+	// Deck Aliases API（牌組別名：不同寫法對應到同一個模板）
+	deckAliasesHandler := handlers.NewDeckAliasesHandler(db)
+	app.Get("/deck-aliases", deckAliasesHandler.GetDeckAliases)
+	app.Post("/deck-aliases", deckAliasesHandler.CreateDeckAlias)
+	app.Patch("/deck-aliases/:id", deckAliasesHandler.UpdateDeckAlias)
+	app.Delete("/deck-aliases/:id", deckAliasesHandler.DeleteDeckAlias)
+
+	// Tags API（對局標籤，每個使用者各自的標籤）
+	tagsHandler := handlers.NewTagsHandler(db)
+	app.Get("/tags", tagsHandler.GetTags)
+	app.Post("/tags", tagsHandler.CreateTag)
+	app.Post("/tags/merge", tagsHandler.MergeTags)
+	app.Patch("/tags/:id", tagsHandler.RenameTag)
+	app.Delete("/tags/:id", tagsHandler.DeleteTag)
+
 	// 啟動伺服器
 	port := getEnv("PORT", "8080")
 	log.Printf("🚀 Server starting on port %s", port)
@@ -113,7 +214,45 @@ func shouldAutoSeed() bool {
 	return !(val == "0" || val == "false" || val == "no" || val == "off")
 }
 
-func needsSeed(db *sql.DB) (bool, error) {
+func isTruthy(val string) bool {
+	val = strings.TrimSpace(strings.ToLower(val))
+	return val == "1" || val == "true" || val == "yes" || val == "on"
+}
+
+func sessionTTL() time.Duration {
+	hours, err := strconv.Atoi(getEnv("SESSION_TTL_HOURS", "720"))
+	if err != nil || hours <= 0 {
+		hours = 720
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// backupConfig 讀取排程快照設定：
+// BACKUP_INTERVAL（預設 24h，0 或 off 停用）、BACKUP_DIR（預設資料庫旁的 backups/）、
+// BACKUP_KEEP_DAILY（預設 7）、BACKUP_KEEP_WEEKLY（預設 4）
+func backupConfig(dbPath string) backup.Config {
+	cfg := backup.Config{
+		Dir:       getEnv("BACKUP_DIR", filepath.Join(filepath.Dir(dbPath), "backups")),
+		Retention: backup.DefaultRetention,
+	}
+	if val := strings.TrimSpace(strings.ToLower(getEnv("BACKUP_INTERVAL", "24h"))); val != "0" && val != "off" && val != "false" {
+		interval, err := time.ParseDuration(val)
+		if err != nil {
+			log.Printf("Invalid BACKUP_INTERVAL %q, using 24h", val)
+			interval = 24 * time.Hour
+		}
+		cfg.Interval = interval
+	}
+	if n, err := strconv.Atoi(getEnv("BACKUP_KEEP_DAILY", "")); err == nil && n >= 0 {
+		cfg.Retention.Daily = n
+	}
+	if n, err := strconv.Atoi(getEnv("BACKUP_KEEP_WEEKLY", "")); err == nil && n >= 0 {
+		cfg.Retention.Weekly = n
+	}
+	return cfg
+}
+
+func needsSeed(db *database.DB) (bool, error) {
 	// Seed is considered needed if any of the essential base data is missing.
 	// We use these markers:
 	// - default user exists
@@ -135,7 +274,7 @@ func needsSeed(db *sql.DB) (bool, error) {
 	return userCount == 0 || gameCount == 0 || tplCount == 0, nil
 }
 
-func applySeed(db *sql.DB) error {
+func applySeed(db *database.DB) error {
 	seedSQL, err := readSeedFile("seed.sql")
 	if err != nil {
 		return err
@@ -165,155 +304,3 @@ func readSeedFile(filename string) (string, error) {
 	}
 	return "", fmt.Errorf("read seed %s: %w", filename, lastErr)
 }
-
-func ensureSchema(db *sql.DB) error {
-	// Ensure base tables exist for a fresh DB.
-	exists, err := tableExists(db, "matches")
-	if err != nil {
-		return err
-	}
-	if !exists {
-		log.Println("ℹ️  Database is empty (no tables yet); applying base schema migrations...")
-		if err := applyBaseMigrations(db); err != nil {
-			return fmt.Errorf("apply base migrations: %w", err)
-		}
-		log.Println("✓ Base schema is ready")
-	}
-
-	// Add matches.mode if missing (older DBs).
-	cols, err := getTableColumns(db, "matches")
-	if err != nil {
-		return err
-	}
-	if _, ok := cols["mode"]; !ok {
-		if _, err := db.Exec("ALTER TABLE matches ADD COLUMN mode TEXT NOT NULL DEFAULT 'Ranked' CHECK (mode IN ('Ranked','Rating','DC'))"); err != nil {
-			return fmt.Errorf("add matches.mode: %w", err)
-		}
-		if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_matches_mode ON matches(mode)"); err != nil {
-			return fmt.Errorf("create idx_matches_mode: %w", err)
-		}
-		log.Println("✓ Applied runtime migration: matches.mode")
-	}
-
-	return nil
-}
-
-func tableExists(db *sql.DB, table string) (bool, error) {
-	var name string
-	err := db.QueryRow(
-		"SELECT name FROM sqlite_master WHERE type='table' AND name = ? LIMIT 1",
-		table,
-	).Scan(&name)
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-	return strings.EqualFold(name, table), nil
-}
-
-func applyBaseMigrations(db *sql.DB) error {
-	// These migrations create the initial schema + deck_templates.
-	// We keep this lightweight so a new user can simply run `go run .`.
-	migrationFiles := []string{
-		"001_create_schema.sql",
-		"002_add_deck_theme.sql",
-		"003_add_match_mode.sql",
-	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	for _, f := range migrationFiles {
-		contents, err := readMigrationFile(f)
-		if err != nil {
-			return err
-		}
-		upSQL := extractGooseUpSQL(contents)
-		if strings.TrimSpace(upSQL) == "" {
-			continue
-		}
-		if _, err := tx.Exec(upSQL); err != nil {
-			return fmt.Errorf("exec %s: %w", f, err)
-		}
-	}
-
-	return tx.Commit()
-}
-
-func extractGooseUpSQL(fileContents string) string {
-	// If this is a Goose migration file, execute ONLY the Up section.
-	// Running the whole file would also execute Down statements, which can drop tables.
-	if !strings.Contains(fileContents, "+goose") {
-		return fileContents
-	}
-
-	lines := strings.Split(fileContents, "\n")
-	inUp := false
-	out := make([]string, 0, len(lines))
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "-- +goose Up") {
-			inUp = true
-			continue
-		}
-		if strings.HasPrefix(trimmed, "-- +goose Down") {
-			break
-		}
-		if !inUp {
-			continue
-		}
-		// Skip Goose directives; keep everything else (including SQL and comments).
-		if strings.HasPrefix(trimmed, "-- +goose") {
-			continue
-		}
-		out = append(out, line)
-	}
-	return strings.Join(out, "\n")
-}
-
-func readMigrationFile(filename string) (string, error) {
-	// Try common working directories:
-	// - when running from apps/api: ./migrations/<file>
-	// - when running from repo root: ./apps/api/migrations/<file>
-	candidates := []string{
-		filepath.Join("migrations", filename),
-		filepath.Join("apps", "api", "migrations", filename),
-	}
-	var lastErr error
-	for _, p := range candidates {
-		b, err := os.ReadFile(p)
-		if err == nil {
-			return string(b), nil
-		}
-		lastErr = err
-	}
-	return "", fmt.Errorf("read migration %s: %w", filename, lastErr)
-}
-
-func getTableColumns(db *sql.DB, table string) (map[string]struct{}, error) {
-	rows, err := db.Query("PRAGMA table_info(" + table + ")")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	cols := map[string]struct{}{}
-	for rows.Next() {
-		var cid int
-		var name string
-		var ctype string
-		var notnull int
-		var dflt sql.NullString
-		var pk int
-		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
-			return nil, err
-		}
-		cols[name] = struct{}{}
-	}
-	return cols, rows.Err()
-}
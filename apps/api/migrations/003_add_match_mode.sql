@@ -14,7 +14,8 @@ CREATE INDEX IF NOT EXISTS idx_matches_mode ON matches(mode);
 -- +goose Down
 -- +goose StatementBegin
 
--- SQLite can't DROP COLUMN easily; keep as no-op.
+-- SQLite 3.35+ supports DROP COLUMN (the index must be dropped first).
 DROP INDEX IF EXISTS idx_matches_mode;
+ALTER TABLE matches DROP COLUMN mode;
 
 -- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 第二款遊戲：Pokémon TCG Live（與 Master Duel 共用同一個實例，以 gameKey 區分）
+INSERT OR IGNORE INTO games (id, key, name) VALUES
+    ('game-ptcg', 'ptcg', 'Pokémon TCG Live');
+
+-- 預設牌組模板（主題分類沿用遊戲王的分類，PTCG 一律為「無」）
+INSERT OR IGNORE INTO deck_templates (id, game_id, main, theme, deck_type) VALUES
+    ('tpl-ptcg-001', 'game-ptcg', '噴火龍ex', '無', 'main'),
+    ('tpl-ptcg-002', 'game-ptcg', '沙奈朵ex', '無', 'main'),
+    ('tpl-ptcg-003', 'game-ptcg', '多龍巴魯托ex', '無', 'main'),
+    ('tpl-ptcg-004', 'game-ptcg', '密勒頓ex', '無', 'main'),
+    ('tpl-ptcg-005', 'game-ptcg', '古劍豹王ex', '無', 'main'),
+    ('tpl-ptcg-006', 'game-ptcg', '起源帕路奇亞VSTAR', '無', 'main'),
+    ('tpl-ptcg-007', 'game-ptcg', '騎拉帝納VSTAR', '無', 'main'),
+    ('tpl-ptcg-008', 'game-ptcg', '洛奇亞VSTAR', '無', 'main'),
+    ('tpl-ptcg-009', 'game-ptcg', '厄詭椪ex', '無', 'main'),
+    ('tpl-ptcg-010', 'game-ptcg', '猛雷鼓ex', '無', 'main'),
+    ('tpl-ptcg-011', 'game-ptcg', 'Unknown', '無', 'main'),
+    ('tpl-ptcg-sub-001', 'game-ptcg', '無', '無', 'sub');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM deck_aliases WHERE template_id IN (SELECT id FROM deck_templates WHERE id LIKE 'tpl-ptcg-%');
+DELETE FROM deck_templates WHERE id LIKE 'tpl-ptcg-%';
+-- 已經記錄過 PTCG 對局時保留遊戲資料
+DELETE FROM games WHERE id = 'game-ptcg'
+    AND NOT EXISTS (SELECT 1 FROM matches WHERE game_id = 'game-ptcg')
+    AND NOT EXISTS (SELECT 1 FROM seasons WHERE game_id = 'game-ptcg')
+    AND NOT EXISTS (SELECT 1 FROM decks WHERE game_id = 'game-ptcg')
+    AND NOT EXISTS (SELECT 1 FROM deck_templates WHERE game_id = 'game-ptcg');
+
+-- +goose StatementEnd
@@ -0,0 +1,105 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 對局結果細節：result 增加平手（D），outcome_reason 記錄勝負的原因
+-- SQLite 無法修改 CHECK 限制，以重建資料表的方式更新（欄位與索引維持不變）
+CREATE TABLE matches_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    game_id TEXT NOT NULL,
+    season_id TEXT NOT NULL,
+    date DATE NOT NULL,                 -- 對局日期 (ISO format: YYYY-MM-DD)
+    rank TEXT NOT NULL,                 -- 階級，e.g. "金 IV"（非天梯模式為 "—"）
+    my_deck_id TEXT NOT NULL,           -- 我的牌組
+    opp_deck_id TEXT NOT NULL,          -- 對手牌組
+    play_order TEXT NOT NULL,           -- "先攻" 或 "後攻"
+    result TEXT NOT NULL,               -- "W" (Win)、"L" (Loss) 或 "D" (Draw)
+    note TEXT,                          -- 備註（可選）
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    mode TEXT NOT NULL DEFAULT 'Ranked',
+    rank_id TEXT,                       -- 對應的階級（見 007_create_ranks）
+    outcome_reason TEXT NOT NULL DEFAULT 'normal', -- 勝負原因
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (game_id) REFERENCES games(id),
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (my_deck_id) REFERENCES decks(id),
+    FOREIGN KEY (opp_deck_id) REFERENCES decks(id),
+    CHECK (result IN ('W', 'L', 'D')),
+    CHECK (play_order IN ('先攻', '後攻')),
+    CHECK (mode IN ('Ranked', 'Rating', 'DC')),
+    CHECK (outcome_reason IN ('normal', 'opp_surrender', 'my_surrender', 'timeout', 'disconnect'))
+);
+
+INSERT INTO matches_new (
+    id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id,
+    play_order, result, note, created_at, updated_at, mode, rank_id
+)
+SELECT
+    id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id,
+    play_order, result, note, created_at, updated_at, mode, rank_id
+FROM matches;
+
+DROP TABLE matches;
+ALTER TABLE matches_new RENAME TO matches;
+
+CREATE INDEX idx_matches_user_id ON matches(user_id);
+CREATE INDEX idx_matches_season_id ON matches(season_id);
+CREATE INDEX idx_matches_date ON matches(date);
+CREATE INDEX idx_matches_my_deck_id ON matches(my_deck_id);
+CREATE INDEX idx_matches_mode ON matches(mode);
+CREATE INDEX idx_matches_rank_id ON matches(rank_id);
+CREATE INDEX idx_matches_outcome_reason ON matches(outcome_reason);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- 還原為只允許 W/L；有平手的對局時會違反 CHECK 而中止，需先手動處理
+CREATE TABLE matches_old (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    game_id TEXT NOT NULL,
+    season_id TEXT NOT NULL,
+    date DATE NOT NULL,
+    rank TEXT NOT NULL,
+    my_deck_id TEXT NOT NULL,
+    opp_deck_id TEXT NOT NULL,
+    play_order TEXT NOT NULL,
+    result TEXT NOT NULL,
+    note TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    mode TEXT NOT NULL DEFAULT 'Ranked'
+        CHECK (mode IN ('Ranked', 'Rating', 'DC')), -- 與 003 相同的欄位限制，003 的 Down 才能 DROP COLUMN
+    rank_id TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (game_id) REFERENCES games(id),
+    FOREIGN KEY (season_id) REFERENCES seasons(id),
+    FOREIGN KEY (my_deck_id) REFERENCES decks(id),
+    FOREIGN KEY (opp_deck_id) REFERENCES decks(id),
+    CHECK (result IN ('W', 'L')),
+    CHECK (play_order IN ('先攻', '後攻'))
+);
+
+INSERT INTO matches_old (
+    id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id,
+    play_order, result, note, created_at, updated_at, mode, rank_id
+)
+SELECT
+    id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id,
+    play_order, result, note, created_at, updated_at, mode, rank_id
+FROM matches;
+
+DROP TABLE matches;
+ALTER TABLE matches_old RENAME TO matches;
+
+CREATE INDEX idx_matches_user_id ON matches(user_id);
+CREATE INDEX idx_matches_season_id ON matches(season_id);
+CREATE INDEX idx_matches_date ON matches(date);
+CREATE INDEX idx_matches_my_deck_id ON matches(my_deck_id);
+CREATE INDEX idx_matches_mode ON matches(mode);
+CREATE INDEX idx_matches_rank_id ON matches(rank_id);
+
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 對局備註的全文索引（FTS5）需要以 -tags sqlite_fts5 編譯，不寫成 migration：
+-- matches_fts 與同步用的 triggers 只是 matches.note 的副本，由 EnsureSchema 依執行檔是否支援 FTS5
+-- 建立、重建或停用（見 database/fulltext.go），不論用哪個執行檔開啟，migrations 管理的 schema 都相同。
+-- 這個版本只為了與 PostgreSQL 的 migrations 對齊。
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- 只移除同步用的 triggers（不需要 FTS5），之後的 Down 改動 matches 時才不會寫入 matches_fts；
+-- matches_fts 保留（沒有 FTS5 時無法 DROP），以 FTS5 編譯的執行檔開啟時會整個重建
+DROP TRIGGER IF EXISTS matches_fts_ai;
+DROP TRIGGER IF EXISTS matches_fts_au;
+DROP TRIGGER IF EXISTS matches_fts_ad;
+
+-- +goose StatementEnd
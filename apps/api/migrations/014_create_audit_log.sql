@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 對局與牌組模板的變更紀錄：誰在什麼時候改了什麼，變更前後的完整內容（JSON）
+-- 新增時 before_data 為 NULL、刪除時 after_data 為 NULL；revert_of 為 POST /audit/:id/revert 還原的紀錄
+CREATE TABLE IF NOT EXISTS audit_log (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    entity TEXT NOT NULL CHECK (entity IN ('match', 'deck_template')),
+    entity_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    before_data TEXT,
+    after_data TEXT,
+    revert_of TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_audit_log_entity;
+DROP TABLE IF EXISTS audit_log;
+
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 變更紀錄也記錄賽季與遊戲的新增、修改、結束與刪除
+-- SQLite 無法修改 CHECK 限制，以重建資料表的方式更新（欄位與索引維持不變）
+CREATE TABLE audit_log_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    entity TEXT NOT NULL CHECK (entity IN ('match', 'deck_template', 'season', 'game')),
+    entity_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    before_data TEXT,
+    after_data TEXT,
+    revert_of TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+INSERT INTO audit_log_new (id, user_id, entity, entity_id, action, before_data, after_data, revert_of, created_at)
+SELECT id, user_id, entity, entity_id, action, before_data, after_data, revert_of, created_at
+FROM audit_log;
+
+DROP TABLE audit_log;
+ALTER TABLE audit_log_new RENAME TO audit_log;
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity, entity_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- 賽季與遊戲的紀錄無法放回舊的資料表，會一併刪除
+CREATE TABLE audit_log_old (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    entity TEXT NOT NULL CHECK (entity IN ('match', 'deck_template')),
+    entity_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    before_data TEXT,
+    after_data TEXT,
+    revert_of TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+INSERT INTO audit_log_old (id, user_id, entity, entity_id, action, before_data, after_data, revert_of, created_at)
+SELECT id, user_id, entity, entity_id, action, before_data, after_data, revert_of, created_at
+FROM audit_log
+WHERE entity IN ('match', 'deck_template');
+
+DROP TABLE audit_log;
+ALTER TABLE audit_log_old RENAME TO audit_log;
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity, entity_id, created_at);
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 牌組別名：把不同寫法（e.g. 天盃、日文名稱）對應到正式的牌組模板
+-- 新增/修改對局與匯入時，牌組名稱會先轉為模板名稱再查找
+CREATE TABLE IF NOT EXISTS deck_aliases (
+    id TEXT PRIMARY KEY,
+    game_id TEXT NOT NULL,
+    alias TEXT NOT NULL,
+    template_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (game_id) REFERENCES games(id),
+    FOREIGN KEY (template_id) REFERENCES deck_templates(id) ON DELETE CASCADE,
+    UNIQUE(game_id, alias)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deck_aliases_template_id ON deck_aliases(template_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_deck_aliases_template_id;
+DROP TABLE IF EXISTS deck_aliases;
+
+-- +goose StatementEnd
@@ -0,0 +1,81 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 階級：每個遊戲、每種模式各自的階梯（ordinal 越大越高，用於排序與範圍篩選）
+-- matches.rank 保留顯示用的文字（非天梯模式為 '—'），有對應階級時 rank_id 指向 ranks
+CREATE TABLE IF NOT EXISTS ranks (
+    id TEXT PRIMARY KEY,
+    game_id TEXT NOT NULL,
+    mode TEXT NOT NULL DEFAULT 'Ranked',
+    tier TEXT NOT NULL,                 -- 段位，e.g. "金"
+    division INTEGER,                   -- 階，e.g. 4（沒有分階的段位為 NULL）
+    name TEXT NOT NULL,                 -- 顯示名稱，e.g. "金 IV"
+    ordinal INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (game_id) REFERENCES games(id),
+    UNIQUE(game_id, mode, name),
+    UNIQUE(game_id, mode, ordinal)
+);
+
+-- Master Duel 天梯：銅 V（最低）～ 大師 I
+INSERT INTO ranks (id, game_id, mode, tier, division, name, ordinal) VALUES
+    ('rank-md-01', 'game-md', 'Ranked', '銅', 5, '銅 V', 1),
+    ('rank-md-02', 'game-md', 'Ranked', '銅', 4, '銅 IV', 2),
+    ('rank-md-03', 'game-md', 'Ranked', '銅', 3, '銅 III', 3),
+    ('rank-md-04', 'game-md', 'Ranked', '銅', 2, '銅 II', 4),
+    ('rank-md-05', 'game-md', 'Ranked', '銅', 1, '銅 I', 5),
+    ('rank-md-06', 'game-md', 'Ranked', '銀', 5, '銀 V', 6),
+    ('rank-md-07', 'game-md', 'Ranked', '銀', 4, '銀 IV', 7),
+    ('rank-md-08', 'game-md', 'Ranked', '銀', 3, '銀 III', 8),
+    ('rank-md-09', 'game-md', 'Ranked', '銀', 2, '銀 II', 9),
+    ('rank-md-10', 'game-md', 'Ranked', '銀', 1, '銀 I', 10),
+    ('rank-md-11', 'game-md', 'Ranked', '金', 5, '金 V', 11),
+    ('rank-md-12', 'game-md', 'Ranked', '金', 4, '金 IV', 12),
+    ('rank-md-13', 'game-md', 'Ranked', '金', 3, '金 III', 13),
+    ('rank-md-14', 'game-md', 'Ranked', '金', 2, '金 II', 14),
+    ('rank-md-15', 'game-md', 'Ranked', '金', 1, '金 I', 15),
+    ('rank-md-16', 'game-md', 'Ranked', '白金', 5, '白金 V', 16),
+    ('rank-md-17', 'game-md', 'Ranked', '白金', 4, '白金 IV', 17),
+    ('rank-md-18', 'game-md', 'Ranked', '白金', 3, '白金 III', 18),
+    ('rank-md-19', 'game-md', 'Ranked', '白金', 2, '白金 II', 19),
+    ('rank-md-20', 'game-md', 'Ranked', '白金', 1, '白金 I', 20),
+    ('rank-md-21', 'game-md', 'Ranked', '鑽石', 5, '鑽石 V', 21),
+    ('rank-md-22', 'game-md', 'Ranked', '鑽石', 4, '鑽石 IV', 22),
+    ('rank-md-23', 'game-md', 'Ranked', '鑽石', 3, '鑽石 III', 23),
+    ('rank-md-24', 'game-md', 'Ranked', '鑽石', 2, '鑽石 II', 24),
+    ('rank-md-25', 'game-md', 'Ranked', '鑽石', 1, '鑽石 I', 25),
+    ('rank-md-26', 'game-md', 'Ranked', '大師', 5, '大師 V', 26),
+    ('rank-md-27', 'game-md', 'Ranked', '大師', 4, '大師 IV', 27),
+    ('rank-md-28', 'game-md', 'Ranked', '大師', 3, '大師 III', 28),
+    ('rank-md-29', 'game-md', 'Ranked', '大師', 2, '大師 II', 29),
+    ('rank-md-30', 'game-md', 'Ranked', '大師', 1, '大師 I', 30)
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE matches ADD COLUMN IF NOT EXISTS rank_id TEXT REFERENCES ranks(id);
+
+CREATE INDEX IF NOT EXISTS idx_matches_rank_id ON matches(rank_id);
+
+-- 既有的階級文字對應到 ranks（忽略空白，接受 "金4"、"金IV"、"鑽 1" 等寫法），並統一為顯示名稱
+UPDATE matches SET rank_id = (
+    SELECT r.id FROM ranks r
+    WHERE r.game_id = matches.game_id AND r.mode = matches.mode
+      AND REPLACE(REPLACE(matches.rank, ' ', ''), '鑽石', '鑽') IN (
+          REPLACE(REPLACE(r.name, ' ', ''), '鑽石', '鑽'),
+          REPLACE(r.tier, '鑽石', '鑽') || r.division
+      )
+)
+WHERE rank_id IS NULL;
+
+UPDATE matches SET rank = (SELECT r.name FROM ranks r WHERE r.id = matches.rank_id)
+WHERE rank_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_matches_rank_id;
+ALTER TABLE matches DROP COLUMN IF EXISTS rank_id;
+DROP TABLE IF EXISTS ranks;
+
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 賽季結束時間：NULL 表示進行中；結束後不能再新增對局到該賽季
+ALTER TABLE seasons ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+
+-- 依日期推斷賽季時使用
+CREATE INDEX IF NOT EXISTS idx_seasons_dates ON seasons(game_id, start_date, end_date);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_seasons_dates;
+ALTER TABLE seasons DROP COLUMN IF EXISTS closed_at;
+
+-- +goose StatementEnd
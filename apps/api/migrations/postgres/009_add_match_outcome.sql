@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 對局結果細節：result 增加平手（D），outcome_reason 記錄勝負的原因
+ALTER TABLE matches DROP CONSTRAINT IF EXISTS matches_result_check;
+ALTER TABLE matches ADD CONSTRAINT matches_result_check CHECK (result IN ('W', 'L', 'D'));
+
+ALTER TABLE matches
+ADD COLUMN IF NOT EXISTS outcome_reason TEXT NOT NULL DEFAULT 'normal'
+CHECK (outcome_reason IN ('normal', 'opp_surrender', 'my_surrender', 'timeout', 'disconnect'));
+
+CREATE INDEX IF NOT EXISTS idx_matches_outcome_reason ON matches(outcome_reason);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- 有平手的對局時會違反 CHECK 而中止，需先手動處理
+DROP INDEX IF EXISTS idx_matches_outcome_reason;
+ALTER TABLE matches DROP COLUMN IF EXISTS outcome_reason;
+ALTER TABLE matches DROP CONSTRAINT IF EXISTS matches_result_check;
+ALTER TABLE matches ADD CONSTRAINT matches_result_check CHECK (result IN ('W', 'L'));
+
+-- +goose StatementEnd
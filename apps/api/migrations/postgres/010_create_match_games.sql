@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 三戰兩勝的各局記錄：每局的先後攻、勝負與換 side 的內容
+-- 有局數記錄的對局，matches.result / play_order 由各局推導（見 handlers/match_games.go）
+CREATE TABLE IF NOT EXISTS match_games (
+    id TEXT PRIMARY KEY,
+    match_id TEXT NOT NULL,
+    game_no INTEGER NOT NULL,           -- 第幾局（1~3），第 1 局為換 side 前
+    play_order TEXT NOT NULL,           -- "先攻" 或 "後攻"
+    result TEXT NOT NULL,               -- "W"、"L" 或 "D"
+    side_in TEXT,                       -- 換入的卡（自由文字）
+    side_out TEXT,                      -- 換出的卡（自由文字）
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (match_id) REFERENCES matches(id) ON DELETE CASCADE,
+    UNIQUE(match_id, game_no),
+    CHECK (game_no BETWEEN 1 AND 3),
+    CHECK (play_order IN ('先攻', '後攻')),
+    CHECK (result IN ('W', 'L', 'D'))
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS match_games;
+
+-- +goose StatementEnd
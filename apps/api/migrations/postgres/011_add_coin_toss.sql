@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 擲硬幣結果與猜中的一方選擇的先後攻（NULL 表示沒有記錄）
+-- coin_toss: "won" / "lost"；toss_choice: 猜中硬幣的一方選擇的 "先攻" / "後攻"
+ALTER TABLE matches ADD COLUMN IF NOT EXISTS coin_toss TEXT CHECK (coin_toss IN ('won', 'lost'));
+ALTER TABLE matches ADD COLUMN IF NOT EXISTS toss_choice TEXT CHECK (toss_choice IN ('先攻', '後攻'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE matches DROP COLUMN IF EXISTS toss_choice;
+ALTER TABLE matches DROP COLUMN IF EXISTS coin_toss;
+
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 對局標籤（e.g. "卡手"、"misplay"、"stream"）：每個使用者各自的標籤，名稱統一為小寫
+CREATE TABLE IF NOT EXISTS tags (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE(user_id, name)
+);
+
+-- 對局 ↔ 標籤（多對多）
+CREATE TABLE IF NOT EXISTS match_tags (
+    id TEXT PRIMARY KEY,
+    match_id TEXT NOT NULL,
+    tag_id TEXT NOT NULL,
+    FOREIGN KEY (match_id) REFERENCES matches(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+    UNIQUE(match_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_tags_tag_id ON match_tags(tag_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_match_tags_tag_id;
+DROP TABLE IF EXISTS match_tags;
+DROP TABLE IF EXISTS tags;
+
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 對局備註的全文索引（GET /matches?q=...）；'simple' 不做詞幹處理，中日文另以 ILIKE 比對
+CREATE INDEX IF NOT EXISTS idx_matches_note_fts ON matches USING GIN (to_tsvector('simple', COALESCE(note, '')));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_matches_note_fts;
+
+-- +goose StatementEnd
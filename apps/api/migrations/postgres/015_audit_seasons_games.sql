@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 變更紀錄也記錄賽季與遊戲的新增、修改、結束與刪除
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_entity_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_entity_check CHECK (entity IN ('match', 'deck_template', 'season', 'game'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- 賽季與遊戲的紀錄無法符合舊的 CHECK，會一併刪除
+DELETE FROM audit_log WHERE entity NOT IN ('match', 'deck_template');
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_entity_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_entity_check CHECK (entity IN ('match', 'deck_template'));
+
+-- +goose StatementEnd
@@ -4,37 +4,68 @@ import "time"
 
 // Match 對局記錄
 type Match struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"userId"`
-	GameID     string    `json:"gameId"`
-	SeasonID   string    `json:"seasonId"`
-	Date       string    `json:"date"`        // ISO format: YYYY-MM-DD
-	Mode       string    `json:"mode"`        // "Ranked" | "Rating" | "DC"
-	Rank       string    `json:"rank"`        // e.g. "金IV", "鑽石I"
-	MyDeckID   string    `json:"myDeckId"`    // 我的牌組 ID
-	OppDeckID  string    `json:"oppDeckId"`   // 對手牌組 ID
-	PlayOrder  string    `json:"playOrder"`   // "先攻" 或 "後攻"
-	Result     string    `json:"result"`      // "W" 或 "L"
-	Note       *string   `json:"note"`        // 備註（可選）
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
+	ID            string    `json:"id"`
+	UserID        string    `json:"userId"`
+	GameID        string    `json:"gameId"`
+	SeasonID      string    `json:"seasonId"`
+	Date          string    `json:"date"`          // ISO format: YYYY-MM-DD
+	Mode          string    `json:"mode"`          // "Ranked" | "Rating" | "DC"
+	Rank          string    `json:"rank"`          // e.g. "金 IV", "鑽石 I"（非天梯模式為 "—"）
+	RankID        *string   `json:"rankId"`        // 對應的階級（ranks.id）
+	MyDeckID      string    `json:"myDeckId"`      // 我的牌組 ID
+	OppDeckID     string    `json:"oppDeckId"`     // 對手牌組 ID
+	PlayOrder     string    `json:"playOrder"`     // "先攻" 或 "後攻"
+	Result        string    `json:"result"`        // "W"、"L" 或 "D"（平手）
+	OutcomeReason string    `json:"outcomeReason"` // "normal" | "opp_surrender" | "my_surrender" | "timeout" | "disconnect"
+	CoinToss      *string   `json:"coinToss"`      // 擲硬幣 "won" | "lost"（沒有記錄時為 null）
+	Choice        *string   `json:"choice"`        // 猜中硬幣的一方選擇的 "先攻" 或 "後攻"
+	Note          *string   `json:"note"`          // 備註（可選）
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
 }
 
 // MatchWithDetails 對局記錄（含完整資訊）
 // 用於 GET /matches，包含 deck 名稱等關聯資料
 type MatchWithDetails struct {
-	ID         string    `json:"id"`
-	Date       string    `json:"date"`
-	Mode       string    `json:"mode"`
-	Rank       string    `json:"rank"`
-	MyDeck     DeckInfo  `json:"myDeck"`      // 我的牌組詳細資訊
-	OppDeck    DeckInfo  `json:"oppDeck"`     // 對手牌組詳細資訊
-	PlayOrder  string    `json:"playOrder"`   // "先攻" 或 "後攻"
-	Result     string    `json:"result"`      // "W" 或 "L"
-	Note       *string   `json:"note"`
-	SeasonCode string    `json:"seasonCode"`  // e.g. "S48"
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
+	ID            string      `json:"id"`
+	Date          string      `json:"date"`
+	Mode          string      `json:"mode"`
+	Rank          string      `json:"rank"`
+	RankID        *string     `json:"rankId"`        // 對應的階級（遊戲沒有階梯或非天梯模式時為 null）
+	RankOrdinal   *int        `json:"rankOrdinal"`   // 階級在階梯中的順序，越大越高
+	MyDeck        DeckInfo    `json:"myDeck"`        // 我的牌組詳細資訊
+	OppDeck       DeckInfo    `json:"oppDeck"`       // 對手牌組詳細資訊
+	PlayOrder     string      `json:"playOrder"`     // "先攻" 或 "後攻"
+	Result        string      `json:"result"`        // "W"、"L" 或 "D"
+	OutcomeReason string      `json:"outcomeReason"` // 勝負原因（見 Match）
+	CoinToss      *string     `json:"coinToss"`      // 擲硬幣（見 Match）
+	Choice        *string     `json:"choice"`
+	Note          *string     `json:"note"`
+	SeasonCode    string      `json:"seasonCode"`        // e.g. "S48"
+	Games         []MatchGame `json:"games,omitempty"`   // 三戰兩勝的各局（單局對局省略）
+	Tags          []string    `json:"tags,omitempty"`    // 標籤（沒有標籤時省略）
+	Snippet       *string     `json:"snippet,omitempty"` // 備註搜尋 q 的符合片段（以 <mark></mark> 標示，沒有 q 時省略）
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
+
+// MatchGame 三戰兩勝中的一局
+type MatchGame struct {
+	GameNo    int     `json:"gameNo"`    // 第幾局（1~3），第 1 局為換 side 前
+	PlayOrder string  `json:"playOrder"` // "先攻" 或 "後攻"
+	Result    string  `json:"result"`    // "W"、"L" 或 "D"
+	SideIn    *string `json:"sideIn"`    // 換入的卡（自由文字）
+	SideOut   *string `json:"sideOut"`   // 換出的卡（自由文字）
+	Note      *string `json:"note"`
+}
+
+// MatchGameForm 一局的表單（用於新增/更新，局數依陣列順序）
+type MatchGameForm struct {
+	PlayOrder string  `json:"playOrder"`
+	Result    string  `json:"result"`
+	SideIn    *string `json:"sideIn"`
+	SideOut   *string `json:"sideOut"`
+	Note      *string `json:"note"`
 }
 
 // DeckInfo 牌組資訊
@@ -46,28 +77,39 @@ type DeckInfo struct {
 
 // CreateMatchRequest 新增對局的請求結構
 type CreateMatchRequest struct {
-	GameKey    string   `json:"gameKey"`    // e.g. "master_duel"
-	SeasonCode string   `json:"seasonCode"` // e.g. "S48"
-	Date       string   `json:"date"`       // ISO format: YYYY-MM-DD
-	Mode       string   `json:"mode"`       // "Ranked" | "Rating" | "DC" (default Ranked)
-	Rank       string   `json:"rank"`       // e.g. "金IV"
-	MyDeck     DeckForm `json:"myDeck"`
-	OppDeck    DeckForm `json:"oppDeck"`
-	PlayOrder  string   `json:"playOrder"` // "先攻" 或 "後攻"
-	Result     string   `json:"result"`    // "W" 或 "L"
-	Note       *string  `json:"note"`      // 備註（可選）
+	GameKey       string          `json:"gameKey"`    // e.g. "master_duel"
+	SeasonCode    string          `json:"seasonCode"` // e.g. "S48"（省略時依 date 推斷）
+	Date          string          `json:"date"`       // ISO format: YYYY-MM-DD
+	Mode          string          `json:"mode"`       // "Ranked" | "Rating" | "DC" (default Ranked)
+	Rank          string          `json:"rank"`       // e.g. "金 IV"（也接受 "金4"、"金IV"）
+	MyDeck        DeckForm        `json:"myDeck"`
+	OppDeck       DeckForm        `json:"oppDeck"`
+	PlayOrder     string          `json:"playOrder"`     // "先攻" 或 "後攻"（有 games 時可省略，為第 1 局的先後攻）
+	Result        string          `json:"result"`        // "W"、"L" 或 "D"（有 games 時可省略，由各局推導）
+	OutcomeReason string          `json:"outcomeReason"` // 勝負原因（預設 "normal"）
+	CoinToss      *string         `json:"coinToss"`      // 擲硬幣 "won" | "lost"（可選）
+	Choice        *string         `json:"choice"`        // 猜中的一方選擇的先後攻（可選，由 coinToss 與 playOrder 推導）
+	Note          *string         `json:"note"`          // 備註（可選）
+	Games         []MatchGameForm `json:"games"`         // 三戰兩勝的各局（可選，最多 3 局）
+	Tags          []string        `json:"tags"`          // 標籤名稱（可選，不存在的標籤會建立）
 }
 
 // UpdateMatchRequest 更新對局的請求結構
 type UpdateMatchRequest struct {
-	Date      *string   `json:"date"`
-	Mode      *string   `json:"mode"`
-	Rank      *string   `json:"rank"`
-	MyDeck    *DeckForm `json:"myDeck"`
-	OppDeck   *DeckForm `json:"oppDeck"`
-	PlayOrder *string   `json:"playOrder"`
-	Result    *string   `json:"result"`
-	Note      *string   `json:"note"`
+	SeasonCode    *string          `json:"seasonCode"`
+	Date          *string          `json:"date"`
+	Mode          *string          `json:"mode"`
+	Rank          *string          `json:"rank"`
+	MyDeck        *DeckForm        `json:"myDeck"`
+	OppDeck       *DeckForm        `json:"oppDeck"`
+	PlayOrder     *string          `json:"playOrder"`
+	Result        *string          `json:"result"`
+	OutcomeReason *string          `json:"outcomeReason"`
+	CoinToss      *string          `json:"coinToss"` // 空字串表示清除
+	Choice        *string          `json:"choice"`
+	Note          *string          `json:"note"`
+	Games         *[]MatchGameForm `json:"games"` // 取代所有局的記錄（空陣列表示改回單局）
+	Tags          *[]string        `json:"tags"`  // 取代所有標籤（空陣列表示清除）
 }
 
 // DeckForm 牌組表單（用於新增/更新）
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// ResolveDeckName 將輸入的牌組名稱轉為正式名稱：
+// 先經過 NormalizeDeckName，再依序比對模板名稱、別名，最後忽略大小寫比對（e.g. "spyral" → "Spyral"）；
+// 都沒有對應時回傳正規化後的名稱。
+func ResolveDeckName(q database.Querier, gameID, name string) (string, error) {
+	name = NormalizeDeckName(name)
+
+	var canonical string
+	err := q.QueryRow(`
+		SELECT main FROM (
+			SELECT main, 0 AS priority FROM deck_templates WHERE game_id = ? AND main = ?
+			UNION ALL
+			SELECT t.main, 1 AS priority FROM deck_aliases a
+			JOIN deck_templates t ON t.id = a.template_id
+			WHERE a.game_id = ? AND a.alias = ?
+		) r
+		ORDER BY priority
+		LIMIT 1
+	`, gameID, name, gameID, name).Scan(&canonical)
+	if err == nil {
+		return canonical, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	names, err := DeckNames(q, gameID)
+	if err != nil {
+		return "", err
+	}
+	folded := FoldDeckName(name)
+	for _, n := range names {
+		if FoldDeckName(n.label()) == folded {
+			return n.Name, nil
+		}
+	}
+	return name, nil
+}
+
+// ResolveDeck 將大軸、小軸轉為正式名稱（新增/修改對局與匯入前呼叫）；小軸為空白或「無」時為 nil
+func ResolveDeck(q database.Querier, gameID, main string, sub *string) (string, *string, error) {
+	main, err := ResolveDeckName(q, gameID, main)
+	if err != nil {
+		return "", nil, err
+	}
+	if sub == nil {
+		return main, nil, nil
+	}
+	resolved, err := ResolveDeckName(q, gameID, *sub)
+	if err != nil {
+		return "", nil, err
+	}
+	if resolved == "" || resolved == "無" {
+		return main, nil, nil
+	}
+	return main, &resolved, nil
+}
+
+// FindDeckNameConflict 找出遊戲中與 name 相同（FoldDeckName 後比對）的模板名稱或別名，沒有時回傳 nil；
+// excludeAliasID 為正在修改的別名，不列入比對
+func FindDeckNameConflict(q database.Querier, gameID, name, excludeAliasID string) (*DeckName, error) {
+	rows, err := q.Query(`
+		SELECT id, main, theme, deck_type, '' FROM deck_templates WHERE game_id = ?
+		UNION ALL
+		SELECT t.id, t.main, t.theme, t.deck_type, a.alias
+		FROM deck_aliases a JOIN deck_templates t ON t.id = a.template_id
+		WHERE a.game_id = ? AND a.id <> ?
+	`, gameID, gameID, excludeAliasID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folded := FoldDeckName(name)
+	for rows.Next() {
+		var n DeckName
+		if err := rows.Scan(&n.TemplateID, &n.Name, &n.Theme, &n.DeckType, &n.Alias); err != nil {
+			return nil, err
+		}
+		if FoldDeckName(n.label()) == folded {
+			return &n, nil
+		}
+	}
+	return nil, rows.Err()
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+const testGameID = "game-test"
+
+// openTestDB 測試資料庫，另外建立一個沒有預設模板的遊戲
+func openTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db := testdb.Open(t)
+	testdb.MustExec(t, db, "INSERT INTO games (id, key, name) VALUES (?, 'test', 'Test')", testGameID)
+	return db
+}
+
+// seedDeckNames 模板：天盃龍、Spyral、閃刀姬；別名：天杯龍 → 天盃龍、スパイラル → Spyral，
+// 以及另一個遊戲中的別名 天龍 → 天盃龍
+func seedDeckNames(t *testing.T, db *database.DB) {
+	t.Helper()
+	testdb.MustExec(t, db, `INSERT INTO deck_templates (id, game_id, main, theme, deck_type) VALUES
+		('tpl-a', ?, '天盃龍', '連結', 'main'),
+		('tpl-b', ?, 'Spyral', '連結', 'main'),
+		('tpl-c', ?, '閃刀姬', '連結', 'main')`, testGameID, testGameID, testGameID)
+	testdb.MustExec(t, db, `INSERT INTO deck_aliases (id, game_id, alias, template_id) VALUES
+		('alias-a', ?, '天杯龍', 'tpl-a'),
+		('alias-b', ?, 'スパイラル', 'tpl-b'),
+		('alias-other', 'game-other', '天龍', 'tpl-a')`, testGameID, testGameID)
+}
+
+func TestResolveDeckName(t *testing.T) {
+	db := openTestDB(t)
+	seedDeckNames(t, db)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"天盃龍", "天盃龍"},
+		{" 天杯龍 ", "天盃龍"},
+		{"スパイラル", "Spyral"},
+		{"spyral", "Spyral"},
+		{"ＳＰＹＲＡＬ", "Spyral"},
+		{"S p y r a l", "Spyral"},
+		{"天龍", "天龍"}, // 其他遊戲的別名不適用
+		{"  新  牌組 ", "新 牌組"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveDeckName(db, testGameID, tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveDeckName(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveDeckNamePrefersTemplates(t *testing.T) {
+	db := openTestDB(t)
+	seedDeckNames(t, db)
+	// 在加上衝突檢查之前建立的別名可能與其他模板同名：模板名稱優先
+	testdb.MustExec(t, db, "INSERT INTO deck_aliases (id, game_id, alias, template_id) VALUES ('alias-c', ?, '閃刀姬', 'tpl-a')", testGameID)
+
+	got, err := ResolveDeckName(db, testGameID, "閃刀姬")
+	if err != nil || got != "閃刀姬" {
+		t.Errorf("ResolveDeckName(閃刀姬) = %q, %v; want the template, not the alias target", got, err)
+	}
+}
+
+func TestResolveDeck(t *testing.T) {
+	db := openTestDB(t)
+	seedDeckNames(t, db)
+
+	none, alias := " 無 ", "スパイラル"
+	main, sub, err := ResolveDeck(db, testGameID, "天杯龍", &none)
+	if err != nil || main != "天盃龍" || sub != nil {
+		t.Errorf("ResolveDeck(天杯龍, 無) = %q, %v, %v; want 天盃龍 and no sub", main, sub, err)
+	}
+	main, sub, err = ResolveDeck(db, testGameID, "閃刀姬", &alias)
+	if err != nil || main != "閃刀姬" || sub == nil || *sub != "Spyral" {
+		t.Errorf("ResolveDeck(閃刀姬, スパイラル) = %q, %v, %v; want 閃刀姬 / Spyral", main, sub, err)
+	}
+}
+
+func TestFindDeckNameConflict(t *testing.T) {
+	db := openTestDB(t)
+	seedDeckNames(t, db)
+
+	tests := []struct {
+		name      string
+		input     string
+		exclude   string
+		wantName  string // "" 表示沒有衝突
+		wantAlias string
+	}{
+		{"template name", "閃刀姬", "", "閃刀姬", ""},
+		{"template name folded", "spy ral", "", "Spyral", ""},
+		{"other alias", "天杯龍", "", "天盃龍", "天杯龍"},
+		{"other alias full-width", "ｽﾊﾟｲﾗﾙ", "", "Spyral", "スパイラル"},
+		{"alias being edited", "天杯龍", "alias-a", "", ""},
+		{"other game", "天龍", "", "", ""},
+		{"new name", "烙印", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindDeckNameConflict(db, testGameID, tt.input, tt.exclude)
+			if err != nil {
+				t.Fatalf("FindDeckNameConflict: %v", err)
+			}
+			switch {
+			case tt.wantName == "" && got != nil:
+				t.Errorf("FindDeckNameConflict(%q) = %+v, want no conflict", tt.input, *got)
+			case tt.wantName != "" && (got == nil || got.Name != tt.wantName || got.Alias != tt.wantAlias):
+				t.Errorf("FindDeckNameConflict(%q) = %+v, want %s (alias %q)", tt.input, got, tt.wantName, tt.wantAlias)
+			}
+		})
+	}
+}
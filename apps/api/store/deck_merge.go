@@ -0,0 +1,347 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// ErrDeckNotFound 找不到要改名/合併的牌組名稱
+var ErrDeckNotFound = errors.New("找不到牌組")
+
+// DeckChange 一個牌組資料列的變更
+type DeckChange struct {
+	ID      string  `json:"id"`
+	Main    string  `json:"main"`
+	Sub     *string `json:"sub"`
+	NewMain string  `json:"newMain"`
+	NewSub  *string `json:"newSub"`
+	// Action "rename"：直接改名；"merge"：新名稱已存在，對局改指向 IntoID 後刪除此資料列；
+	// "repoint"：其他使用者的對局仍在使用此牌組，只將自己的對局改指向 IntoID（見 MergeDeckNames 的 userID）
+	Action  string `json:"action"`
+	IntoID  string `json:"intoId,omitempty"`
+	Matches int    `json:"matches"` // 使用此牌組（我方或敵方）的對局數（限定使用者時只計算該使用者的對局）
+}
+
+// TemplateChange 一個牌組模板的變更
+type TemplateChange struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	DeckType string `json:"deckType"`
+	// Action "rename"：直接改名；"merge"：新名稱的模板已存在（保留其主題），刪除此模板
+	Action string `json:"action"`
+	IntoID string `json:"intoId,omitempty"` // 合併到的模板（"merge"）
+	// AliasAdded 舊名稱已加為別名（限定使用者時：其他使用者的對局仍使用舊名稱，之後輸入舊名稱會轉為新名稱）
+	AliasAdded bool `json:"aliasAdded,omitempty"`
+}
+
+// DeckMergeSummary 改名/合併結果
+type DeckMergeSummary struct {
+	Sources         []string         `json:"sources"`
+	Target          string           `json:"target"`
+	Decks           []DeckChange     `json:"decks"`
+	Templates       []TemplateChange `json:"templates"`
+	AffectedMatches int              `json:"affectedMatches"` // 顯示名稱改變或改指向其他牌組的對局數（不重複計算）
+}
+
+// MergeDeckNames 將 sources 這些牌組名稱（大軸或小軸）改為 target
+// 新名稱已存在時不會違反 UNIQUE(game_id, main, sub)：對局改指向既有的牌組，再刪除重複的資料列；
+// 牌組模板同理（保留既有模板的主題）。請在交易中呼叫。
+//
+// userID 非空時只改該使用者的對局（API）：其他使用者也在用的牌組保留原本的資料列，
+// 只把自己的對局改指向新名稱的牌組。牌組模板是共用的，同樣改名或合併，
+// 並將舊名稱加為指向新模板的別名（其他使用者的對局仍使用舊名稱）。
+// userID 為空字串時改所有使用者的資料（cmd/rename-deck 等管理工具）。
+func MergeDeckNames(q database.Querier, gameID, userID string, sources []string, target string) (*DeckMergeSummary, error) {
+	summary := &DeckMergeSummary{Sources: sources, Target: target, Decks: []DeckChange{}, Templates: []TemplateChange{}}
+	renamed := map[string]bool{}
+	for _, s := range sources {
+		if s != target {
+			renamed[s] = true
+		}
+	}
+	rename := func(name *string) *string {
+		if name != nil && renamed[*name] {
+			return &target
+		}
+		return name
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sources)), ", ")
+	args := []interface{}{gameID}
+	for _, s := range sources {
+		args = append(args, s)
+	}
+
+	// 先讀完再更新（SQLite 交易中不能邊讀邊寫同一個連線）
+	decks, err := queryDeckChanges(q,
+		"SELECT id, main, sub FROM decks WHERE game_id = ? AND (main IN ("+placeholders+") OR sub IN ("+placeholders+")) ORDER BY id",
+		append(args, args[1:]...)...)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := queryTemplateChanges(q,
+		"SELECT id, main, deck_type FROM deck_templates WHERE game_id = ? AND main IN ("+placeholders+") ORDER BY deck_type, id",
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(decks) == 0 && len(templates) == 0 {
+		return nil, ErrDeckNotFound
+	}
+
+	// 限定使用者時，對局的條件都加上 user_id
+	userCond, userArgs := "", []interface{}{}
+	if userID != "" {
+		userCond, userArgs = " AND user_id = ?", []interface{}{userID}
+	}
+
+	if len(decks) > 0 {
+		ids := make([]interface{}, len(decks))
+		for i, d := range decks {
+			ids[i] = d.ID
+		}
+		in := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+		if err := q.QueryRow(
+			"SELECT COUNT(*) FROM matches WHERE (my_deck_id IN ("+in+") OR opp_deck_id IN ("+in+"))"+userCond,
+			append(append(ids, ids...), userArgs...)...,
+		).Scan(&summary.AffectedMatches); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, d := range decks {
+		d.NewMain = *rename(&d.Main)
+		d.NewSub = rename(d.Sub)
+		if err := q.QueryRow(
+			"SELECT COUNT(*) FROM matches WHERE (my_deck_id = ? OR opp_deck_id = ?)"+userCond,
+			append([]interface{}{d.ID, d.ID}, userArgs...)...,
+		).Scan(&d.Matches); err != nil {
+			return nil, err
+		}
+
+		shared := false
+		if userID != "" {
+			if d.Matches == 0 {
+				continue // 自己的對局沒有使用
+			}
+			var others int
+			if err := q.QueryRow(
+				"SELECT COUNT(*) FROM matches WHERE (my_deck_id = ? OR opp_deck_id = ?) AND user_id <> ?", d.ID, d.ID, userID,
+			).Scan(&others); err != nil {
+				return nil, err
+			}
+			shared = others > 0
+		}
+
+		existing, err := FindDeckID(q, gameID, d.NewMain, d.NewSub)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case shared:
+			if existing == "" {
+				if existing, err = FindOrCreateDeck(q, gameID, d.NewMain, d.NewSub); err != nil {
+					return nil, err
+				}
+			}
+			d.Action, d.IntoID = "repoint", existing
+			for _, col := range []string{"my_deck_id", "opp_deck_id"} {
+				if _, err := q.Exec(
+					"UPDATE matches SET "+col+" = ?, updated_at = CURRENT_TIMESTAMP WHERE "+col+" = ?"+userCond,
+					append([]interface{}{existing, d.ID}, userArgs...)...,
+				); err != nil {
+					return nil, err
+				}
+			}
+		case existing != "" && existing != d.ID:
+			d.Action, d.IntoID = "merge", existing
+			for _, col := range []string{"my_deck_id", "opp_deck_id"} {
+				if _, err := q.Exec("UPDATE matches SET "+col+" = ?, updated_at = CURRENT_TIMESTAMP WHERE "+col+" = ?", existing, d.ID); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := q.Exec("DELETE FROM decks WHERE id = ?", d.ID); err != nil {
+				return nil, err
+			}
+		default:
+			d.Action = "rename"
+			if _, err := q.Exec("UPDATE decks SET main = ?, sub = ? WHERE id = ?", d.NewMain, nullString(d.NewSub), d.ID); err != nil {
+				return nil, err
+			}
+		}
+		summary.Decks = append(summary.Decks, d)
+	}
+	if userID != "" && len(summary.Decks) == 0 {
+		return nil, ErrDeckNotFound // 自己的對局沒有使用這些名稱，不動共用的模板
+	}
+
+	for _, t := range templates {
+		var existing string
+		err := q.QueryRow(
+			"SELECT id FROM deck_templates WHERE game_id = ? AND main = ? AND deck_type = ?",
+			gameID, target, t.DeckType,
+		).Scan(&existing)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if existing != "" {
+			t.Action, t.IntoID = "merge", existing
+			// 指向被刪除模板的別名改指向保留的模板
+			if _, err = q.Exec("UPDATE deck_aliases SET template_id = ? WHERE template_id = ?", existing, t.ID); err != nil {
+				return nil, err
+			}
+			_, err = q.Exec("DELETE FROM deck_templates WHERE id = ?", t.ID)
+		} else {
+			t.Action, existing = "rename", t.ID
+			_, err = q.Exec("UPDATE deck_templates SET main = ? WHERE id = ?", target, t.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if userID != "" {
+			if t.AliasAdded, err = addMergeAlias(q, gameID, t.Name, existing); err != nil {
+				return nil, err
+			}
+		}
+		summary.Templates = append(summary.Templates, t)
+	}
+
+	if userID != "" {
+		// 新名稱沒有模板時補上（與新增對局相同）
+		if err := EnsureDeckTemplate(q, gameID, target); err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// addMergeAlias 將合併前的模板名稱加為 templateID 的別名；
+// 別名已存在時（e.g. 同名的大軸、小軸模板都被合併）不重複加入，回傳 false
+func addMergeAlias(q database.Querier, gameID, alias, templateID string) (bool, error) {
+	var used int
+	if err := q.QueryRow("SELECT COUNT(*) FROM deck_aliases WHERE game_id = ? AND alias = ?", gameID, alias).Scan(&used); err != nil {
+		return false, err
+	}
+	if used > 0 {
+		return false, nil
+	}
+	_, err := q.Exec(
+		"INSERT INTO deck_aliases (id, game_id, alias, template_id) VALUES (?, ?, ?, ?)",
+		uuid.New().String(), gameID, alias, templateID,
+	)
+	return err == nil, err
+}
+
+// DeckTemplateIDs 遊戲中名稱為 names 其中之一的牌組模板 ID（改名/合併前寫入變更紀錄用）
+func DeckTemplateIDs(q database.Querier, gameID string, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	args := []interface{}{gameID}
+	for _, n := range names {
+		args = append(args, n)
+	}
+
+	rows, err := q.Query("SELECT id FROM deck_templates WHERE game_id = ? AND main IN ("+placeholders+") ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func queryDeckChanges(q database.Querier, query string, args ...interface{}) ([]DeckChange, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	decks := []DeckChange{}
+	for rows.Next() {
+		var d DeckChange
+		var sub sql.NullString
+		if err := rows.Scan(&d.ID, &d.Main, &sub); err != nil {
+			return nil, err
+		}
+		if sub.Valid {
+			d.Sub = &sub.String
+		}
+		decks = append(decks, d)
+	}
+	return decks, rows.Err()
+}
+
+func queryTemplateChanges(q database.Querier, query string, args ...interface{}) ([]TemplateChange, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []TemplateChange{}
+	for rows.Next() {
+		var t TemplateChange
+		if err := rows.Scan(&t.ID, &t.Name, &t.DeckType); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// DeckNameMatchIDs 使用者使用這些牌組名稱（我方或對手的大軸、小軸）的對局 ID
+func DeckNameMatchIDs(q database.Querier, gameID, userID string, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	args := []interface{}{userID, gameID}
+	for _, n := range names {
+		args = append(args, n)
+	}
+	args = append(args, args[2:]...)
+
+	rows, err := q.Query(`
+		SELECT DISTINCT m.id FROM matches m
+		JOIN decks d ON d.id = m.my_deck_id OR d.id = m.opp_deck_id
+		WHERE m.user_id = ? AND m.game_id = ? AND (d.main IN (`+placeholders+`) OR d.sub IN (`+placeholders+`))
+		ORDER BY m.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/database"
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+// seedMergeDecks 牌組：天盃（user-a、user-b 都在用）、天杯龍（只有 user-a）、天盃龍（合併目標）、閃刀姬/天盃（user-a）；
+// 模板：天盃（大軸，別名 テンハイ）、天盃龍（大軸）、天杯龍（小軸）
+func seedMergeDecks(t *testing.T, db *database.DB) {
+	t.Helper()
+	testdb.MustExec(t, db, `INSERT INTO decks (id, game_id, main, sub) VALUES
+		('d-old', ?, '天盃', NULL),
+		('d-typo', ?, '天杯龍', NULL),
+		('d-target', ?, '天盃龍', NULL),
+		('d-sub', ?, '閃刀姬', '天盃'),
+		('d-opp', ?, '蛇眼', NULL)`, testGameID, testGameID, testGameID, testGameID, testGameID)
+	testdb.MustExec(t, db, `INSERT INTO matches (id, user_id, game_id, season_id, date, rank, my_deck_id, opp_deck_id, play_order, result) VALUES
+		('m-a1', 'user-a', ?, 's', '2025-01-02', '金 IV', 'd-old', 'd-opp', '先攻', 'W'),
+		('m-b1', 'user-b', ?, 's', '2025-01-02', '金 IV', 'd-old', 'd-opp', '先攻', 'W'),
+		('m-a2', 'user-a', ?, 's', '2025-01-02', '金 IV', 'd-opp', 'd-typo', '後攻', 'L'),
+		('m-a3', 'user-a', ?, 's', '2025-01-02', '金 IV', 'd-target', 'd-opp', '先攻', 'W'),
+		('m-a4', 'user-a', ?, 's', '2025-01-02', '金 IV', 'd-sub', 'd-opp', '後攻', 'W')`,
+		testGameID, testGameID, testGameID, testGameID, testGameID)
+	testdb.MustExec(t, db, `INSERT INTO deck_templates (id, game_id, main, theme, deck_type) VALUES
+		('tpl-old', ?, '天盃', '連結', 'main'),
+		('tpl-target', ?, '天盃龍', '融合', 'main'),
+		('tpl-typo', ?, '天杯龍', '連結', 'sub')`, testGameID, testGameID, testGameID)
+	testdb.MustExec(t, db, "INSERT INTO deck_aliases (id, game_id, alias, template_id) VALUES ('alias-1', ?, 'テンハイ', 'tpl-old')", testGameID)
+}
+
+func matchDeck(t *testing.T, db *database.DB, matchID, col string) string {
+	t.Helper()
+	var id string
+	if err := db.QueryRow("SELECT "+col+" FROM matches WHERE id = ?", matchID).Scan(&id); err != nil {
+		t.Fatalf("select %s of %s: %v", col, matchID, err)
+	}
+	return id
+}
+
+func countRows(t *testing.T, db *database.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestMergeDeckNamesForUser(t *testing.T) {
+	db := openTestDB(t)
+	seedMergeDecks(t, db)
+
+	summary, err := MergeDeckNames(db, testGameID, "user-a", []string{"天盃", "天杯龍"}, "天盃龍")
+	if err != nil {
+		t.Fatalf("MergeDeckNames: %v", err)
+	}
+
+	wantDecks := map[string]struct{ action, into string }{
+		"d-old":  {"repoint", "d-target"}, // user-b 的對局仍在使用
+		"d-typo": {"merge", "d-target"},
+		"d-sub":  {"rename", ""},
+	}
+	if len(summary.Decks) != len(wantDecks) {
+		t.Fatalf("deck changes = %+v, want %d", summary.Decks, len(wantDecks))
+	}
+	for _, d := range summary.Decks {
+		want, ok := wantDecks[d.ID]
+		if !ok || d.Action != want.action || d.IntoID != want.into {
+			t.Errorf("deck change %+v, want action %q into %q", d, want.action, want.into)
+		}
+	}
+	if summary.AffectedMatches != 3 {
+		t.Errorf("affected matches = %d, want 3", summary.AffectedMatches)
+	}
+
+	for _, tt := range []struct{ match, col, want string }{
+		{"m-a1", "my_deck_id", "d-target"},
+		{"m-b1", "my_deck_id", "d-old"}, // 其他使用者的對局不變
+		{"m-a2", "opp_deck_id", "d-target"},
+		{"m-a4", "my_deck_id", "d-sub"},
+	} {
+		if got := matchDeck(t, db, tt.match, tt.col); got != tt.want {
+			t.Errorf("%s.%s = %s, want %s", tt.match, tt.col, got, tt.want)
+		}
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM decks WHERE id IN ('d-old', 'd-typo')"); n != 1 {
+		t.Errorf("%d of d-old, d-typo left, want only d-old", n)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM decks WHERE id = 'd-sub' AND sub = '天盃龍'"); n != 1 {
+		t.Errorf("d-sub was not renamed")
+	}
+
+	// 共用的模板：天盃併入天盃龍、天杯龍（小軸）改名，舊名稱都成為別名
+	wantTemplates := map[string]struct{ action, into string }{
+		"tpl-old":  {"merge", "tpl-target"},
+		"tpl-typo": {"rename", ""},
+	}
+	if len(summary.Templates) != len(wantTemplates) {
+		t.Fatalf("template changes = %+v, want %d", summary.Templates, len(wantTemplates))
+	}
+	for _, tc := range summary.Templates {
+		want := wantTemplates[tc.ID]
+		if tc.Action != want.action || tc.IntoID != want.into || !tc.AliasAdded {
+			t.Errorf("template change %+v, want action %q into %q with an alias", tc, want.action, want.into)
+		}
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM deck_templates WHERE game_id = ? AND main IN ('天盃', '天杯龍')", testGameID); n != 0 {
+		t.Errorf("%d source templates left, want 0", n)
+	}
+
+	for _, name := range []string{"天盃", "天杯龍", "テンハイ"} {
+		if got, err := ResolveDeckName(db, testGameID, name); err != nil || got != "天盃龍" {
+			t.Errorf("ResolveDeckName(%q) = %q, %v; want 天盃龍", name, got, err)
+		}
+	}
+	names, err := DeckNames(db, testGameID)
+	if err != nil {
+		t.Fatalf("DeckNames: %v", err)
+	}
+	for _, s := range Suggest(names, "天盃", 0) {
+		if s.Name != "天盃龍" {
+			t.Errorf("Suggest(天盃) includes %q, want only 天盃龍", s.Name)
+		}
+	}
+}
+
+func TestMergeDeckNamesAll(t *testing.T) {
+	db := openTestDB(t)
+	seedMergeDecks(t, db)
+
+	// 管理工具：所有使用者的對局一起改，不需要別名
+	summary, err := MergeDeckNames(db, testGameID, "", []string{"天盃"}, "天盃龍")
+	if err != nil {
+		t.Fatalf("MergeDeckNames: %v", err)
+	}
+	for _, d := range summary.Decks {
+		if d.Action == "repoint" {
+			t.Errorf("deck change %+v, want no repoint without a user", d)
+		}
+	}
+	if got := matchDeck(t, db, "m-b1", "my_deck_id"); got != "d-target" {
+		t.Errorf("m-b1.my_deck_id = %s, want d-target", got)
+	}
+	if len(summary.Templates) != 1 || summary.Templates[0].Action != "merge" || summary.Templates[0].AliasAdded {
+		t.Errorf("template changes = %+v, want one merge without an alias", summary.Templates)
+	}
+	// 被合併模板的別名改指向保留的模板
+	if got, err := ResolveDeckName(db, testGameID, "テンハイ"); err != nil || got != "天盃龍" {
+		t.Errorf("ResolveDeckName(テンハイ) = %q, %v; want 天盃龍", got, err)
+	}
+}
+
+func TestMergeDeckNamesNotFound(t *testing.T) {
+	db := openTestDB(t)
+	seedMergeDecks(t, db)
+
+	tests := []struct {
+		name    string
+		userID  string
+		sources []string
+	}{
+		{"unknown name", "user-a", []string{"烙印"}},
+		{"unknown name for all users", "", []string{"烙印"}},
+		{"only other users' decks", "user-c", []string{"天杯龍"}}, // 也不改共用的模板
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MergeDeckNames(db, testGameID, tt.userID, tt.sources, "天盃龍"); !errors.Is(err, ErrDeckNotFound) {
+				t.Errorf("MergeDeckNames = %v, want ErrDeckNotFound", err)
+			}
+		})
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM deck_templates WHERE id = 'tpl-typo' AND main = '天杯龍'"); n != 1 {
+		t.Errorf("template 天杯龍 was changed by a user without matches using it")
+	}
+}
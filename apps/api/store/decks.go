@@ -0,0 +1,84 @@
+// Package store 放置 handlers、匯入工具共用的資料存取邏輯（牌組、賽季的查找與自動建立）。
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// FindDeckID 查詢牌組 ID；找不到時回傳空字串
+func FindDeckID(q database.Querier, gameID, main string, sub *string) (string, error) {
+	var deckID string
+	var err error
+	if sub == nil {
+		err = q.QueryRow("SELECT id FROM decks WHERE game_id = ? AND main = ? AND sub IS NULL", gameID, main).Scan(&deckID)
+	} else {
+		err = q.QueryRow("SELECT id FROM decks WHERE game_id = ? AND main = ? AND sub = ?", gameID, main, *sub).Scan(&deckID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return deckID, err
+}
+
+// FindOrCreateDeck 尋找或建立牌組
+func FindOrCreateDeck(q database.Querier, gameID, main string, sub *string) (string, error) {
+	// 先嘗試尋找
+	if deckID, err := FindDeckID(q, gameID, main, sub); err != nil || deckID != "" {
+		return deckID, err // 找到了（或查詢失敗：Postgres 的交易已中止，不能再繼續）
+	}
+
+	var subValue sql.NullString
+	if sub != nil {
+		subValue.String = *sub
+		subValue.Valid = true
+	}
+
+	// 沒找到，建立新的
+	deckID := uuid.New().String()
+	_, err := q.Exec(
+		"INSERT INTO decks (id, game_id, main, sub) VALUES (?, ?, ?, ?)",
+		deckID, gameID, main, subValue,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	// 同時確保 deck_templates 中有這個牌組（用於顏色顯示）
+	if err := EnsureDeckTemplate(q, gameID, main); err != nil {
+		return "", err
+	}
+	if sub != nil && *sub != "" && *sub != "無" {
+		if err := EnsureDeckTemplate(q, gameID, *sub); err != nil {
+			return "", err
+		}
+	}
+
+	return deckID, nil
+}
+
+// EnsureDeckTemplate 確保牌組模板存在，不存在則建立（預設主題為「無」）
+// 在交易中呼叫時必須檢查錯誤：Postgres 的語句失敗後整個交易都會中止
+func EnsureDeckTemplate(q database.Querier, gameID, deckName string) error {
+	// 檢查是否已存在
+	var exists bool
+	err := q.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM deck_templates WHERE game_id = ? AND main = ? AND deck_type = 'main')",
+		gameID,
+		deckName,
+	).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+
+	// 不存在，建立新的模板（預設主題為「無」= 灰色）
+	templateID := "tpl-auto-" + uuid.New().String()[:8]
+	_, err = q.Exec(`
+		INSERT INTO deck_templates (id, game_id, main, theme, deck_type, created_at)
+		VALUES (?, ?, ?, '無', 'main', CURRENT_TIMESTAMP)
+	`, templateID, gameID, deckName)
+	return err
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+var folder = cases.Fold()
+
+// NormalizeDeckName 牌組名稱的統一格式：NFKC（全形英數轉半形、相容字元轉標準字元）、
+// 去除無效的 UTF-8 與控制字元、前後空白，連續空白合併為一個半形空白
+func NormalizeDeckName(name string) string {
+	name = norm.NFKC.String(strings.ToValidUTF8(name, ""))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// FoldDeckName 比對用的名稱：NormalizeDeckName 後再做大小寫折疊並去除空白
+// （"spyral" 與 "Spyral"、"Y O" 與 "YO" 視為相同）
+func FoldDeckName(name string) string {
+	return strings.ReplaceAll(folder.String(NormalizeDeckName(name)), " ", "")
+}
+
+// DeckName 牌組模板名稱或別名（比對、建議用）
+type DeckName struct {
+	TemplateID string
+	Name       string // 模板名稱
+	Theme      string
+	DeckType   string
+	Alias      string // 由別名比對到時為別名，否則為空字串
+}
+
+// DeckNames 列出遊戲中所有牌組模板名稱與別名
+func DeckNames(q database.Querier, gameID string) ([]DeckName, error) {
+	rows, err := q.Query(`
+		SELECT id, main, theme, deck_type, '' FROM deck_templates WHERE game_id = ?
+		UNION ALL
+		SELECT t.id, t.main, t.theme, t.deck_type, a.alias
+		FROM deck_aliases a JOIN deck_templates t ON t.id = a.template_id
+		WHERE a.game_id = ?
+	`, gameID, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []DeckName{}
+	for rows.Next() {
+		var n DeckName
+		if err := rows.Scan(&n.TemplateID, &n.Name, &n.Theme, &n.DeckType, &n.Alias); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+// label 比對用的文字（別名或模板名稱）
+func (n DeckName) label() string {
+	if n.Alias != "" {
+		return n.Alias
+	}
+	return n.Name
+}
+
+// Suggestion 牌組名稱建議
+type Suggestion struct {
+	DeckName
+	Score  float64 // 0~1，越高越接近
+	Reason string  // "exact" | "prefix" | "contains" | "similar"
+}
+
+// minSuggestScore 低於此分數的候選不列入建議（兩個字的名稱差一個字約為 0.375）
+const minSuggestScore = 0.35
+
+// Suggest 依相似度排序的牌組名稱建議（同一個模板只列出分數最高的一筆）
+// 比對前雙方都會經過 FoldDeckName，所以全形/半形、大小寫不同都視為相同。
+func Suggest(names []DeckName, query string, limit int) []Suggestion {
+	q := FoldDeckName(query)
+	if q == "" {
+		return []Suggestion{}
+	}
+
+	best := map[string]Suggestion{}
+	for _, n := range names {
+		score, reason := scoreName(q, FoldDeckName(n.label()))
+		if score < minSuggestScore {
+			continue
+		}
+		if prev, ok := best[n.TemplateID]; !ok || score > prev.Score {
+			best[n.TemplateID] = Suggestion{DeckName: n, Score: score, Reason: reason}
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(best))
+	for _, s := range best {
+		suggestions = append(suggestions, s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if suggestions[i].Name != suggestions[j].Name {
+			return suggestions[i].Name < suggestions[j].Name
+		}
+		return suggestions[i].DeckType < suggestions[j].DeckType
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// scoreName 比較兩個已折疊的名稱
+func scoreName(query, candidate string) (float64, string) {
+	switch {
+	case query == candidate:
+		return 1, "exact"
+	case strings.HasPrefix(candidate, query):
+		return 0.9, "prefix"
+	case strings.Contains(candidate, query) || strings.Contains(query, candidate):
+		return 0.8, "contains"
+	}
+	a, b := []rune(query), []rune(candidate)
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	// 編輯距離換算的相似度最高 0.75，一律排在前綴/包含之後
+	return 0.75 * (1 - float64(levenshtein(a, b))/float64(longest)), "similar"
+}
+
+// levenshtein 編輯距離（以字元計）
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeDeckName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"蛇眼", "蛇眼"},
+		{"ＥＭ　ＨＥＲＯ", "EM HERO"},     // 全形英數與全形空白
+		{"ｶﾞｰﾃﾞｨｱﾝ", "ガーディアン"},     // 半形片假名
+		{"Ｙ－Ｏ", "Y-O"},             // 全形符號
+		{"  烙印\t\n 融合  ", "烙印 融合"}, // 前後空白去除、連續空白合併
+		{"蛇\x00眼\x7f", "蛇眼"},       // 控制字元
+		{"蛇眼\xff", "蛇眼"},           // 無效的 UTF-8
+		{"Spyral", "Spyral"},       // 大小寫不變
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeDeckName(tt.name); got != tt.want {
+				t.Errorf("NormalizeDeckName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldDeckName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Spyral", "spyral"},
+		{"SPYRAL", "spyral"},
+		{"Ｓｐｙｒａｌ", "spyral"},
+		{"Y O", "yo"},
+		{"ＹＯ", "yo"},
+		{" E m  H e r o ", "emhero"},
+		{"ΣΟΦΊΑ", "σοφία"},
+		{"天盃龍", "天盃龍"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FoldDeckName(tt.name); got != tt.want {
+				t.Errorf("FoldDeckName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	names := []DeckName{
+		{TemplateID: "tpl-tenpai", Name: "天盃龍"},
+		{TemplateID: "tpl-tenpai", Name: "天盃龍", Alias: "天杯"},
+		{TemplateID: "tpl-snake", Name: "蛇眼"},
+		{TemplateID: "tpl-snake", Name: "蛇眼", Alias: "スネークアイ"},
+		{TemplateID: "tpl-spyral", Name: "Spyral"},
+		{TemplateID: "tpl-sky", Name: "閃刀姬"},
+		{TemplateID: "tpl-sky-re", Name: "閃刀姬-零衣"},
+		{TemplateID: "tpl-branded", Name: "烙印融合"},
+	}
+
+	type result struct {
+		Name   string
+		Alias  string
+		Score  float64
+		Reason string
+	}
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []result
+	}{
+		{"exact across width and case", "ｓｐｙｒａｌ", 0, []result{{"Spyral", "", 1, "exact"}}},
+		{"exact alias", "スネーク アイ", 0, []result{{"蛇眼", "スネークアイ", 1, "exact"}}},
+		{"prefix", "SPY", 0, []result{{"Spyral", "", 0.9, "prefix"}}},
+		{"contains", "盃龍", 0, []result{{"天盃龍", "", 0.8, "contains"}}},
+		{"query contains the name", "蛇眼 (壞獸)", 0, []result{{"蛇眼", "", 0.8, "contains"}}},
+		// 同一個模板只列出分數最高的一筆（包含別名的 0.8 勝過與名稱相似的 0.5）
+		{"best per template", "天杯龍", 0, []result{{"天盃龍", "天杯", 0.8, "contains"}}},
+		// 相同分數依名稱排序，limit 只取前面的
+		{"ties sorted by name", "閃刀", 0, []result{{"閃刀姬", "", 0.9, "prefix"}, {"閃刀姬-零衣", "", 0.9, "prefix"}}},
+		{"limit", "閃刀", 1, []result{{"閃刀姬", "", 0.9, "prefix"}}},
+		// 編輯距離：兩個字差一個字 0.375、四個字差兩個字 0.375 仍列出；三個字差兩個字、六個字差四個字 0.25 不列出
+		{"similar two characters", "蛇目", 0, []result{{"蛇眼", "", 0.375, "similar"}}},
+		{"similar four characters", "烙印合體", 0, []result{{"烙印融合", "", 0.375, "similar"}}},
+		{"similar three characters", "閃刀姫", 0, []result{{"閃刀姬", "", 0.5, "similar"}}}, // 閃刀姬-零衣 差四個字，0.25
+		{"below cutoff", "天龍盃", 0, []result{}},
+		{"unrelated", "黑魔導", 0, []result{}},
+		{"empty", "  ", 0, []result{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []result{}
+			for _, s := range Suggest(names, tt.query, tt.limit) {
+				got = append(got, result{s.Name, s.Alias, math.Round(s.Score*1000) / 1000, s.Reason})
+			}
+			want := tt.want
+			for i := range want {
+				want[i].Score = math.Round(want[i].Score*1000) / 1000
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Suggest(%q) = %+v, want %+v", tt.query, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// ErrRankNotFound 遊戲有階梯，但輸入的階級無法對應
+var ErrRankNotFound = errors.New("rank not found")
+
+// Rank 階級（ranks 表），Ordinal 越大越高
+type Rank struct {
+	ID       string `json:"id"`
+	Mode     string `json:"mode"`
+	Tier     string `json:"tier"`     // 段位，e.g. "金"
+	Division *int   `json:"division"` // 階，e.g. 4（沒有分階時為 null）
+	Name     string `json:"name"`     // 顯示名稱，e.g. "金 IV"
+	Ordinal  int    `json:"ordinal"`
+}
+
+// ListRanks 遊戲在指定模式下的階梯（由低到高）
+func ListRanks(q database.Querier, gameID, mode string) ([]Rank, error) {
+	rows, err := q.Query(`
+		SELECT id, mode, tier, division, name, ordinal
+		FROM ranks
+		WHERE game_id = ? AND mode = ?
+		ORDER BY ordinal ASC
+	`, gameID, mode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranks := []Rank{}
+	for rows.Next() {
+		var r Rank
+		var division *int64
+		if err := rows.Scan(&r.ID, &r.Mode, &r.Tier, &division, &r.Name, &r.Ordinal); err != nil {
+			return nil, err
+		}
+		if division != nil {
+			d := int(*division)
+			r.Division = &d
+		}
+		ranks = append(ranks, r)
+	}
+	return ranks, rows.Err()
+}
+
+// ResolveRank 將輸入的階級文字對應到階梯上的階級。
+// 忽略空白、大小寫與全形半形，階可以用羅馬數字或阿拉伯數字，段位可以用開頭的簡寫
+// （"金4"、"金IV"、"鑽 1" 都可以）。
+// 遊戲在該模式沒有階梯時回傳 nil（沿用自由輸入的文字）；有階梯但無法對應時回傳 ErrRankNotFound。
+func ResolveRank(q database.Querier, gameID, mode, input string) (*Rank, error) {
+	ranks, err := ListRanks(q, gameID, mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, nil
+	}
+	if r := MatchRank(ranks, input); r != nil {
+		return r, nil
+	}
+	return nil, ErrRankNotFound
+}
+
+// MatchRank 在階梯中找出輸入的階級（規則見 ResolveRank），找不到或有歧義時回傳 nil
+func MatchRank(ranks []Rank, input string) *Rank {
+	key := FoldDeckName(input)
+	if key == "" {
+		return nil
+	}
+	for i := range ranks {
+		if FoldDeckName(ranks[i].Name) == key {
+			return &ranks[i]
+		}
+	}
+
+	tier, division := splitDivision(key)
+	var exact, prefix []*Rank
+	for i := range ranks {
+		r := &ranks[i]
+		if !sameDivision(r.Division, division) {
+			continue
+		}
+		rt := FoldDeckName(r.Tier)
+		switch {
+		case rt == tier:
+			exact = append(exact, r)
+		case tier != "" && strings.HasPrefix(rt, tier):
+			prefix = append(prefix, r)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0]
+	}
+	if len(exact) == 0 && len(prefix) == 1 {
+		return prefix[0]
+	}
+	return nil
+}
+
+// splitDivision 拆出結尾的階（羅馬數字或阿拉伯數字）；沒有時 division 為 nil
+func splitDivision(key string) (string, *int) {
+	i := len(key)
+	for i > 0 && key[i-1] >= '0' && key[i-1] <= '9' {
+		i--
+	}
+	if i < len(key) && i > 0 {
+		if n, err := strconv.Atoi(key[i:]); err == nil {
+			return key[:i], &n
+		}
+	}
+
+	i = len(key)
+	for i > 0 && strings.IndexByte("ivx", key[i-1]) >= 0 {
+		i--
+	}
+	if i < len(key) && i > 0 {
+		if n := romanToInt(key[i:]); n > 0 {
+			return key[:i], &n
+		}
+	}
+	return key, nil
+}
+
+// romanToInt 小寫羅馬數字（1~39）轉為整數，格式錯誤時回傳 0
+func romanToInt(s string) int {
+	values := map[byte]int{'i': 1, 'v': 5, 'x': 10}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		v := values[s[i]]
+		if i+1 < len(s) && v < values[s[i+1]] {
+			n -= v
+		} else {
+			n += v
+		}
+	}
+	if n <= 0 || n >= 40 || toRoman(n) != s {
+		return 0
+	}
+	return n
+}
+
+// toRoman 整數（1~39）轉為小寫羅馬數字
+func toRoman(n int) string {
+	var b strings.Builder
+	for _, p := range []struct {
+		v int
+		s string
+	}{{10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"}} {
+		for n >= p.v {
+			b.WriteString(p.s)
+			n -= p.v
+		}
+	}
+	return b.String()
+}
+
+func sameDivision(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+func TestResolveRank(t *testing.T) {
+	db := testdb.Open(t)
+
+	tests := []struct {
+		mode  string
+		input string
+		want  string // 空字串表示回傳 nil
+		err   error
+	}{
+		{"Ranked", "金 IV", "金 IV", nil},
+		{"Ranked", "金4", "金 IV", nil},
+		{"Ranked", "金IV", "金 IV", nil},
+		{"Ranked", " 金 iv ", "金 IV", nil},
+		{"Ranked", "金４", "金 IV", nil},
+		{"Ranked", "金ＩＶ", "金 IV", nil},
+		{"Ranked", "白金3", "白金 III", nil},
+		{"Ranked", "鑽 1", "鑽石 I", nil},
+		{"Ranked", "大師I", "大師 I", nil},
+		{"Ranked", "銅 V", "銅 V", nil},
+		// 階梯上沒有的階級
+		{"Ranked", "金9", "", ErrRankNotFound},
+		{"Ranked", "金 0", "", ErrRankNotFound},
+		{"Ranked", "金IIII", "", ErrRankNotFound},
+		{"Ranked", "金", "", ErrRankNotFound},
+		{"Ranked", "4", "", ErrRankNotFound},
+		{"Ranked", "傳說 I", "", ErrRankNotFound},
+		{"Ranked", "", "", ErrRankNotFound},
+		// 沒有階梯的模式沿用自由輸入的文字
+		{"Rating", "1500", "", nil},
+		{"DC", "金9", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.input, func(t *testing.T) {
+			r, err := ResolveRank(db, testdb.GameID, tt.mode, tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ResolveRank(%q) error = %v, want %v", tt.input, err, tt.err)
+			}
+			got := ""
+			if r != nil {
+				got = r.Name
+			}
+			if got != tt.want {
+				t.Errorf("ResolveRank(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	// 沒有階梯的遊戲
+	if r, err := ResolveRank(db, "game-none", "Ranked", "金4"); r != nil || err != nil {
+		t.Errorf("ResolveRank for a game without ranks = %+v, %v; want nil", r, err)
+	}
+}
+
+func TestMatchRank(t *testing.T) {
+	one, two := 1, 2
+	ranks := []Rank{
+		{ID: "gr1", Tier: "Great", Division: &one, Name: "Great 1"},
+		{ID: "gd1", Tier: "Grand", Division: &one, Name: "Grand 1"},
+		{ID: "gd2", Tier: "Grand", Division: &two, Name: "Grand 2"},
+		{ID: "legend", Tier: "Legend", Name: "Legend"},
+	}
+
+	tests := []struct {
+		input string
+		want  string // 空字串表示找不到
+	}{
+		{"Great1", "gr1"},
+		{"grand ii", "gd2"},
+		{"gre 1", "gr1"},
+		{"gr 1", ""}, // 簡寫同時符合 Great、Grand
+		{"Grand 3", ""},
+		{"legend", "legend"},
+		{"leg", "legend"},
+		{"legend 1", ""}, // 沒有分階的段位不能加上階
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ""
+			if r := MatchRank(ranks, tt.input); r != nil {
+				got = r.ID
+			}
+			if got != tt.want {
+				t.Errorf("MatchRank(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// FindSeasonID 查詢賽季 ID；找不到時回傳空字串
+func FindSeasonID(q database.Querier, gameID, seasonCode string) (string, error) {
+	var seasonID string
+	err := q.QueryRow("SELECT id FROM seasons WHERE code = ? AND game_id = ?", seasonCode, gameID).Scan(&seasonID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return seasonID, err
+}
+
+// GetOrCreateSeasonID 取得賽季 ID，不存在時自動建立
+func GetOrCreateSeasonID(q database.Querier, gameID, seasonCode string) (string, error) {
+	seasonID, err := FindSeasonID(q, gameID, seasonCode)
+	if err != nil || seasonID != "" {
+		return seasonID, err
+	}
+
+	// Not found: auto-create so users can start recording immediately.
+	seasonID = uuid.New().String()
+
+	// If seasonCode looks like YYYY-MM, fill start/end dates; otherwise leave them NULL.
+	var startDate any = nil
+	var endDate any = nil
+	if start, end, ok := MonthRange(seasonCode); ok {
+		startDate, endDate = start, end
+	}
+
+	_, err = q.Exec(
+		"INSERT INTO seasons (id, game_id, code, start_date, end_date) VALUES (?, ?, ?, ?, ?)",
+		seasonID, gameID, seasonCode, startDate, endDate,
+	)
+	if err != nil {
+		// If another request created it concurrently, just re-read.
+		if existing, readErr := FindSeasonID(q, gameID, seasonCode); readErr == nil && existing != "" {
+			return existing, nil
+		}
+		return "", err
+	}
+
+	return seasonID, nil
+}
+
+// MonthRange 賽季代碼為 YYYY-MM 時回傳該月的第一天與最後一天（YYYY-MM-DD）
+func MonthRange(seasonCode string) (start, end string, ok bool) {
+	t, err := time.Parse("2006-01", seasonCode)
+	if err != nil {
+		return "", "", false
+	}
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, 0).AddDate(0, 0, -1)
+	return first.Format("2006-01-02"), last.Format("2006-01-02"), true
+}
+
+// ErrSeasonNotInferred 沒有任何賽季的日期範圍包含該日期
+var ErrSeasonNotInferred = errors.New("no season contains the date")
+
+// InferSeason 找出日期範圍包含 date（YYYY-MM-DD）的賽季；
+// 沒有結束日期的賽季視為持續到現在，範圍重疊時取開始日期最晚的一個
+func InferSeason(q database.Querier, gameID, date string) (Season, error) {
+	seasons, err := ListSeasons(q, gameID)
+	if err != nil {
+		return Season{}, err
+	}
+	var found *Season
+	for i := range seasons {
+		s := &seasons[i]
+		if !s.Contains(date) {
+			continue
+		}
+		if found == nil || *s.StartDate > *found.StartDate {
+			found = s
+		}
+	}
+	if found == nil {
+		return Season{}, ErrSeasonNotInferred
+	}
+	return *found, nil
+}
+
+// Season 賽季（seasons 表）
+type Season struct {
+	ID        string     `json:"id"`
+	Code      string     `json:"code"`
+	StartDate *string    `json:"startDate"` // YYYY-MM-DD，未設定時為 null
+	EndDate   *string    `json:"endDate"`
+	ClosedAt  *time.Time `json:"closedAt"` // 結束時間，進行中為 null
+}
+
+// Closed 賽季是否已結束
+func (s Season) Closed() bool {
+	return s.ClosedAt != nil
+}
+
+// Contains 日期（YYYY-MM-DD）是否在賽季範圍內；沒有開始日期的賽季不包含任何日期
+func (s Season) Contains(date string) bool {
+	if s.StartDate == nil || date < *s.StartDate {
+		return false
+	}
+	return s.EndDate == nil || date <= *s.EndDate
+}
+
+// ListSeasons 遊戲的所有賽季（依開始日期排序，沒有日期的排在最後）
+func ListSeasons(q database.Querier, gameID string) ([]Season, error) {
+	rows, err := q.Query(`
+		SELECT id, code, start_date, end_date, closed_at
+		FROM seasons
+		WHERE game_id = ?
+		ORDER BY CASE WHEN start_date IS NULL THEN 1 ELSE 0 END, start_date ASC, code ASC
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := []Season{}
+	for rows.Next() {
+		s, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, s)
+	}
+	return seasons, rows.Err()
+}
+
+// GetSeason 依 ID 取得賽季，找不到時回傳 sql.ErrNoRows
+func GetSeason(q database.Querier, id string) (Season, error) {
+	rows, err := q.Query("SELECT id, code, start_date, end_date, closed_at FROM seasons WHERE id = ?", id)
+	if err != nil {
+		return Season{}, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return Season{}, err
+		}
+		return Season{}, sql.ErrNoRows
+	}
+	return scanSeason(rows)
+}
+
+func scanSeason(rows *sql.Rows) (Season, error) {
+	var s Season
+	var start, end sql.NullString
+	var closedAt sql.NullTime
+	if err := rows.Scan(&s.ID, &s.Code, &start, &end, &closedAt); err != nil {
+		return s, err
+	}
+	s.StartDate = datePtr(start)
+	s.EndDate = datePtr(end)
+	if closedAt.Valid {
+		s.ClosedAt = &closedAt.Time
+	}
+	return s, nil
+}
+
+// datePtr DATE 欄位轉為 YYYY-MM-DD（SQLite driver 會回傳 RFC 3339 格式）
+func datePtr(v sql.NullString) *string {
+	if !v.Valid || v.String == "" {
+		return nil
+	}
+	d := v.String
+	if len(d) > len("2006-01-02") {
+		d = d[:len("2006-01-02")]
+	}
+	return &d
+}
+
+// SeasonIssue 賽季日期範圍的問題
+type SeasonIssue struct {
+	Type    string   `json:"type"`    // "overlap" | "gap" | "undated"
+	Seasons []string `json:"seasons"` // 相關賽季的代碼
+	From    string   `json:"from,omitempty"`
+	To      string   `json:"to,omitempty"`
+	Message string   `json:"message"`
+}
+
+// SeasonIssues 檢查賽季範圍是否重疊、中間是否有空檔，以及沒有設定日期的賽季
+// （seasons 需依開始日期排序，見 ListSeasons）
+func SeasonIssues(seasons []Season) []SeasonIssue {
+	issues := []SeasonIssue{}
+	var dated []Season
+	for _, s := range seasons {
+		if s.StartDate == nil {
+			issues = append(issues, SeasonIssue{
+				Type:    "undated",
+				Seasons: []string{s.Code},
+				Message: s.Code + " 沒有設定開始日期，無法依日期推斷",
+			})
+			continue
+		}
+		dated = append(dated, s)
+	}
+
+	// cover 目前為止結束得最晚的賽季；下一個賽季在它結束前開始就是重疊，之後才開始就是空檔
+	for i := 1; i < len(dated); i++ {
+		cover := dated[0]
+		for _, s := range dated[1:i] {
+			if endOrOpen(s) > endOrOpen(cover) {
+				cover = s
+			}
+		}
+		next := dated[i]
+
+		if endOrOpen(cover) >= *next.StartDate {
+			issue := SeasonIssue{
+				Type:    "overlap",
+				Seasons: []string{cover.Code, next.Code},
+				From:    *next.StartDate,
+				Message: fmt.Sprintf("%s 與 %s 的日期重疊", cover.Code, next.Code),
+			}
+			if to := minDate(endOrOpen(cover), endOrOpen(next)); to != openEnd {
+				issue.To = to
+			}
+			issues = append(issues, issue)
+			continue
+		}
+		if gapFrom := addDays(*cover.EndDate, 1); gapFrom < *next.StartDate {
+			issues = append(issues, SeasonIssue{
+				Type:    "gap",
+				Seasons: []string{cover.Code, next.Code},
+				From:    gapFrom,
+				To:      addDays(*next.StartDate, -1),
+				Message: fmt.Sprintf("%s 與 %s 之間有空檔", cover.Code, next.Code),
+			})
+		}
+	}
+	return issues
+}
+
+// openEnd 沒有結束日期的賽季視為持續到這一天
+const openEnd = "9999-12-31"
+
+func endOrOpen(s Season) string {
+	if s.EndDate == nil {
+		return openEnd
+	}
+	return *s.EndDate
+}
+
+func minDate(a, b string) string {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func addDays(date string, days int) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, days).Format("2006-01-02")
+}
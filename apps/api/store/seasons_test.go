@@ -0,0 +1,141 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/harvc/duellog/apps/api/internal/testdb"
+)
+
+func datePtrOf(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func TestInferSeason(t *testing.T) {
+	db := testdb.Open(t)
+	for _, s := range []struct{ code, start, end string }{
+		{"S40", "2025-01-01", "2025-01-31"},
+		{"S41", "2025-02-01", "2025-02-28"},
+		// 03-01 ~ 03-09 是空檔
+		{"S42", "2025-03-10", "2025-04-15"},
+		// 與 S42 重疊，沒有結束日期
+		{"S43", "2025-04-01", ""},
+		{"S0", "", ""},
+	} {
+		testdb.MustExec(t, db, "INSERT INTO seasons (id, game_id, code, start_date, end_date) VALUES (?, ?, ?, ?, ?)",
+			"season-"+s.code, testdb.GameID, s.code, datePtrOf(s.start), datePtrOf(s.end))
+	}
+
+	tests := []struct {
+		date string
+		want string // 空字串表示推斷不出來
+	}{
+		{"2024-12-31", ""},
+		{"2025-01-01", "S40"}, // 第一天
+		{"2025-01-31", "S40"}, // 最後一天
+		{"2025-02-01", "S41"},
+		{"2025-02-28", "S41"},
+		{"2025-03-01", ""}, // 空檔
+		{"2025-03-09", ""},
+		{"2025-03-10", "S42"},
+		{"2025-03-31", "S42"},
+		{"2025-04-01", "S43"}, // 重疊時取開始日期較晚的
+		{"2025-04-15", "S43"},
+		{"2026-06-01", "S43"}, // 沒有結束日期的賽季持續到現在
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			s, err := InferSeason(db, testdb.GameID, tt.date)
+			if tt.want == "" {
+				if !errors.Is(err, ErrSeasonNotInferred) {
+					t.Errorf("InferSeason(%s) = %s, %v; want ErrSeasonNotInferred", tt.date, s.Code, err)
+				}
+				return
+			}
+			if err != nil || s.Code != tt.want {
+				t.Errorf("InferSeason(%s) = %s, %v; want %s", tt.date, s.Code, err, tt.want)
+			}
+		})
+	}
+
+	// 同樣的賽季：一個空檔、一個重疊與沒有日期的 S0
+	seasons, err := ListSeasons(db, testdb.GameID)
+	if err != nil {
+		t.Fatalf("ListSeasons: %v", err)
+	}
+	want := []SeasonIssue{
+		{Type: "undated", Seasons: []string{"S0"}},
+		{Type: "gap", Seasons: []string{"S41", "S42"}, From: "2025-03-01", To: "2025-03-09"},
+		{Type: "overlap", Seasons: []string{"S42", "S43"}, From: "2025-04-01", To: "2025-04-15"},
+	}
+	if got := withoutMessages(SeasonIssues(seasons)); !reflect.DeepEqual(got, want) {
+		t.Errorf("SeasonIssues = %+v, want %+v", got, want)
+	}
+}
+
+// withoutMessages 比對時忽略說明文字
+func withoutMessages(issues []SeasonIssue) []SeasonIssue {
+	for i := range issues {
+		issues[i].Message = ""
+	}
+	return issues
+}
+
+func TestSeasonIssues(t *testing.T) {
+	season := func(code, start, end string) Season {
+		return Season{Code: code, StartDate: datePtrOf(start), EndDate: datePtrOf(end)}
+	}
+
+	tests := []struct {
+		name    string
+		seasons []Season
+		want    []SeasonIssue
+	}{
+		{
+			"adjacent",
+			[]Season{season("A", "2025-01-01", "2025-01-31"), season("B", "2025-02-01", "")},
+			[]SeasonIssue{},
+		},
+		{
+			"one day gap",
+			[]Season{season("A", "2025-01-01", "2025-01-31"), season("B", "2025-02-02", "2025-02-28")},
+			[]SeasonIssue{{Type: "gap", Seasons: []string{"A", "B"}, From: "2025-02-01", To: "2025-02-01"}},
+		},
+		{
+			"same day",
+			[]Season{season("A", "2025-01-01", "2025-01-31"), season("B", "2025-01-31", "2025-02-28")},
+			[]SeasonIssue{{Type: "overlap", Seasons: []string{"A", "B"}, From: "2025-01-31", To: "2025-01-31"}},
+		},
+		{
+			// 長的賽季涵蓋之後兩個賽季：都算重疊，B 與 C 之間不算空檔
+			"nested",
+			[]Season{season("A", "2025-01-01", "2025-12-31"), season("B", "2025-03-01", "2025-03-31"), season("C", "2025-05-01", "2025-05-31")},
+			[]SeasonIssue{
+				{Type: "overlap", Seasons: []string{"A", "B"}, From: "2025-03-01", To: "2025-03-31"},
+				{Type: "overlap", Seasons: []string{"A", "C"}, From: "2025-05-01", To: "2025-05-31"},
+			},
+		},
+		{
+			// 兩個都沒有結束日期：重疊沒有結束日期
+			"open ended",
+			[]Season{season("A", "2025-01-01", ""), season("B", "2025-02-01", "")},
+			[]SeasonIssue{{Type: "overlap", Seasons: []string{"A", "B"}, From: "2025-02-01"}},
+		},
+		{
+			"undated only",
+			[]Season{season("A", "", "")},
+			[]SeasonIssue{{Type: "undated", Seasons: []string{"A"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withoutMessages(SeasonIssues(tt.seasons)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SeasonIssues = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
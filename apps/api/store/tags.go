@@ -0,0 +1,161 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harvc/duellog/apps/api/database"
+)
+
+// ErrTagNotFound 找不到要合併的標籤
+var ErrTagNotFound = errors.New("找不到標籤")
+
+// NormalizeTagName 標籤名稱的統一格式：同 NormalizeDeckName，再轉為小寫（"Brick" 與 "brick" 視為相同）
+func NormalizeTagName(name string) string {
+	return strings.ToLower(NormalizeDeckName(name))
+}
+
+// FindTagID 依名稱取得使用者的標籤 ID，不存在時回傳空字串
+func FindTagID(q database.Querier, userID, name string) (string, error) {
+	var id string
+	err := q.QueryRow("SELECT id FROM tags WHERE user_id = ? AND name = ?", userID, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return id, err
+}
+
+// FindOrCreateTag 依名稱取得使用者的標籤，不存在時建立（name 需已正規化）
+func FindOrCreateTag(q database.Querier, userID, name string) (string, error) {
+	id, err := FindTagID(q, userID, name)
+	if err != nil || id != "" {
+		return id, err
+	}
+	id = uuid.New().String()
+	if _, err := q.Exec("INSERT INTO tags (id, user_id, name) VALUES (?, ?, ?)", id, userID, name); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// SetMatchTags 以 names 取代對局的所有標籤（不存在的標籤會建立），請在交易中呼叫
+func SetMatchTags(q database.Querier, userID, matchID string, names []string) error {
+	if _, err := q.Exec("DELETE FROM match_tags WHERE match_id = ?", matchID); err != nil {
+		return err
+	}
+	for _, name := range names {
+		tagID, err := FindOrCreateTag(q, userID, name)
+		if err != nil {
+			return err
+		}
+		_, err = q.Exec(
+			"INSERT INTO match_tags (id, match_id, tag_id) VALUES (?, ?, ?)",
+			uuid.New().String(), matchID, tagID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TagMatchIDs 有 tagIDs 中任一個標籤的對局 ID
+func TagMatchIDs(q database.Querier, tagIDs []string) ([]string, error) {
+	if len(tagIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tagIDs)), ", ")
+	args := make([]interface{}, len(tagIDs))
+	for i, id := range tagIDs {
+		args[i] = id
+	}
+	rows, err := q.Query("SELECT DISTINCT match_id FROM match_tags WHERE tag_id IN ("+placeholders+") ORDER BY match_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// TagMergeSummary 標籤合併結果
+type TagMergeSummary struct {
+	Sources         []string `json:"sources"`
+	Target          string   `json:"target"`
+	Created         bool     `json:"created"`         // target 原本不存在，由第一個來源標籤改名而來
+	AffectedMatches int      `json:"affectedMatches"` // 標籤有變動的對局數（不重複計算）
+}
+
+// MergeTags 將 sources 這些標籤合併為 target：對局改標為 target（已有 target 的不重複），再刪除來源標籤。
+// target 不存在時由第一個來源標籤改名。名稱需已正規化，請在交易中呼叫。
+func MergeTags(q database.Querier, userID string, sources []string, target string) (*TagMergeSummary, error) {
+	summary := &TagMergeSummary{Sources: sources, Target: target}
+
+	sourceIDs := make([]string, 0, len(sources))
+	seen := map[string]bool{}
+	for _, name := range sources {
+		id, err := FindTagID(q, userID, name)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return nil, ErrTagNotFound
+		}
+		if !seen[id] {
+			seen[id] = true
+			sourceIDs = append(sourceIDs, id)
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sourceIDs)), ", ")
+	args := make([]interface{}, len(sourceIDs))
+	for i, id := range sourceIDs {
+		args[i] = id
+	}
+	if err := q.QueryRow(
+		"SELECT COUNT(DISTINCT match_id) FROM match_tags WHERE tag_id IN ("+placeholders+")", args...,
+	).Scan(&summary.AffectedMatches); err != nil {
+		return nil, err
+	}
+
+	targetID, err := FindTagID(q, userID, target)
+	if err != nil {
+		return nil, err
+	}
+	if targetID == "" {
+		// 第一個來源標籤直接改名，其餘合併進來
+		targetID, sourceIDs = sourceIDs[0], sourceIDs[1:]
+		summary.Created = true
+		if _, err := q.Exec("UPDATE tags SET name = ? WHERE id = ?", target, targetID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range sourceIDs {
+		// 已有 target 的對局不再加一次（UNIQUE(match_id, tag_id)）
+		_, err := q.Exec(`
+			UPDATE match_tags SET tag_id = ?
+			WHERE tag_id = ? AND match_id NOT IN (SELECT match_id FROM match_tags WHERE tag_id = ?)
+		`, targetID, id, targetID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := q.Exec("DELETE FROM match_tags WHERE tag_id = ?", id); err != nil {
+			return nil, err
+		}
+		if _, err := q.Exec("DELETE FROM tags WHERE id = ?", id); err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
@@ -1,8 +1,16 @@
 import { Outlet, NavLink, useLocation } from 'react-router-dom'
+import { useQuery } from '@tanstack/react-query'
 import { useTheme } from '../contexts/ThemeContext'
+import { useGame } from '../contexts/GameContext'
+import { gamesService } from '../services/gamesService'
 
 export default function AppShell() {
   const { theme, toggleTheme } = useTheme()
+  const { gameKey, setGameKey } = useGame()
+  const { data: gamesData } = useQuery({
+    queryKey: ['games'],
+    queryFn: () => gamesService.getGames(),
+  })
   const location = useLocation()
 
   const navItems = [
@@ -87,7 +95,29 @@ export default function AppShell() {
             ? 'bg-[#0a0a0f]/80 backdrop-blur-sm border-b border-white/5'
             : 'bg-gray-100/80 backdrop-blur-sm border-b border-gray-200'
         }`}>
-          <div className="text-lg font-semibold">DuelLog</div>
+          <div className="flex items-center gap-4">
+            <div className="text-lg font-semibold">DuelLog</div>
+
+            {/* Game Selector */}
+            <select
+              value={gameKey}
+              onChange={(e) => setGameKey(e.target.value)}
+              className={`px-3 py-1.5 rounded-lg text-sm border focus:outline-none focus:ring-2 focus:ring-indigo-500 ${
+                theme === 'dark'
+                  ? 'bg-[#111118] border-white/10 text-white'
+                  : 'bg-white border-gray-200 text-gray-900'
+              }`}
+              aria-label="切換遊戲"
+            >
+              {(gamesData?.games ?? []).map((g) => (
+                <option key={g.key} value={g.key}>{g.name}</option>
+              ))}
+              {/* 遊戲列表尚未載入時仍顯示目前的選擇 */}
+              {!gamesData?.games.some((g) => g.key === gameKey) && (
+                <option value={gameKey}>{gameKey}</option>
+              )}
+            </select>
+          </div>
           
           {/* Theme Toggle */}
           <button
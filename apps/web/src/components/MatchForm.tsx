@@ -3,8 +3,9 @@ import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
 import { matchesService } from '../services/matchesService'
 import { decksService } from '../services/decksService'
 import { useTheme } from '../contexts/ThemeContext'
+import { getCurrentGameKey } from '../services/gamesService'
 import { getCurrentSeasonCode } from '../utils/season'
-import type { Match } from '../types/match'
+import type { Match, OutcomeReason, CoinToss } from '../types/match'
 
 interface DefaultValues {
   date?: string
@@ -43,6 +44,11 @@ function parseRank(rank: string): { tier: string; level: string } {
   return { tier: '金', level: 'V' }
 }
 
+// 逗號分隔的標籤（全形逗號也接受），空白的略過
+function parseTags(input: string): string[] {
+  return input.split(/[,，]/).map((t) => t.trim()).filter(Boolean)
+}
+
 export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatch, seasonCode, mode: modeFromParent }: MatchFormProps) {
   const { theme } = useTheme()
   const isDark = theme === 'dark'
@@ -76,7 +82,10 @@ export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatc
     oppDeckSub: editMatch.oppDeck.sub || '無',
     playOrder: editMatch.playOrder,
     result: editMatch.result,
+    outcomeReason: editMatch.outcomeReason || 'normal',
+    coinToss: (editMatch.coinToss ?? '') as CoinToss | '',
     note: editMatch.note || '',
+    tags: (editMatch.tags ?? []).join(', '),
   } : {
     date: defaultValues?.date?.split('T')[0] || new Date().toISOString().split('T')[0],
     mode: defaultValues?.mode || modeFromParent || 'Ranked',
@@ -87,7 +96,10 @@ export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatc
     oppDeckSub: '無',
     playOrder: '先攻' as const,
     result: 'W' as const,
+    outcomeReason: 'normal' as const,
+    coinToss: '' as CoinToss | '',
     note: '',
+    tags: '',
   }
 
   // 解析預設階級
@@ -103,8 +115,11 @@ export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatc
   const [oppDeckMain, setOppDeckMain] = useState(initialData.oppDeckMain)
   const [oppDeckSub, setOppDeckSub] = useState(initialData.oppDeckSub)
   const [playOrder, setPlayOrder] = useState<'先攻' | '後攻'>(initialData.playOrder)
-  const [result, setResult] = useState<'W' | 'L'>(initialData.result)
+  const [result, setResult] = useState<'W' | 'L' | 'D'>(initialData.result)
+  const [outcomeReason, setOutcomeReason] = useState<OutcomeReason>(initialData.outcomeReason)
+  const [coinToss, setCoinToss] = useState<CoinToss | ''>(initialData.coinToss)
   const [note, setNote] = useState(initialData.note)
+  const [tags, setTags] = useState(initialData.tags)
 
   // 搜尋狀態
   const [myDeckSearch, setMyDeckSearch] = useState('')
@@ -153,7 +168,7 @@ export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatc
   // 新增 mutation
   const createMutation = useMutation({
     mutationFn: () => matchesService.createMatch({
-      gameKey: 'master_duel',
+      gameKey: getCurrentGameKey(),
       seasonCode: seasonCodeForCreate,
       date,
       mode,
@@ -162,7 +177,10 @@ export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatc
       oppDeck: { main: oppDeckMain || oppDeckSearch, sub: getSubValue(oppDeckSub, oppSubSearch) },
       playOrder,
       result,
+      outcomeReason,
+      coinToss: coinToss || undefined,
       note: note || undefined,
+      tags: parseTags(tags),
     }),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['matches'] })
@@ -180,7 +198,10 @@ export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatc
       oppDeck: { main: oppDeckMain || oppDeckSearch, sub: getSubValue(oppDeckSub, oppSubSearch) },
       playOrder,
       result,
+      outcomeReason,
+      coinToss,
       note: note || undefined,
+      tags: parseTags(tags),
     }),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['matches'] })
@@ -486,15 +507,65 @@ export default function MatchForm({ onCancel, onSuccess, defaultValues, editMatc
             <label className={labelClass}>結果</label>
             <select
               value={result}
-              onChange={(e) => setResult(e.target.value as 'W' | 'L')}
+              onChange={(e) => {
+                const next = e.target.value as 'W' | 'L' | 'D'
+                setResult(next)
+                // 投降原因與勝負不一致時改回一般
+                if ((outcomeReason === 'opp_surrender' && next !== 'W') || (outcomeReason === 'my_surrender' && next !== 'L')) {
+                  setOutcomeReason('normal')
+                }
+              }}
               className={inputClass}
             >
               <option value="W">勝 (W)</option>
               <option value="L">敗 (L)</option>
+              <option value="D">和 (D)</option>
             </select>
           </div>
         </div>
 
+        {/* 擲硬幣（選擇由先後攻推導：猜中＝自己選的，猜錯＝對手選的） */}
+        <div>
+          <label className={labelClass}>擲硬幣</label>
+          <select
+            value={coinToss}
+            onChange={(e) => setCoinToss(e.target.value as CoinToss | '')}
+            className={inputClass}
+          >
+            <option value="">未記錄</option>
+            <option value="won">猜中（自己選{playOrder}）</option>
+            <option value="lost">猜錯（對手選{playOrder === '先攻' ? '後攻' : '先攻'}）</option>
+          </select>
+        </div>
+
+        {/* 勝負原因 */}
+        <div>
+          <label className={labelClass}>勝負原因</label>
+          <select
+            value={outcomeReason}
+            onChange={(e) => setOutcomeReason(e.target.value as OutcomeReason)}
+            className={inputClass}
+          >
+            <option value="normal">一般</option>
+            <option value="opp_surrender" disabled={result !== 'W'}>對手投降</option>
+            <option value="my_surrender" disabled={result !== 'L'}>我方投降</option>
+            <option value="timeout">時間到</option>
+            <option value="disconnect">斷線</option>
+          </select>
+        </div>
+
+        {/* 標籤 */}
+        <div>
+          <label className={labelClass}>標籤</label>
+          <input
+            type="text"
+            value={tags}
+            onChange={(e) => setTags(e.target.value)}
+            placeholder="以逗號分隔，e.g. brick, misplay"
+            className={inputClass}
+          />
+        </div>
+
         {/* 備註 */}
         <div>
           <label className={labelClass}>備註</label>
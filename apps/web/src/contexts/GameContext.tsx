@@ -0,0 +1,40 @@
+import { createContext, useContext, useState } from 'react'
+import type { ReactNode } from 'react'
+import { useQueryClient } from '@tanstack/react-query'
+import { getCurrentGameKey, setCurrentGameKey } from '../services/gamesService'
+
+interface GameContextType {
+  gameKey: string
+  setGameKey: (key: string) => void
+}
+
+const GameContext = createContext<GameContextType | undefined>(undefined)
+
+export function GameProvider({ children }: { children: ReactNode }) {
+  const queryClient = useQueryClient()
+  // 從 localStorage 讀取，預設 Master Duel
+  const [gameKey, setGameKeyState] = useState(getCurrentGameKey)
+
+  const setGameKey = (key: string) => {
+    if (key === gameKey) return
+    // 先寫入 localStorage，API 攔截器才會帶上新的 gameKey
+    setCurrentGameKey(key)
+    setGameKeyState(key)
+    // 切換遊戲後，既有的對局、統計、模板快取都屬於舊遊戲
+    queryClient.invalidateQueries()
+  }
+
+  return (
+    <GameContext.Provider value={{ gameKey, setGameKey }}>
+      {children}
+    </GameContext.Provider>
+  )
+}
+
+export function useGame() {
+  const context = useContext(GameContext)
+  if (!context) {
+    throw new Error('useGame must be used within a GameProvider')
+  }
+  return context
+}
@@ -20,6 +20,16 @@ export default function DecksPage() {
     queryFn: () => decksService.getTemplates(),
   })
 
+  // 相似名稱建議（新增時提示可能重複的牌組）
+  const suggestQuery = newDeckName.trim()
+  const { data: suggestData } = useQuery({
+    queryKey: ['deck-templates', 'suggest', suggestQuery],
+    queryFn: () => decksService.suggestTemplates(suggestQuery),
+    enabled: showAddForm && !editingDeck && suggestQuery.length > 0,
+    staleTime: 30_000,
+  })
+  const suggestions = suggestData?.suggestions ?? []
+
   // 新增 mutation
   const createMutation = useMutation({
     mutationFn: (data: { name: string; theme: string; deckType: 'main' | 'sub' }) => 
@@ -152,6 +162,20 @@ export default function DecksPage() {
                       : 'bg-white border-gray-300 text-gray-900 focus:border-indigo-500'
                   } focus:outline-none`}
                 />
+                {!editingDeck && suggestions.length > 0 && (
+                  <div className={`mt-2 text-sm ${isDark ? 'text-amber-300' : 'text-amber-700'}`}>
+                    {suggestions[0].reason === 'exact' ? '已有相同的牌組：' : '是否為：'}
+                    {suggestions.map((s) => (
+                      <span
+                        key={s.id}
+                        className={`inline-block ml-1 px-2 py-0.5 rounded ${THEME_COLORS[s.theme as DeckTheme]?.bg ?? 'bg-gray-500'} ${THEME_COLORS[s.theme as DeckTheme]?.text ?? 'text-white'}`}
+                        title={s.alias ? `別名：${s.alias}` : undefined}
+                      >
+                        {s.name}
+                      </span>
+                    ))}
+                  </div>
+                )}
               </div>
             </div>
             {/* 主題類型選擇 - 按鈕式 */}
@@ -153,7 +153,9 @@ function VirtualizedTable({
                     <span className={`inline-flex items-center justify-center w-6 h-6 rounded-full text-xs font-bold ${
                       match.result === 'W'
                         ? 'bg-green-500/20 text-green-500'
-                        : 'bg-red-500/20 text-red-500'
+                        : match.result === 'D'
+                          ? 'bg-gray-500/20 text-gray-400'
+                          : 'bg-red-500/20 text-red-500'
                     }`}>
                       {match.result}
                     </span>
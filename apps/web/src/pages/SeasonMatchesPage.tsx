@@ -1,6 +1,7 @@
 import { useState, useMemo, useEffect } from 'react'
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
 import { matchesService } from '../services/matchesService'
+import { ranksService } from '../services/ranksService'
 import { decksService, THEME_COLORS, type DeckTheme } from '../services/decksService'
 import { useTheme } from '../contexts/ThemeContext'
 import MatchForm from '../components/MatchForm'
@@ -120,6 +121,13 @@ export default function SeasonMatchesPage() {
   })
 
   // 取得牌組模板資料
+  // 天梯階級變化（只在 Ranked 模式顯示；key 以 'matches' 開頭，新增/修改對局時一併更新）
+  const { data: progressionData } = useQuery({
+    queryKey: ['matches', 'rank-progression', selectedSeason],
+    queryFn: () => ranksService.getProgression({ seasonCode: selectedSeason }),
+    enabled: selectedMode === 'Ranked',
+  })
+
   const { data: deckTemplatesData } = useQuery({
     queryKey: ['deck-templates'],
     queryFn: () => decksService.getTemplates(),
@@ -362,6 +370,62 @@ export default function SeasonMatchesPage() {
         </div>
       </div>
 
+      {/* 階級變化（折線） */}
+      {selectedMode === 'Ranked' && progressionData && progressionData.total > 0 && (
+        <div className={`rounded-xl p-4 mb-4 ${isDark ? 'bg-[#1e1e26]' : 'bg-gray-50 border border-gray-200'}`}>
+          <div className="flex items-center justify-between mb-2">
+            <div className={`text-xs uppercase tracking-wider font-semibold ${isDark ? 'text-gray-400' : 'text-gray-600'}`}>階級變化</div>
+            {progressionData.peak && (
+              <div className={`text-sm ${isDark ? 'text-gray-500' : 'text-gray-500'}`}>
+                最高：<span className="font-bold text-indigo-400">{progressionData.peak.rank}</span>（{progressionData.peak.date}）
+              </div>
+            )}
+          </div>
+          <ReactECharts
+            style={{ height: 260 }}
+            option={{
+              backgroundColor: 'transparent',
+              grid: { left: 64, right: 16, top: 16, bottom: 32 },
+              tooltip: {
+                trigger: 'axis',
+                formatter: (ps: any) => {
+                  const p = progressionData.points[ps?.[0]?.dataIndex ?? 0]
+                  return p ? `${p.date}<br/>${p.rank}（${p.result === 'W' ? '勝' : p.result === 'D' ? '和' : '敗'}）` : ''
+                },
+              },
+              xAxis: {
+                type: 'category',
+                data: progressionData.points.map(p => p.date.slice(5)),
+                axisLabel: { color: isDark ? '#9ca3af' : '#4b5563' },
+              },
+              yAxis: {
+                type: 'value',
+                min: (v: { min: number }) => Math.max(1, v.min - 1),
+                max: (v: { max: number }) => Math.min(progressionData.ranks.length, v.max + 1),
+                interval: 1,
+                axisLabel: {
+                  color: isDark ? '#9ca3af' : '#4b5563',
+                  formatter: (v: number) => progressionData.ranks.find(r => r.ordinal === v)?.name ?? '',
+                },
+                splitLine: { lineStyle: { color: isDark ? '#ffffff10' : '#e5e7eb' } },
+              },
+              series: [
+                {
+                  type: 'line',
+                  step: 'end',
+                  symbolSize: 6,
+                  data: progressionData.points.map(p => ({
+                    value: p.ordinal,
+                    itemStyle: { color: p.result === 'W' ? '#22c55e' : '#ef4444' },
+                  })),
+                  lineStyle: { color: '#6366f1' },
+                },
+              ],
+            }}
+          />
+        </div>
+      )}
+
       {/* 圖表 + 表格 */}
       <div className="grid grid-cols-1 lg:grid-cols-2 gap-4 mb-4">
         {/* 對手牌組分布（圓餅） */}
@@ -804,7 +868,9 @@ export default function SeasonMatchesPage() {
                       <span className={`inline-flex items-center justify-center w-7 h-7 rounded-full text-xs font-bold ${
                         match.result === 'W'
                           ? 'bg-green-500/20 text-green-500'
-                          : 'bg-red-500/20 text-red-500'
+                          : match.result === 'D'
+                            ? 'bg-gray-500/20 text-gray-400'
+                            : 'bg-red-500/20 text-red-500'
                       }`}>
                         {match.result}
                       </span>
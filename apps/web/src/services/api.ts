@@ -1,5 +1,35 @@
 import axios from 'axios'
 
+// localStorage 中存放登入 token 的 key
+export const AUTH_TOKEN_KEY = 'duellog_token'
+
+// localStorage 中存放目前選擇遊戲的 key
+export const GAME_KEY_STORAGE_KEY = 'duellog_game'
+export const DEFAULT_GAME_KEY = 'master_duel'
+
+// 目前選擇的遊戲（對局、統計、牌組模板的查詢都需要 gameKey）
+export function getCurrentGameKey(): string {
+  return localStorage.getItem(GAME_KEY_STORAGE_KEY) || DEFAULT_GAME_KEY
+}
+
+export function setCurrentGameKey(key: string) {
+  localStorage.setItem(GAME_KEY_STORAGE_KEY, key)
+}
+
+// 後端錯誤回應格式（code 見 README「錯誤回應格式」）
+export interface ApiFieldError {
+  field: string // e.g. "myDeck.main"
+  code: 'required' | 'invalid_enum' | 'invalid_date' | 'too_long' | 'too_short' | 'invalid' | 'not_found'
+  message: string
+}
+
+export interface ApiError {
+  error: string
+  code: string // e.g. "VALIDATION_FAILED"
+  fields?: ApiFieldError[]
+  details?: string
+}
+
 // 建立 axios 實例
 const api = axios.create({
   baseURL: import.meta.env.VITE_API_BASE_URL || 'http://localhost:8080',
@@ -11,7 +41,15 @@ const api = axios.create({
 // 請求攔截器（可以加入 token 等）
 api.interceptors.request.use(
   (config) => {
-    // 未來可以在這裡加入 auth token
+    // 已登入時帶上 bearer token（未登入則由後端決定是否允許本機單人模式）
+    const token = localStorage.getItem(AUTH_TOKEN_KEY)
+    if (token) {
+      config.headers.Authorization = `Bearer ${token}`
+    }
+    // GET 查詢一律帶上目前的遊戲（已指定 gameKey 時不覆蓋）
+    if ((config.method ?? 'get').toLowerCase() === 'get' && config.params?.gameKey === undefined) {
+      config.params = { ...config.params, gameKey: getCurrentGameKey() }
+    }
     return config
   },
   (error) => {
@@ -0,0 +1,44 @@
+import api from './api'
+
+// 一筆變更紀錄（before/after 為變更前後的完整內容，新增時 before 為 null、刪除時 after 為 null）
+export interface AuditEntry {
+  id: string
+  entity: 'match' | 'deck_template'
+  entityId: string
+  action: 'create' | 'update' | 'delete'
+  userId: string // 做出變更的使用者
+  userEmail: string
+  before: Record<string, unknown> | null
+  after: Record<string, unknown> | null
+  changes?: string[] // 修改時有變動的欄位
+  revertOf: string | null // 由還原產生時為被還原的紀錄 ID
+  createdAt: string
+}
+
+export interface AuditHistoryResponse {
+  history: AuditEntry[] // 新的在前
+  total: number
+}
+
+export const auditService = {
+  // 對局的變更紀錄（已刪除的對局也查得到）
+  async getMatchHistory(id: string): Promise<AuditHistoryResponse> {
+    const response = await api.get<AuditHistoryResponse>(`/matches/${id}/history`)
+    return response.data
+  },
+
+  // 牌組模板的變更紀錄（所有使用者的變更）
+  async getDeckTemplateHistory(id: string): Promise<AuditHistoryResponse> {
+    const response = await api.get<AuditHistoryResponse>(`/deck-templates/${id}/history`)
+    return response.data
+  },
+
+  // 還原一筆變更；之後還有其他變更時回傳 409，force 為 true 時仍然還原
+  async revert(
+    auditId: string,
+    force = false
+  ): Promise<{ message: string; entity: AuditEntry['entity']; entityId: string; revertOf: string }> {
+    const response = await api.post(`/audit/${auditId}/revert`, force ? { force } : undefined)
+    return response.data
+  },
+}
@@ -1,4 +1,4 @@
-import api from './api'
+import api, { getCurrentGameKey } from './api'
 
 export interface DeckTemplate {
   id: string
@@ -13,7 +13,24 @@ interface GetDeckTemplatesResponse {
   total: number
 }
 
+export interface DeckSuggestion {
+  id: string
+  name: string
+  theme: string
+  deckType: 'main' | 'sub'
+  alias?: string
+  score: number
+  reason: 'exact' | 'prefix' | 'contains' | 'similar'
+}
+
+interface SuggestDeckTemplatesResponse {
+  query: string
+  suggestions: DeckSuggestion[]
+  total: number
+}
+
 interface CreateDeckTemplateRequest {
+  gameKey?: string // 預設為目前選擇的遊戲
   name: string
   theme: string
   deckType: 'main' | 'sub'
@@ -31,8 +48,14 @@ export const decksService = {
     return response.data
   },
 
+  // 相似名稱建議（忽略全形半形、大小寫），新增前檢查是否已有相同牌組
+  async suggestTemplates(q: string, limit = 5): Promise<SuggestDeckTemplatesResponse> {
+    const response = await api.get<SuggestDeckTemplatesResponse>('/deck-templates/suggest', { params: { q, limit } })
+    return response.data
+  },
+
   async createTemplate(data: CreateDeckTemplateRequest): Promise<{ id: string; message: string }> {
-    const response = await api.post('/deck-templates', data)
+    const response = await api.post('/deck-templates', { gameKey: getCurrentGameKey(), ...data })
     return response.data
   },
 
@@ -0,0 +1,22 @@
+import api from './api'
+
+export { getCurrentGameKey, setCurrentGameKey, DEFAULT_GAME_KEY } from './api'
+
+export interface Game {
+  id: string
+  key: string // e.g. "master_duel", "ptcg"
+  name: string
+  matches: number
+}
+
+interface GetGamesResponse {
+  games: Game[]
+  total: number
+}
+
+export const gamesService = {
+  async getGames(): Promise<GetGamesResponse> {
+    const response = await api.get<GetGamesResponse>('/games')
+    return response.data
+  },
+}
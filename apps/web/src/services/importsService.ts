@@ -0,0 +1,85 @@
+import api, { getCurrentGameKey } from './api'
+
+// 可對應的欄位
+export type ImportField =
+  | 'rank' | 'account' | 'myMain' | 'mySub' | 'result' | 'playOrder'
+  | 'oppMain' | 'oppSub' | 'note' | 'date' | 'season' | 'mode'
+
+// 欄位對應：標題名稱或欄位索引（0 起算）
+export type ImportMapping = Partial<Record<ImportField, string | number>>
+
+export interface ImportOptions {
+  gameKey?: string // 預設為目前選擇的遊戲
+  delimiter?: 'comma' | 'tab' // 未指定時自動判斷
+  noHeader?: boolean
+  mapping?: ImportMapping // 未指定時使用 cmd/import 的欄位順序
+}
+
+export interface ImportRowError {
+  line: number
+  reason: string
+  raw: string[]
+}
+
+export interface ImportPreviewRow {
+  line: number
+  status: 'new' | 'duplicate'
+  date: string
+  seasonCode: string
+  mode: 'Ranked' | 'Rating' | 'DC'
+  rank: string
+  rankMapped: boolean
+  myDeck: { main: string; sub: string | null }
+  oppDeck: { main: string; sub: string | null }
+  playOrder: '先攻' | '後攻'
+  result: 'W' | 'L' | 'D'
+  note: string | null
+}
+
+export interface ImportReport {
+  dryRun: boolean
+  rows: number
+  imported: number
+  duplicates: number
+  failed: number
+  newSeasons: string[]
+  newDecks: string[]
+  unmappedRanks: Record<string, number>
+  errors: ImportRowError[]
+  preview?: ImportPreviewRow[]
+}
+
+// 上傳檔案用 multipart，貼上的文字用 JSON
+function buildBody(input: File | string, options: ImportOptions) {
+  options = { gameKey: getCurrentGameKey(), ...options }
+  if (typeof input === 'string') {
+    return { text: input, ...options }
+  }
+  const form = new FormData()
+  form.append('file', input)
+  if (options.gameKey) form.append('gameKey', options.gameKey)
+  if (options.delimiter) form.append('delimiter', options.delimiter)
+  if (options.noHeader) form.append('noHeader', 'true')
+  if (options.mapping) form.append('mapping', JSON.stringify(options.mapping))
+  return form
+}
+
+// api 預設 Content-Type 為 JSON，上傳檔案時需改為 multipart（axios 會補上 boundary）
+function requestConfig(input: File | string) {
+  return typeof input === 'string' ? {} : { headers: { 'Content-Type': 'multipart/form-data' } }
+}
+
+// Imports API Service
+export const importsService = {
+  // 預覽（不寫入）
+  async preview(input: File | string, options: ImportOptions = {}): Promise<ImportReport> {
+    const response = await api.post<ImportReport>('/imports', buildBody(input, options), requestConfig(input))
+    return response.data
+  },
+
+  // 寫入（已存在的對局會略過）
+  async commit(input: File | string, options: ImportOptions = {}): Promise<ImportReport> {
+    const response = await api.post<ImportReport>('/imports/commit', buildBody(input, options), requestConfig(input))
+    return response.data
+  },
+}
@@ -1,16 +1,106 @@
 import api from './api'
 import type { MatchesResponse, CreateMatchRequest, UpdateMatchRequest } from '../types/match'
 
+// 統計摘要（比率 0~1，分母為 0 時為 null）
+export interface StatsSummary {
+  total: number
+  wins: number
+  draws: number
+  first: number
+  second: number
+  firstWins: number
+  secondWins: number
+  firstRate: number | null
+  winRate: number | null
+  firstWinRate: number | null
+  secondWinRate: number | null
+}
+
+// 換 side 前（第 1 局）與換 side 後（第 2、3 局）的勝率
+export interface SideStatsResponse {
+  matches: number
+  preSide: StatsSummary
+  postSide: StatsSummary
+  games: (StatsSummary & { gameNo: number })[]
+}
+
 // 查詢參數介面
-interface GetMatchesParams {
+export interface GetMatchesParams {
   seasonCode?: string
   myDeckMain?: string
   oppDeckMain?: string
-  result?: 'W' | 'L'
+  result?: 'W' | 'L' | 'D'
   playOrder?: '先攻' | '後攻'
   dateFrom?: string
   dateTo?: string
   mode?: 'Ranked' | 'Rating' | 'DC'
+  // 階級範圍（含），e.g. rankMin: '金 V', rankMax: '鑽石 I'
+  rankMin?: string
+  rankMax?: string
+  // 勝負原因（逗號分隔），e.g. excludeOutcomes: 'opp_surrender,disconnect' 排除白勝與斷線
+  outcomeReason?: string
+  excludeOutcomes?: string
+  // 3：只看有各局記錄的三戰兩勝，1：只看單局
+  bestOf?: 1 | 3
+  // 擲硬幣結果與猜中的一方的選擇
+  coinToss?: 'won' | 'lost'
+  choice?: '先攻' | '後攻'
+  // 標籤（逗號分隔）；tagMode all 為全部標籤都有，預設 any 為有任一個
+  // e.g. excludeTags: 'disconnect' 排除斷線的對局
+  tags?: string
+  tagMode?: 'any' | 'all'
+  excludeTags?: string
+  // 搜尋備註（詞之間為 AND），e.g. q: '卡手 "turn 2" misp*'
+  q?: string
+  // 分頁（未指定 limit 時回傳全部）
+  limit?: number
+  offset?: number
+  // 排序欄位，逗號分隔，前綴 - 表示遞減，e.g. '-date,oppDeck'
+  sort?: string
+  // 只回傳指定欄位，逗號分隔
+  fields?: string
+}
+
+// 擲硬幣結果 × 選擇的勝率
+export interface TossChoiceStats {
+  coinToss: 'won' | 'lost'
+  choice: '先攻' | '後攻'
+  playOrder: '先攻' | '後攻' // 自己的先後攻
+  games: number
+  wins: number
+  losses: number
+  draws: number
+  winRate: number | null
+  ci: { lower: number; upper: number }
+}
+
+export interface CoinTossStatsResponse {
+  recorded: number
+  unrecorded: number
+  won: number
+  lost: number
+  tossWinRate: number | null
+  ci: { lower: number; upper: number }
+  pValue: number // 猜中率是否偏離 50% 的雙尾二項檢定
+  significant: boolean // pValue < 0.05
+  byChoice: TossChoiceStats[]
+}
+
+// 各標籤的勝率（othersRate 為沒有此標籤的對局勝率）
+export interface TagStats {
+  tag: string
+  games: number
+  wins: number
+  losses: number
+  draws: number
+  winRate: number | null
+  pct: number // 佔篩選後對局的比例
+  othersRate: number | null
+}
+
+export interface TagStatsResponse {
+  tags: TagStats[]
+  summary: StatsSummary
 }
 
 // Matches API Service
@@ -33,6 +123,42 @@ export const matchesService = {
     return response.data
   },
 
+  // 匯出對局（篩選條件同 getMatches；csv 可直接用匯入功能重新匯入）
+  async exportMatches(
+    format: 'csv' | 'json' | 'xlsx',
+    params?: Omit<GetMatchesParams, 'limit' | 'offset' | 'fields'>
+  ): Promise<Blob> {
+    const response = await api.get<Blob>('/exports/matches', {
+      params: { ...params, format },
+      responseType: 'blob',
+    })
+    return response.data
+  },
+
+  // 換 side 前後的勝率（只統計三戰兩勝的對局）
+  async getSideStats(
+    params?: Omit<GetMatchesParams, 'limit' | 'offset' | 'sort' | 'fields'>
+  ): Promise<SideStatsResponse> {
+    const response = await api.get<SideStatsResponse>('/stats/side', { params })
+    return response.data
+  },
+
+  // 擲硬幣統計（只統計有記錄擲硬幣的對局）
+  async getCoinTossStats(
+    params?: Omit<GetMatchesParams, 'limit' | 'offset' | 'sort' | 'fields'>
+  ): Promise<CoinTossStatsResponse> {
+    const response = await api.get<CoinTossStatsResponse>('/stats/coin-toss', { params })
+    return response.data
+  },
+
+  // 各標籤的勝率
+  async getTagStats(
+    params?: Omit<GetMatchesParams, 'limit' | 'offset' | 'sort' | 'fields'>
+  ): Promise<TagStatsResponse> {
+    const response = await api.get<TagStatsResponse>('/stats/tags', { params })
+    return response.data
+  },
+
   // 刪除對局
   async deleteMatch(id: string): Promise<{ message: string }> {
     const response = await api.delete(`/matches/${id}`)
@@ -0,0 +1,54 @@
+import api from './api'
+import type { GetMatchesParams } from './matchesService'
+
+// 階級（遊戲的天梯），ordinal 越大越高
+export interface Rank {
+  id: string
+  mode: 'Ranked' | 'Rating' | 'DC'
+  tier: string // e.g. "金"
+  division: number | null // e.g. 4
+  name: string // e.g. "金 IV"
+  ordinal: number
+}
+
+export interface RankPoint {
+  id: string
+  date: string
+  seasonCode: string
+  rank: string
+  ordinal: number
+  result: 'W' | 'L' | 'D'
+}
+
+export interface RankDay {
+  date: string
+  start: number
+  end: number
+  high: number
+  low: number
+  matches: number
+}
+
+export interface RankProgressionResponse {
+  ranks: Rank[]
+  points: RankPoint[]
+  daily: RankDay[]
+  peak: RankPoint | null
+  total: number
+}
+
+export const ranksService = {
+  // 目前遊戲的階梯（由低到高）
+  async getRanks(mode: Rank['mode'] = 'Ranked'): Promise<{ ranks: Rank[]; total: number }> {
+    const response = await api.get('/ranks', { params: { mode } })
+    return response.data
+  },
+
+  // 天梯階級變化（篩選條件同 getMatches）
+  async getProgression(
+    params?: Omit<GetMatchesParams, 'limit' | 'offset' | 'sort' | 'fields'>
+  ): Promise<RankProgressionResponse> {
+    const response = await api.get<RankProgressionResponse>('/stats/rank-progression', { params })
+    return response.data
+  },
+}
@@ -0,0 +1,62 @@
+import api, { getCurrentGameKey } from './api'
+
+export interface Season {
+  id: string
+  code: string // e.g. "S49"、"2026-01"
+  startDate: string | null // YYYY-MM-DD
+  endDate: string | null
+  closedAt: string | null // 結束時間，進行中為 null
+  matches: number // 目前使用者在該賽季的對局數
+}
+
+// 賽季日期範圍的問題（重疊、空檔、沒有日期）
+export interface SeasonIssue {
+  type: 'overlap' | 'gap' | 'undated'
+  seasons: string[]
+  from?: string
+  to?: string
+  message: string
+}
+
+interface GetSeasonsResponse {
+  seasons: Season[]
+  issues: SeasonIssue[]
+  total: number
+}
+
+interface CreateSeasonRequest {
+  gameKey?: string // 預設為目前選擇的遊戲
+  code: string
+  startDate?: string // 代碼為 YYYY-MM 時可省略
+  endDate?: string
+}
+
+interface UpdateSeasonRequest {
+  code?: string
+  startDate?: string // 空字串表示清除
+  endDate?: string
+  closed?: boolean // false 重新開啟
+}
+
+export const seasonsService = {
+  async getSeasons(): Promise<GetSeasonsResponse> {
+    const response = await api.get<GetSeasonsResponse>('/seasons')
+    return response.data
+  },
+
+  async createSeason(data: CreateSeasonRequest): Promise<{ id: string; message: string; warnings: SeasonIssue[] }> {
+    const response = await api.post('/seasons', { gameKey: getCurrentGameKey(), ...data })
+    return response.data
+  },
+
+  async updateSeason(id: string, data: UpdateSeasonRequest): Promise<{ message: string; warnings: SeasonIssue[] }> {
+    const response = await api.patch(`/seasons/${id}`, data)
+    return response.data
+  },
+
+  // 結束賽季（之後不能再新增對局到該賽季）；endDate 省略時為今天
+  async closeSeason(id: string, endDate?: string): Promise<{ message: string; endDate: string; warnings: SeasonIssue[] }> {
+    const response = await api.post(`/seasons/${id}/close`, endDate ? { endDate } : {})
+    return response.data
+  },
+}
@@ -0,0 +1,50 @@
+import api from './api'
+
+// 對局標籤（名稱統一為小寫）
+export interface Tag {
+  id: string
+  name: string
+  matches: number // 有此標籤的對局數
+  createdAt: string
+}
+
+export interface TagMergeSummary {
+  sources: string[]
+  target: string
+  created: boolean // target 原本不存在，由第一個來源標籤改名而來
+  affectedMatches: number
+}
+
+export const tagsService = {
+  async getTags(): Promise<{ tags: Tag[]; total: number }> {
+    const response = await api.get('/tags')
+    return response.data
+  },
+
+  async createTag(name: string): Promise<{ id: string; name: string; message: string }> {
+    const response = await api.post('/tags', { name })
+    return response.data
+  },
+
+  // 新名稱已存在時回傳 409，請改用 mergeTags
+  async renameTag(id: string, name: string): Promise<{ message: string; from: string; to: string }> {
+    const response = await api.patch(`/tags/${id}`, { name })
+    return response.data
+  },
+
+  // 刪除標籤（對局本身保留）
+  async deleteTag(id: string): Promise<{ message: string; matches: number }> {
+    const response = await api.delete(`/tags/${id}`)
+    return response.data
+  },
+
+  // 將 sources 合併為 target；dryRun 只預覽
+  async mergeTags(
+    sources: string[],
+    target: string,
+    dryRun = false
+  ): Promise<{ dryRun: boolean; summary: TagMergeSummary }> {
+    const response = await api.post('/tags/merge', { sources, target, dryRun })
+    return response.data
+  },
+}
@@ -6,29 +6,59 @@ export interface DeckInfo {
   sub: string | null
 }
 
+// 勝負原因：一般、對手投降、我方投降、時間到、斷線
+export type OutcomeReason = 'normal' | 'opp_surrender' | 'my_surrender' | 'timeout' | 'disconnect'
+
+// 擲硬幣：猜中 / 猜錯
+export type CoinToss = 'won' | 'lost'
+
 export interface Match {
   id: string
   date: string
   mode: 'Ranked' | 'Rating' | 'DC'
   rank: string
+  rankId: string | null // 對應的階級（遊戲沒有階梯或非天梯模式時為 null）
+  rankOrdinal: number | null // 階級在階梯中的順序，越大越高
   myDeck: DeckInfo
   oppDeck: DeckInfo
   playOrder: '先攻' | '後攻'
-  result: 'W' | 'L'
+  result: 'W' | 'L' | 'D'
+  outcomeReason: OutcomeReason
+  coinToss: CoinToss | null // 擲硬幣（沒有記錄時為 null）
+  choice: '先攻' | '後攻' | null // 猜中硬幣的一方選擇的先後攻
   note: string | null
   seasonCode: string
+  games?: MatchGame[] // 三戰兩勝的各局（單局對局沒有此欄位）
+  tags?: string[] // 標籤（沒有標籤時沒有此欄位）
+  snippet?: string // 備註搜尋 q 的符合片段，符合處以 <mark></mark> 標示（其餘已 HTML 跳脫）
   createdAt: string
   updatedAt: string
 }
 
+// 三戰兩勝中的一局（gameNo 1 為換 side 前）
+export interface MatchGame {
+  gameNo: number
+  playOrder: '先攻' | '後攻'
+  result: 'W' | 'L' | 'D'
+  sideIn: string | null // 換入的卡（自由文字）
+  sideOut: string | null // 換出的卡（自由文字）
+  note: string | null
+}
+
+// 新增/更新時的一局（局數依陣列順序，第 1 局不能記錄換 side）
+export type MatchGameForm = Omit<MatchGame, 'gameNo'>
+
 export interface MatchesResponse {
   matches: Match[]
-  total: number
+  total: number // 符合篩選條件的總筆數（不受分頁影響）
+  limit: number // 0 表示未分頁
+  offset: number
+  hasMore: boolean
 }
 
 export interface CreateMatchRequest {
   gameKey: string
-  seasonCode: string
+  seasonCode?: string // 省略時依 date 推斷賽季
   date: string
   mode?: 'Ranked' | 'Rating' | 'DC'
   rank: string
@@ -40,12 +70,20 @@ export interface CreateMatchRequest {
     main: string
     sub: string | null
   }
-  playOrder: '先攻' | '後攻'
-  result: 'W' | 'L'
+  // 有 games 時 playOrder/result 可省略，由各局推導
+  playOrder?: '先攻' | '後攻'
+  result?: 'W' | 'L' | 'D'
+  outcomeReason?: OutcomeReason // 省略時為 normal；opp_surrender 需為 W、my_surrender 需為 L
+  // 擲硬幣；choice 可省略（由 coinToss 與 playOrder 推導），省略 playOrder 時由 coinToss 與 choice 推導
+  coinToss?: CoinToss
+  choice?: '先攻' | '後攻'
   note?: string
+  games?: MatchGameForm[] // 三戰兩勝的各局（最多 3 局）
+  tags?: string[] // 標籤名稱（不存在的標籤會建立，最多 20 個）
 }
 
 export interface UpdateMatchRequest {
+  seasonCode?: string
   date?: string
   mode?: 'Ranked' | 'Rating' | 'DC'
   rank?: string
@@ -58,6 +96,11 @@ export interface UpdateMatchRequest {
     sub: string | null
   }
   playOrder?: '先攻' | '後攻'
-  result?: 'W' | 'L'
+  result?: 'W' | 'L' | 'D'
+  outcomeReason?: OutcomeReason
+  coinToss?: CoinToss | '' // 空字串表示清除
+  choice?: '先攻' | '後攻'
   note?: string
+  games?: MatchGameForm[] // 取代所有局的記錄（空陣列表示改回單局）
+  tags?: string[] // 取代所有標籤（空陣列表示清除）
 }
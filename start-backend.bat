@@ -16,7 +16,9 @@ cd /d "%~dp0apps\api" || (
   exit /b 1
 )
 
-go run .
+rem 本機單人使用：未登入的請求以本機帳號執行（多人共用伺服器時請拿掉這行）
+set AUTH_REQUIRED=false
+go run -tags sqlite_fts5 .
 
 popd
 endlocal